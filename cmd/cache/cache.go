@@ -0,0 +1,25 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package cache
+
+import (
+	"parm/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCacheCmd(f *cmdutil.Factory) *cobra.Command {
+	var cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manages the local cache of GitHub API responses",
+		Long: `Parm caches responses from the GitHub API and revalidates them with conditional requests,
+which don't count against the API rate limit. Pass --no-cache to any command to bypass the cache.`,
+	}
+
+	cacheCmd.AddCommand(
+		NewCleanCmd(f),
+	)
+
+	return cacheCmd
+}
@@ -0,0 +1,34 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package cache
+
+import (
+	"fmt"
+	"parm/internal/cmdutil"
+	"parm/internal/config"
+	"parm/internal/gh"
+
+	"github.com/spf13/cobra"
+)
+
+func NewCleanCmd(f *cmdutil.Factory) *cobra.Command {
+	var cleanCmd = &cobra.Command{
+		Use:   "clean",
+		Short: "Removes every cached GitHub API response",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := config.GetHTTPCacheDir()
+			if err != nil {
+				return err
+			}
+			if err := gh.CleanCache(dir); err != nil {
+				return fmt.Errorf("cannot remove cache dir %s: \n%w", dir, err)
+			}
+			fmt.Printf("Removed cache at %s\n", dir)
+			return nil
+		},
+	}
+
+	return cleanCmd
+}
@@ -27,7 +27,7 @@ func NewConfigureCmd(f *cmdutil.Factory) *cobra.Command {
 			}
 			sorted := slices.Sorted(maps.Keys(settings))
 			for _, k := range sorted {
-				fmt.Printf("%s: %s\n", k, settings[k])
+				fmt.Printf("%s: %v\n", k, settings[k])
 			}
 
 			return nil
@@ -0,0 +1,52 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package export
+
+import (
+	"fmt"
+	"os"
+	"parm/internal/cmdutil"
+	"parm/internal/core/catalog"
+	"parm/internal/parmfile"
+
+	"github.com/spf13/cobra"
+)
+
+func NewExportCmd(f *cmdutil.Factory) *cobra.Command {
+	var output string
+
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Writes every installed package to a parmfile",
+		Long: `Writes every installed package, along with its channel, version and pinned status,
+to a parmfile that can be applied on another machine with "parm sync".`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mans, err := catalog.GetAllPkgManifest()
+			if err != nil {
+				return err
+			}
+
+			pf := parmfile.FromManifests(mans)
+			if output == "-" {
+				data, err := pf.Marshal()
+				if err != nil {
+					return err
+				}
+				_, err = os.Stdout.Write(data)
+				return err
+			}
+
+			if err := pf.Write(output); err != nil {
+				return fmt.Errorf("cannot write parmfile: \n%w", err)
+			}
+			fmt.Printf("Exported %d packages to %s\n", len(pf.Packages), output)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&output, "output", "o", parmfile.DefaultFileName, "Path to write the parmfile to. Use \"-\" to print to stdout.")
+
+	return exportCmd
+}
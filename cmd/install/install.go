@@ -6,8 +6,10 @@ package install
 import (
 	"fmt"
 	"io"
+	"os"
 	"parm/internal/cmdutil"
 	"parm/internal/core/installer"
+	"parm/internal/core/verify"
 	"parm/internal/gh"
 	"parm/internal/manifest"
 	"parm/internal/parmutil"
@@ -30,6 +32,12 @@ func NewInstallCmd(f *cmdutil.Factory) *cobra.Command {
 	var asset string
 	var strict bool
 	var no_verify bool
+	var verifyLevel string
+	var yes bool
+	var dryRun bool
+	var bins []string
+	var renames map[string]string
+	var takeBins []string
 
 	// installCmd represents the install command
 	var installCmd = &cobra.Command{
@@ -137,25 +145,57 @@ func NewInstallCmd(f *cmdutil.Factory) *cobra.Command {
 				Callback: nil,
 			}
 
+			level := verify.LevelNone
+			if !no_verify {
+				level, err = verify.ParseLevel(verifyLevel)
+				if err != nil {
+					return err
+				}
+			}
+
 			var ass *string
+			var assPattern *string
+			old, oldErr := manifest.Read(parmutil.GetInstallDir(owner, repo))
 			if asset == "" {
 				ass = nil
+				// reinstalls pick the same asset as the previous install
+				if oldErr == nil && old.Asset != nil {
+					assPattern = &old.Asset.Pattern
+				}
 			} else {
 				ass = &asset
 			}
+			if oldErr != nil {
+				old = nil
+			}
+			bins, renames := installer.BinSelection(owner, repo, bins, renames, old)
+			for name, alias := range renames {
+				if alias == "" || filepath.Base(alias) != alias {
+					return fmt.Errorf("cannot link %s as %q: not a file name", name, alias)
+				}
+			}
 
 			opts := installer.InstallFlags{
-				Type:    insType,
-				Version: version,
-				Asset:   ass,
-				Strict:  strict,
-				VerifyLevel: func() uint8 {
-					if no_verify {
-						return 0
-					}
-					// TODO: change to actual verify-level once implemented
-					return 1
-				}(),
+				Type:         insType,
+				Version:      version,
+				Asset:        ass,
+				AssetPattern: assPattern,
+				Picker:       cmdutil.NewAssetPicker(yes),
+				Strict:       strict,
+				VerifyLevel:  level,
+				Bins:         bins,
+				Renames:      renames,
+				TakeBins:     takeBins,
+			}
+
+			pkgPath := parmutil.GetPkgDir(owner, repo)
+			if dryRun {
+				plan, err := inst.Plan(ctx, owner, repo, pkgPath, opts)
+				if err != nil {
+					return gh.ExplainRateLimit(err)
+				}
+				cmdutil.PrintInstallPlan(os.Stdout, plan)
+				return nil
 			}
 
 			if opts.Version == nil {
@@ -164,42 +204,35 @@ func NewInstallCmd(f *cmdutil.Factory) *cobra.Command {
 				fmt.Printf("Installing %s/%s::%s\n", owner, repo, *opts.Version)
 			}
 
-			installPath := parmutil.GetInstallDir(owner, repo)
-			res, err := inst.Install(ctx, owner, repo, installPath, opts, hooks)
+			res, err := inst.Install(ctx, owner, repo, pkgPath, opts, hooks)
 			pb.Wait()
 			if err != nil {
-				if res == nil {
-					return err
-				}
-				parentDir, cErr := sysutil.GetParentDir(res.InstallPath)
+				parentDir, cErr := sysutil.GetParentDir(pkgPath)
 				if cErr == nil {
-					err = parmutil.Cleanup(parentDir)
-					if err != nil {
-						return err
+					if cErr = parmutil.Cleanup(parentDir); cErr != nil {
+						return cErr
 					}
 				}
-				return err
+				return gh.ExplainRateLimit(err)
 			}
 
 			man, err := manifest.New(owner, repo, res.Version, opts.Type, res.InstallPath)
 			if err != nil {
+				_ = res.Abort()
 				return fmt.Errorf("failed to create manifest: \n%w", err)
 			}
-			err = man.Write(res.InstallPath)
-			if err != nil {
+			man.Asset = res.Asset
+			man.Signer = res.Signer
+			man.VerifyLevel = res.VerifyLevel.String()
+			man.Bins = bins
+			man.Renames = renames
+
+			// other installed versions are kept, switch back to them with "parm use"
+			if err := res.Commit(man); err != nil {
 				return err
 			}
 
-			binPaths := man.GetFullExecPaths()
-
-			for _, execPath := range binPaths {
-				pathToSymLinkTo := parmutil.GetBinDir(filepath.Base(execPath))
-
-				// TODO: use shims for windows instead?
-				err = sysutil.SymlinkBinToPath(execPath, pathToSymLinkTo)
-				if err != nil {
-					return err
-				}
+			for _, execPath := range man.GetLinkedExecPaths() {
 				deps, err := deps.GetMissingLibs(ctx, execPath)
 				if err != nil {
 					return err
@@ -222,11 +255,20 @@ func NewInstallCmd(f *cmdutil.Factory) *cobra.Command {
 	installCmd.Flags().BoolVarP(&pre_release, "pre-release", "p", false, "Installs the latest pre-release binary, if available")
 	installCmd.Flags().BoolVarP(&strict, "strict", "s", false, "Only available with the --pre-release flag. Will only install pre-release versions and not stable releases.")
 	installCmd.Flags().BoolVarP(&no_verify, "no-verify", "n", false, "Skips integrity check")
+	installCmd.Flags().StringVar(&verifyLevel, "verify", verify.LevelChecksum.String(), "How strictly to verify the asset: none, digest (if the release has a digest or checksum file), checksum (require one), signature or attestation")
 	installCmd.Flags().StringVarP(&release, "release", "r", "", "Install binary from this release tag.")
 	installCmd.Flags().StringVarP(&asset, "asset", "a", "", "Installs a specific asset from a release.")
+	installCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Never prompt for an asset; fail if the asset to install is ambiguous")
+
+	installCmd.Flags().StringSliceVar(&bins, "bin", nil, "Only links these executables, by name or path in the asset, e.g. --bin nvim")
+	installCmd.Flags().StringToStringVar(&renames, "rename", nil, "Links an executable under another name, e.g. tool-linux-amd64=tool, or fd=fdfind when its name is taken by another package")
+	installCmd.Flags().StringSliceVar(&takeBins, "take-bin", nil, "Links these executables even if another package owns their name; it gets the link back once this package is removed")
+
+	installCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Shows which release and asset would be installed and which links would change, without downloading anything")
 
 	installCmd.MarkFlagsMutuallyExclusive("release", "pre-release")
 	installCmd.MarkFlagsMutuallyExclusive("release", "strict")
+	installCmd.MarkFlagsMutuallyExclusive("verify", "no-verify")
 
 	return installCmd
 }
@@ -0,0 +1,134 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package outdated
+
+import (
+	"fmt"
+	"os"
+	"parm/internal/cmdutil"
+	"parm/internal/core/catalog"
+	"parm/internal/core/installer"
+	"parm/internal/core/updater"
+	"parm/internal/gh"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"parm/pkg/cmdparser"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewOutdatedCmd(f *cmdutil.Factory) *cobra.Command {
+	var strict bool
+	var jobs int
+
+	var outdatedCmd = &cobra.Command{
+		Use:   "outdated [<owner>/<repo>...]",
+		Short: "Lists packages that have a newer version available",
+		Long: `Checks every installed package, or only the given ones, for a newer release on its channel without installing anything.
+Exits with a non-zero status if any package that isn't pinned is outdated.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if jobs < 1 {
+				return fmt.Errorf("--jobs must be at least 1")
+			}
+
+			var mans []*manifest.Manifest
+			if len(args) == 0 {
+				var err error
+				mans, err = catalog.GetAllPkgManifest()
+				if err != nil {
+					return fmt.Errorf("failed to retrieve packages: \n%w", err)
+				}
+			}
+			for _, arg := range args {
+				owner, repo, err := cmdparser.ParseRepoRef(arg)
+				if err != nil {
+					owner, repo, err = cmdparser.ParseGithubUrlPattern(arg)
+					if err != nil {
+						return err
+					}
+				}
+				man, err := manifest.Read(parmutil.GetInstallDir(owner, repo))
+				if err != nil {
+					return fmt.Errorf("%s/%s is not installed", owner, repo)
+				}
+				mans = append(mans, man)
+			}
+			if len(mans) == 0 {
+				fmt.Println("no packages installed")
+				return nil
+			}
+
+			token, _ := gh.GetStoredApiKey(viper.GetViper())
+			provider := f.Provider(ctx, token)
+			client := provider.Repos()
+			up := updater.New(client, installer.New(client))
+			// the GraphQL API doesn't allow unauthenticated requests
+			if token != "" {
+				up.WithBulkLookup(provider)
+			}
+
+			flags := updater.UpdateFlags{Strict: strict}
+			if err := gh.EnsureBudget(ctx, provider, up.LookupCost(mans, &flags)); err != nil {
+				return err
+			}
+			results := up.CheckOutdated(ctx, mans, &flags, jobs)
+
+			numOutdated, numFailed := 0, 0
+			var rows []string
+			for _, res := range results {
+				man := res.Manifest
+				if res.Err == nil && !res.Outdated() {
+					continue
+				}
+
+				latest, note := res.Latest, ""
+				switch {
+				case res.Err != nil:
+					latest = "?"
+					numFailed++
+				case man.Pinned:
+					note = "(pinned)"
+				default:
+					numOutdated++
+				}
+				rows = append(rows, fmt.Sprintf("%s/%s\t%s\t%s\t%s\t%s", man.Owner, man.Repo, man.InstallType, man.Version, latest, note))
+			}
+
+			if len(rows) == 0 {
+				fmt.Println("All packages are up to date.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PACKAGE\tCHANNEL\tINSTALLED\tAVAILABLE\t")
+			for _, row := range rows {
+				fmt.Fprintln(w, row)
+			}
+			w.Flush()
+
+			for _, res := range results {
+				if res.Err != nil {
+					fmt.Fprintf(os.Stderr, "error: could not check %s/%s:\n\t%q\n", res.Manifest.Owner, res.Manifest.Repo, res.Err)
+				}
+			}
+
+			cmd.SilenceUsage = true
+			if numOutdated > 0 {
+				return fmt.Errorf("%d package(s) outdated", numOutdated)
+			}
+			if numFailed > 0 {
+				return fmt.Errorf("could not check %d package(s)", numFailed)
+			}
+			return nil
+		},
+	}
+
+	outdatedCmd.Flags().BoolVarP(&strict, "strict", "s", false, "Only applies to pre-release channels. Compares against the latest pre-release only, even if a newer stable release exists.")
+	outdatedCmd.Flags().IntVarP(&jobs, "jobs", "j", 4, "Number of packages to check at once.")
+
+	return outdatedCmd
+}
@@ -0,0 +1,26 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+
+// The launcher parm copies under the name of a command when link_mode is "shim".
+// It runs the executable named in the ".shim" file next to it.
+package main
+
+import (
+	"fmt"
+	"os"
+	"parm/pkg/shim"
+)
+
+func main() {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "parm-shim:", err)
+		os.Exit(1)
+	}
+	code, err := shim.Run(exe, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "parm-shim:", err)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,30 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package plan
+
+import (
+	"parm/cmd/install"
+	"parm/cmd/remove"
+	"parm/cmd/update"
+	"parm/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+func NewPlanCmd(f *cmdutil.Factory) *cobra.Command {
+	var planCmd = &cobra.Command{
+		Use:   "plan",
+		Short: "Shows what install, update or remove would do without changing anything",
+		Long: `Runs install, update or remove with --dry-run: resolves the release and asset that would be used
+and prints them along with the install dir and the links that would change, without downloading anything.`,
+	}
+
+	for _, sub := range []*cobra.Command{install.NewInstallCmd(f), update.NewUpdateCmd(f), remove.NewRemoveCmd(f)} {
+		_ = sub.Flags().Set("dry-run", "true")
+		_ = sub.Flags().MarkHidden("dry-run")
+		planCmd.AddCommand(sub)
+	}
+
+	return planCmd
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package ratelimit
+
+import (
+	"fmt"
+	"os"
+	"parm/internal/cmdutil"
+	"parm/internal/gh"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewRateLimitCmd(f *cmdutil.Factory) *cobra.Command {
+	var rateLimitCmd = &cobra.Command{
+		Use:   "ratelimit",
+		Short: "Shows the remaining GitHub API requests for the current token",
+		Long: `Shows how many GitHub API requests are left for the token parm is configured with, and when the limits reset.
+Checking the rate limit doesn't count against it.`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			token, err := gh.GetStoredApiKey(viper.GetViper())
+			if err != nil {
+				fmt.Println("No GitHub token found, showing limits for unauthenticated requests.")
+			}
+
+			limits, err := f.Provider(ctx, token).RateLimits(ctx)
+			if err != nil {
+				return fmt.Errorf("could not retrieve rate limits: \n%w", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "RESOURCE\tREMAINING\tLIMIT\tRESETS")
+			for _, row := range []struct {
+				name string
+				rate *github.Rate
+			}{
+				{"core", limits.GetCore()},
+				{"search", limits.GetSearch()},
+				{"graphql", limits.GetGraphQL()},
+			} {
+				if row.rate == nil {
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", row.name, row.rate.Remaining, row.rate.Limit, row.rate.Reset.Local().Format(time.Kitchen))
+			}
+			return w.Flush()
+		},
+	}
+
+	return rateLimitCmd
+}
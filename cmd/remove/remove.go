@@ -5,6 +5,7 @@ package remove
 
 import (
 	"fmt"
+	"os"
 	"parm/internal/cmdutil"
 	"parm/internal/core/uninstaller"
 	"parm/pkg/cmdparser"
@@ -13,14 +14,16 @@ import (
 )
 
 func NewRemoveCmd(f *cmdutil.Factory) *cobra.Command {
+	var dryRun bool
+
 	// uninstallCmd represents the uninstall command
 	var RemoveCmd = &cobra.Command{
-		Use:     "remove <owner>/<repo>...",
+		Use:     "remove <owner>/<repo>[@release-tag]...",
 		Aliases: []string{"uninstall", "rm"},
 		Short:   "Uninstalls a parm package",
-		Long:    `Uninstalls a parm package. Does not remove the configuration files`,
+		Long: `Uninstalls a parm package. Does not remove the configuration files.
+With a release tag, only that installed version of the package is removed.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			ctx := cmd.Context()
 			removed := make(map[string]bool)
 
 			for _, pkg := range args {
@@ -29,19 +32,44 @@ func NewRemoveCmd(f *cmdutil.Factory) *cobra.Command {
 					continue
 				}
 				removed[pkg] = true
-				owner, repo, err := cmdparser.ParseRepoRef(pkg)
+				owner, repo, tag, err := cmdparser.ParseRepoReleaseRef(pkg)
 
 				if err != nil {
 					fmt.Printf("invalid package ref: %q: %s\n", pkg, err)
 					continue
 				}
 
-				err = uninstaller.RemovePkgSymlinks(ctx, owner, repo)
+				if dryRun {
+					var plan *uninstaller.Plan
+					if tag != "" {
+						plan, err = uninstaller.PlanRemoveVersion(owner, repo, tag)
+					} else {
+						plan, err = uninstaller.PlanUninstall(owner, repo)
+					}
+					if err != nil {
+						fmt.Printf("error: cannot remove %s: %s\n", pkg, err)
+						continue
+					}
+					cmdutil.PrintRemovalPlan(os.Stdout, plan)
+					continue
+				}
+
+				if tag != "" {
+					err = uninstaller.RemoveVersion(owner, repo, tag)
+					if err != nil {
+						fmt.Printf("error: cannot remove %s: %s\n", pkg, err)
+						continue
+					}
+					fmt.Printf("* Successfully removed %s/%s %s\n", owner, repo, tag)
+					continue
+				}
+
+				err = uninstaller.RemovePkgSymlinks(owner, repo)
 				if err != nil {
 					fmt.Printf("error: cannot remove symlink for %s/%s:\n%q", owner, repo, err)
 				}
 
-				err = uninstaller.Uninstall(ctx, owner, repo)
+				err = uninstaller.Uninstall(owner, repo)
 				if err != nil {
 					fmt.Printf("error: cannot uninstall %s: %s\n", pkg, err)
 				}
@@ -50,5 +78,7 @@ func NewRemoveCmd(f *cmdutil.Factory) *cobra.Command {
 		},
 	}
 
+	RemoveCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Shows what would be removed without removing anything")
+
 	return RemoveCmd
 }
@@ -0,0 +1,41 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package rollback
+
+import (
+	"fmt"
+	"parm/internal/cmdutil"
+	"parm/internal/core/updater"
+	"parm/pkg/cmdparser"
+
+	"github.com/spf13/cobra"
+)
+
+func NewRollbackCmd(f *cmdutil.Factory) *cobra.Command {
+	var rollbackCmd = &cobra.Command{
+		Use:   "rollback <owner>/<repo>",
+		Short: "Restores the version of a package that was active before its last update",
+		Long: `Restores the version of a package that was active before its last update, without downloading it again.
+Rolling back twice undoes the rollback. The number of replaced versions kept around is set by the keep_generations config option.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			owner, repo, err := cmdparser.ParseRepoRef(args[0])
+			if err != nil {
+				owner, repo, err = cmdparser.ParseGithubUrlPattern(args[0])
+				if err != nil {
+					return err
+				}
+			}
+
+			res, err := updater.Rollback(owner, repo)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("* Rolled back %s/%s: %s -> %s.\n", owner, repo, res.From.Version, res.To.Version)
+			return nil
+		},
+	}
+
+	return rollbackCmd
+}
@@ -4,16 +4,28 @@ Copyright © 2025 Alexander Wang
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"parm/cmd/cache"
 	"parm/cmd/configure"
+	"parm/cmd/export"
 	"parm/cmd/info"
 	"parm/cmd/install"
 	"parm/cmd/list"
+	"parm/cmd/outdated"
 	"parm/cmd/pin"
+	"parm/cmd/plan"
+	"parm/cmd/ratelimit"
 	"parm/cmd/remove"
+	"parm/cmd/rollback"
+	"parm/cmd/sync"
 	"parm/cmd/update"
+	"parm/cmd/use"
 	"parm/internal/cmdutil"
 	"parm/internal/config"
+	"parm/internal/core/catalog"
+	"parm/internal/core/journal"
 	"parm/internal/gh"
 	"parm/parmver"
 
@@ -21,6 +33,19 @@ import (
 )
 
 func NewRootCmd(f *cmdutil.Factory) *cobra.Command {
+	var noCache bool
+
+	// API responses are cached unless --no-cache is set
+	provider := f.Provider
+	f.Provider = func(ctx context.Context, token string, opts ...gh.Option) gh.Provider {
+		if !noCache {
+			if dir, err := config.GetHTTPCacheDir(); err == nil {
+				opts = append(opts, gh.WithCache(dir))
+			}
+		}
+		return provider(ctx, token, opts...)
+	}
+
 	// rootCmd represents the base command when called without any subcommands
 	var rootCmd = &cobra.Command{
 		Use:   "parm",
@@ -34,6 +59,20 @@ cross-platform on Windows, Linux, and MacOS.`,
 			if err != nil {
 				return err
 			}
+			if err := catalog.MigrateLegacyLayout(); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not migrate installed packages to the versioned layout:\n%s\n", err)
+			}
+			recovered, err := journal.Recover()
+			for _, rec := range recovered {
+				if rec.Finished {
+					fmt.Fprintf(os.Stderr, "finished interrupted install of %s/%s %s\n", rec.Owner, rec.Repo, rec.Version)
+				} else {
+					fmt.Fprintf(os.Stderr, "rolled back interrupted install of %s/%s %s\n", rec.Owner, rec.Repo, rec.Version)
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not recover interrupted installs:\n%s\n", err)
+			}
 			return nil
 		},
 	}
@@ -43,13 +82,23 @@ cross-platform on Windows, Linux, and MacOS.`,
 		install.NewInstallCmd(f),
 		remove.NewRemoveCmd(f),
 		update.NewUpdateCmd(f),
+		outdated.NewOutdatedCmd(f),
+		plan.NewPlanCmd(f),
 		list.NewListCmd(f),
 		info.NewInfoCmd(f),
 		pin.NewPinCmd(f),
 		pin.NewUnpinCmd(f),
+		export.NewExportCmd(f),
+		sync.NewSyncCmd(f),
+		use.NewUseCmd(f),
+		rollback.NewRollbackCmd(f),
+		cache.NewCacheCmd(f),
+		ratelimit.NewRateLimitCmd(f),
 		// search.NewSearchCmd(f),
 	)
 
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Don't use or update the local cache of GitHub API responses.")
+
 	return rootCmd
 }
 
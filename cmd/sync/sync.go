@@ -0,0 +1,180 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package sync
+
+import (
+	"context"
+	"fmt"
+	"parm/internal/cmdutil"
+	"parm/internal/core/catalog"
+	"parm/internal/core/installer"
+	"parm/internal/core/switcher"
+	"parm/internal/core/syncer"
+	"parm/internal/core/uninstaller"
+	"parm/internal/core/updater"
+	"parm/internal/core/verify"
+	"parm/internal/gh"
+	"parm/internal/manifest"
+	"parm/internal/parmfile"
+	"parm/internal/parmutil"
+	"parm/pkg/sysutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewSyncCmd(f *cmdutil.Factory) *cobra.Command {
+	var file string
+	var no_verify bool
+	var verifyLevel string
+	var yes bool
+
+	var syncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Makes the installed packages match a parmfile",
+		Long: `Installs, reinstalls or removes packages until the installed packages
+match the ones declared in the parmfile. Packages that are installed but not
+declared in the parmfile are removed. Packages declared without a version are
+only installed if missing, never updated; use "parm update" for that.`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			level := verify.LevelNone
+			if !no_verify {
+				var err error
+				level, err = verify.ParseLevel(verifyLevel)
+				if err != nil {
+					return err
+				}
+			}
+
+			pf, err := parmfile.Read(file)
+			if err != nil {
+				return fmt.Errorf("cannot read parmfile: \n%w", err)
+			}
+
+			mans, err := catalog.GetAllPkgManifest()
+			if err != nil {
+				return err
+			}
+
+			actions, err := syncer.Plan(pf, mans)
+			if err != nil {
+				return err
+			}
+			if len(actions) == 0 {
+				fmt.Println("Everything is up to date with the parmfile.")
+				return nil
+			}
+
+			token, _ := gh.GetStoredApiKey(viper.GetViper())
+			provider := f.Provider(ctx, token)
+
+			// resolving a release and requesting its asset are one API request each
+			needed := 0
+			for _, act := range actions {
+				if act.Kind == syncer.ActionInstall || act.Kind == syncer.ActionReinstall {
+					needed += 2
+				}
+			}
+			if err := gh.EnsureBudget(ctx, provider, needed); err != nil {
+				return err
+			}
+
+			client := provider.Repos()
+			inst := installer.New(client)
+
+			picker := cmdutil.NewAssetPicker(yes)
+			var failed int
+			for _, act := range actions {
+				fmt.Printf("* %s\n", act)
+				if err := apply(ctx, inst, act, level, picker); err != nil {
+					fmt.Printf("error: failed to %s %s/%s:\n\t%q\n", act.Kind, act.Owner, act.Repo, gh.ExplainRateLimit(err))
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d sync actions failed", failed, len(actions))
+			}
+			fmt.Printf("Synced %d packages.\n", len(actions))
+			return nil
+		},
+	}
+
+	syncCmd.Flags().StringVarP(&file, "file", "f", parmfile.DefaultFileName, "Path to the parmfile to sync from.")
+	syncCmd.Flags().BoolVarP(&no_verify, "no-verify", "n", false, "Skips integrity check")
+	syncCmd.Flags().StringVar(&verifyLevel, "verify", verify.LevelChecksum.String(), "How strictly to verify assets: none, digest, checksum, signature or attestation")
+	syncCmd.MarkFlagsMutuallyExclusive("verify", "no-verify")
+	syncCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Never prompt for an asset; fail if the asset to install is ambiguous")
+
+	return syncCmd
+}
+
+func apply(ctx context.Context, inst *installer.Installer, act syncer.Action, level verify.Level, picker installer.AssetPicker) error {
+	switch act.Kind {
+	case syncer.ActionInstall, syncer.ActionReinstall:
+		return install(ctx, inst, act.Owner, act.Repo, act.Package, act.Installed, level, picker)
+	case syncer.ActionConfigure:
+		if err := switcher.SwitchChannel(act.Owner, act.Repo, act.Package.Channel); err != nil {
+			return err
+		}
+		_, err := updater.ChangePinnedStatus(act.Owner, act.Repo, act.Package.Pinned)
+		return err
+	case syncer.ActionRemove:
+		if err := uninstaller.RemovePkgSymlinks(act.Owner, act.Repo); err != nil {
+			fmt.Printf("error: cannot remove symlink for %s/%s:\n%q\n", act.Owner, act.Repo, err)
+		}
+		return uninstaller.Uninstall(act.Owner, act.Repo)
+	}
+	return fmt.Errorf("unknown sync action %q", act.Kind)
+}
+
+func install(ctx context.Context, inst *installer.Installer, owner, repo string, pkg *parmfile.Package, prev *manifest.Manifest, level verify.Level, picker installer.AssetPicker) error {
+	opts := installer.InstallFlags{
+		Type:        pkg.Channel,
+		Picker:      picker,
+		VerifyLevel: level,
+	}
+	if pkg.Version != "" {
+		// an exact tag is resolved the same way on either channel
+		opts.Type = manifest.Release
+		opts.Version = &pkg.Version
+	}
+	if pkg.Asset != "" {
+		opts.Asset = &pkg.Asset
+	} else if prev != nil && prev.Asset != nil {
+		opts.AssetPattern = &prev.Asset.Pattern
+	}
+	opts.Bins, opts.Renames = installer.BinSelection(owner, repo, nil, nil, prev)
+
+	pkgPath := parmutil.GetPkgDir(owner, repo)
+	res, err := inst.Install(ctx, owner, repo, pkgPath, opts, nil)
+	if err != nil {
+		if parentDir, cErr := sysutil.GetParentDir(pkgPath); cErr == nil {
+			_ = parmutil.Cleanup(parentDir)
+		}
+		return err
+	}
+
+	man, err := manifest.New(owner, repo, res.Version, pkg.Channel, res.InstallPath)
+	if err != nil {
+		_ = res.Abort()
+		return fmt.Errorf("failed to create manifest: \n%w", err)
+	}
+	man.Pinned = pkg.Pinned
+	man.Asset = res.Asset
+	man.Signer = res.Signer
+	man.VerifyLevel = res.VerifyLevel.String()
+	man.Bins = opts.Bins
+	man.Renames = opts.Renames
+	if err := res.Commit(man); err != nil {
+		return err
+	}
+	if prev != nil && prev.Version != man.Version {
+		return uninstaller.RemoveVersion(owner, repo, prev.Version)
+	}
+	return nil
+}
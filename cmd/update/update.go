@@ -5,8 +5,12 @@ package update
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"parm/internal/cmdutil"
+	"parm/internal/config"
 	"parm/internal/core/catalog"
 	"parm/internal/core/installer"
 	"parm/internal/core/updater"
@@ -14,15 +18,20 @@ import (
 	"parm/internal/manifest"
 	"parm/internal/parmutil"
 	"parm/pkg/cmdparser"
-	"parm/pkg/sysutil"
+	"parm/pkg/progress"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
 )
 
 func NewUpdateCmd(f *cmdutil.Factory) *cobra.Command {
 	type argsKey struct{}
 	var strict bool
+	var jobs int
+	var dryRun bool
 	var aKey argsKey
 
 	// updateCmd represents the update command
@@ -79,78 +88,168 @@ func NewUpdateCmd(f *cmdutil.Factory) *cobra.Command {
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx := cmd.Context()
 			args, _ := ctx.Value(aKey).([]string)
+			if jobs < 1 {
+				return fmt.Errorf("--jobs must be at least 1")
+			}
 
 			token, err := gh.GetStoredApiKey(viper.GetViper())
 			if err != nil {
 				fmt.Printf("%s\ncontinuing without api key.\n", err)
 			}
-			client := f.Provider(ctx, token).Repos()
+			provider := f.Provider(ctx, token)
+			client := provider.Repos()
 			inst := installer.New(client)
 			up := updater.New(client, inst)
-			flags := updater.UpdateFlags{
-				Strict: strict,
+			if token != "" {
+				up.WithBulkLookup(provider)
 			}
 
+			// pinned packages are skipped without looking them up
+			var toCheck []*manifest.Manifest
 			for _, pkg := range args {
-				// guaranteed to work now
 				owner, repo, _ := cmdparser.ParseRepoRef(pkg)
-
-				installPath := parmutil.GetInstallDir(owner, repo)
-				parentDir, _ := sysutil.GetParentDir(installPath)
-
-				man, err := manifest.Read(installPath)
-
-				if err != nil {
-					fmt.Printf("error: cannot read manifest file for %s/%s\n\t%q\n", owner, repo, err)
-					continue
+				if man, err := manifest.Read(parmutil.GetInstallDir(owner, repo)); err == nil && !man.Pinned {
+					toCheck = append(toCheck, man)
 				}
+			}
+			if err := gh.EnsureBudget(ctx, provider, up.LookupCost(toCheck, &updater.UpdateFlags{Strict: strict})); err != nil {
+				return err
+			}
 
-				if man.Pinned {
-					// don't update if pinned
-					fmt.Printf("%s/%s is pinned to version %s, skipping update...\n", owner, repo, man.Version)
-					continue
-				}
+			if dryRun {
+				planUpdates(ctx, up, args, &updater.UpdateFlags{Strict: strict})
+				return nil
+			}
 
-				res, err := up.Update(ctx, owner, repo, installPath, man, &flags, nil)
-				if err != nil {
-					_ = parmutil.Cleanup(parentDir)
-					fmt.Printf("error: failed to update %s/%s:\n\t%q \n", owner, repo, err)
-					continue
-				}
+			pb := mpb.New(mpb.WithWidth(60))
+			flags := updater.BatchFlags{
+				UpdateFlags: updater.UpdateFlags{
+					Strict: strict,
+				},
+				Jobs: jobs,
+				Hooks: func(owner, repo string) *progress.Hooks {
+					return &progress.Hooks{
+						Decorator: func(stage progress.Stage, r io.Reader, total int64) io.Reader {
+							if stage != progress.StageDownload {
+								return r
+							}
+							bar := pb.AddBar(total,
+								mpb.PrependDecorators(
+									decor.Name(fmt.Sprintf("%s/%s", owner, repo), decor.WCSyncSpaceR),
+									decor.Percentage(decor.WCSyncSpace),
+								),
+								mpb.AppendDecorators(
+									decor.OnComplete(
+										decor.EwmaETA(decor.ET_STYLE_GO, 60), "done",
+									),
+									decor.Name(" "),
+									decor.EwmaSpeed(decor.SizeB1024(0), "% .2f", 60),
+								),
+							)
+							return bar.ProxyReader(r)
+						},
+					}
+				},
+			}
 
-				// Write new manifest
+			results := up.UpdateAll(ctx, args, &flags, func(res *updater.UpdateResult) error {
 				old := res.OldManifest
-				man, err = manifest.New(owner, repo, res.Version, old.InstallType, res.InstallPath)
-				// TODO: maybe set this pinned thing somewhere else
-				man.Pinned = old.Pinned
+				owner, repo := old.Owner, old.Repo
 
+				// Write new manifest
+				man, err := manifest.New(owner, repo, res.Version, old.InstallType, res.InstallPath)
 				if err != nil {
+					_ = res.Abort()
 					return fmt.Errorf("failed to create manifest: \n%w", err)
 				}
-				err = man.Write(res.InstallPath)
-				if err != nil {
-					return err
-				}
+				// TODO: maybe set this pinned thing somewhere else
+				man.Pinned = old.Pinned
+				man.Asset = res.Asset
+				man.Signer = res.Signer
+				man.VerifyLevel = res.VerifyLevel.String()
+				man.Bins, man.Renames = installer.BinSelection(owner, repo, nil, nil, old)
 
 				// Symlinked executables to PATH
-				binPaths := man.GetFullExecPaths()
-				for _, execPath := range binPaths {
-					pathToSymLinkTo := parmutil.GetBinDir(man.Repo)
+				if err := res.Commit(man); err != nil {
+					return fmt.Errorf("could not activate %s: \n%w", res.Version, err)
+				}
 
-					// TODO: use shims for windows instead?
-					err = sysutil.SymlinkBinToPath(execPath, pathToSymLinkTo)
-					if err != nil {
-						fmt.Println("error: could not symlink binary to PATH")
-						continue
-					}
+				// keep the replaced version around for "parm rollback"
+				if err := updater.RetainGeneration(owner, repo, old.Version, config.Cfg.KeepGenerations); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: could not prune old versions of %s/%s:\n\t%q\n", owner, repo, err)
 				}
-				fmt.Printf("* Updated %s/%s: %s -> %s.\n", owner, repo, old.Version, res.Version)
-			}
+				return nil
+			})
+			pb.Wait()
+
+			printSummary(results)
 			return nil
 		},
 	}
 
+	updateCmd.Flags().IntVarP(&jobs, "jobs", "j", 4, "Number of packages to update at once.")
+	updateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Shows which release and asset each package would be updated to, without downloading anything")
 	updateCmd.Flags().BoolVarP(&strict, "strict", "s", false, "Only available on pre-release channels. Will only install pre-release versions and not stable releases, even if there exists a stable version more up-to-date than a pre-release.")
 
 	return updateCmd
 }
+
+// prints what updating each package would do
+func planUpdates(ctx context.Context, up *updater.Updater, pkgs []string, flags *updater.UpdateFlags) {
+	for i, pkg := range pkgs {
+		if i > 0 {
+			fmt.Println()
+		}
+		owner, repo, _ := cmdparser.ParseRepoRef(pkg)
+		man, err := manifest.Read(parmutil.GetInstallDir(owner, repo))
+		if err != nil {
+			fmt.Printf("error: %s is not installed\n", pkg)
+			continue
+		}
+		if man.Pinned {
+			fmt.Printf("%s is pinned at %s, skipping\n", pkg, man.Version)
+			continue
+		}
+
+		plan, err := up.Plan(ctx, owner, repo, parmutil.GetPkgDir(owner, repo), man, flags)
+		if errors.Is(err, updater.ErrUpToDate) {
+			fmt.Printf("%s is up to date (%s)\n", pkg, man.Version)
+			continue
+		}
+		if err != nil {
+			fmt.Printf("error: cannot update %s:\n\t%q\n", pkg, gh.ExplainRateLimit(err))
+			continue
+		}
+		fmt.Printf("update from %s:\n", man.Version)
+		cmdutil.PrintInstallPlan(os.Stdout, plan)
+	}
+}
+
+func printSummary(results []updater.BatchResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tSTATUS\tINSTALLED\tAVAILABLE")
+	counts := map[updater.Status]int{}
+	for _, res := range results {
+		counts[res.Status]++
+		to := res.To
+		if res.Status != updater.StatusUpdated {
+			to = "-"
+		}
+		fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\n", res.Owner, res.Repo, res.Status, res.From, to)
+	}
+	w.Flush()
+
+	for _, res := range results {
+		if res.Err != nil {
+			fmt.Printf("\nerror: failed to update %s/%s:\n\t%q\n", res.Owner, res.Repo, res.Err)
+		}
+	}
+
+	fmt.Printf("\n%d updated, %d up to date, %d pinned, %d failed\n",
+		counts[updater.StatusUpdated], counts[updater.StatusSkipped], counts[updater.StatusPinned], counts[updater.StatusFailed])
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2025 Alexander Wang
+*/
+package use
+
+import (
+	"fmt"
+	"parm/internal/cmdutil"
+	"parm/internal/core/catalog"
+	"parm/internal/core/switcher"
+	"parm/internal/parmutil"
+	"parm/pkg/cmdparser"
+
+	"github.com/spf13/cobra"
+)
+
+func NewUseCmd(f *cmdutil.Factory) *cobra.Command {
+	var useCmd = &cobra.Command{
+		Use:   "use <owner>/<repo>@<release-tag>",
+		Short: "Switches a package to another installed version",
+		Long: `Points the package's binaries in PATH to another installed version of the package.
+Without a release tag, lists the installed versions of the package instead.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			owner, repo, tag, err := cmdparser.ParseRepoReleaseRef(args[0])
+			if err != nil {
+				owner, repo, tag, err = cmdparser.ParseGithubUrlPatternWithRelease(args[0])
+				if err != nil {
+					return err
+				}
+			}
+
+			if tag == "" {
+				mans, err := catalog.GetInstalledVersions(owner, repo)
+				if err != nil || len(mans) == 0 {
+					return fmt.Errorf("%s/%s is not installed", owner, repo)
+				}
+				current, _ := parmutil.GetCurrentVersion(owner, repo)
+				for _, man := range mans {
+					marker := " "
+					if man.Version == current {
+						marker = "*"
+					}
+					fmt.Printf("%s %s (installed %s)\n", marker, man.Version, man.LastUpdated)
+				}
+				return nil
+			}
+
+			man, err := switcher.UseVersion(owner, repo, tag)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Now using %s/%s %s\n", owner, repo, man.Version)
+			return nil
+		},
+	}
+
+	return useCmd
+}
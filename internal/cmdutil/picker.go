@@ -0,0 +1,56 @@
+package cmdutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"parm/internal/core/installer"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// Returns a picker that asks on the terminal which asset to install. Returns nil if noPrompt is set
+// or parm isn't attached to a terminal, so ambiguous installs fail instead.
+func NewAssetPicker(noPrompt bool) installer.AssetPicker {
+	if noPrompt || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return nil
+	}
+	return func(tag string, candidates []installer.AssetCandidate) (*github.ReleaseAsset, error) {
+		return promptAsset(os.Stdin, os.Stdout, tag, candidates)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func promptAsset(in io.Reader, out io.Writer, tag string, candidates []installer.AssetCandidate) (*github.ReleaseAsset, error) {
+	fmt.Fprintf(out, "Cannot tell which asset of release %s to install:\n%s\n", tag, installer.FormatCandidates(candidates))
+
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "Choose an asset [1-%d] or q to cancel: ", len(candidates))
+		if !sc.Scan() {
+			fmt.Fprintln(out)
+			return nil, installer.ErrNoAssetChosen
+		}
+
+		input := strings.TrimSpace(sc.Text())
+		if input == "q" || input == "" {
+			return nil, installer.ErrNoAssetChosen
+		}
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 1 || n > len(candidates) {
+			fmt.Fprintf(out, "%q is not a valid choice\n", input)
+			continue
+		}
+		return candidates[n-1].Asset, nil
+	}
+}
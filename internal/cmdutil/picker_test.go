@@ -0,0 +1,43 @@
+package cmdutil
+
+import (
+	"errors"
+	"io"
+	"parm/internal/core/installer"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+)
+
+func candidates() []installer.AssetCandidate {
+	return []installer.AssetCandidate{
+		{Asset: &github.ReleaseAsset{Name: github.Ptr("tool-linux-amd64.tar.gz"), Size: github.Ptr(2048)}, Score: 36},
+		{Asset: &github.ReleaseAsset{Name: github.Ptr("tool-linux-x86_64.tar.gz"), Size: github.Ptr(4096)}, Score: 36},
+	}
+}
+
+func TestPromptAsset(t *testing.T) {
+	var out strings.Builder
+	ass, err := promptAsset(strings.NewReader("3\nabc\n2\n"), &out, "v1.0.0", candidates())
+	if err != nil {
+		t.Fatalf("promptAsset() error: %v", err)
+	}
+	if ass.GetName() != "tool-linux-x86_64.tar.gz" {
+		t.Errorf("promptAsset() = %s, want tool-linux-x86_64.tar.gz", ass.GetName())
+	}
+	for _, want := range []string{"tool-linux-amd64.tar.gz", "2.0 KiB", "\"3\" is not a valid choice", "\"abc\" is not a valid choice"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("prompt output is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPromptAsset_Cancel(t *testing.T) {
+	for _, input := range []string{"q\n", "\n", ""} {
+		_, err := promptAsset(strings.NewReader(input), io.Discard, "v1.0.0", candidates())
+		if !errors.Is(err, installer.ErrNoAssetChosen) {
+			t.Errorf("promptAsset(%q) error = %v, want ErrNoAssetChosen", input, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"parm/internal/core/installer"
+	"parm/internal/core/uninstaller"
+	"parm/internal/core/verify"
+	"parm/internal/parmutil"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Prints what an install or update would do.
+func PrintInstallPlan(w io.Writer, plan *installer.Plan) {
+	ass := plan.Asset
+	fmt.Fprintf(w, "%s/%s %s (%s)\n", plan.Owner, plan.Repo, plan.Version, plan.Type)
+	fmt.Fprintf(w, "  asset:   %s (%s)\n", ass.GetName(), installer.FormatSize(ass.GetSize()))
+
+	switch {
+	case ass.Digest != nil:
+		fmt.Fprintf(w, "  digest:  %s\n", ass.GetDigest())
+	case plan.ChecksumFile != "":
+		fmt.Fprintf(w, "  digest:  none published, looked up in %s\n", plan.ChecksumFile)
+	case plan.VerifyLevel == verify.LevelChecksum:
+		fmt.Fprintln(w, "  digest:  none published, the install would fail without --verify=digest or --no-verify")
+	default:
+		fmt.Fprintln(w, "  digest:  none published")
+	}
+	fmt.Fprintf(w, "  verify:  %s\n", plan.VerifyLevel)
+	if plan.VerifyLevel == verify.LevelSignature {
+		if len(plan.Signatures) > 0 {
+			fmt.Fprintf(w, "  signed:  %s\n", strings.Join(plan.Signatures, ", "))
+		} else {
+			fmt.Fprintln(w, "  signed:  no signature files published, the install would fail")
+		}
+	}
+
+	if plan.Replaces {
+		fmt.Fprintf(w, "  into:    %s (replaces the version already installed there)\n", plan.InstallPath)
+	} else {
+		fmt.Fprintf(w, "  into:    %s\n", plan.InstallPath)
+	}
+
+	if plan.LinksGuessed {
+		fmt.Fprintln(w, "  links (guessed from the repo name, the executables are only known once the asset is unpacked):")
+	} else {
+		fmt.Fprintln(w, "  links (taken from the installed version, the executables are only known once the asset is unpacked):")
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, link := range plan.Links {
+		fmt.Fprintf(tw, "    %s\t%s -> %s", link.Action, link.Path, link.Target)
+		if link.Action == installer.LinkConflict {
+			fmt.Fprintf(tw, " (owned by %s)", link.Owner)
+		}
+		if link.Action == installer.LinkOverwrite {
+			if link.Current != "" {
+				fmt.Fprintf(tw, " (currently -> %s)", link.Current)
+			} else {
+				fmt.Fprint(tw, " (currently a file)")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+// Prints what a remove would do.
+func PrintRemovalPlan(w io.Writer, plan *uninstaller.Plan) {
+	fmt.Fprintf(w, "%s/%s\n", plan.Owner, plan.Repo)
+	for _, dir := range plan.Dirs {
+		fmt.Fprintf(w, "  delete  %s\n", dir)
+	}
+	for _, link := range plan.Links {
+		fmt.Fprintf(w, "  unlink  %s\n", link)
+	}
+	for _, name := range slices.Sorted(maps.Keys(plan.Restores)) {
+		fmt.Fprintf(w, "  relink  %s (to %s)\n", parmutil.GetBinDir(name), plan.Restores[name])
+	}
+}
@@ -3,8 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"parm/pkg/sysutil"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
@@ -18,6 +21,84 @@ type Config struct {
 
 	// directory added to PATH where symlinked binaries reside
 	ParmBinPath string `mapstructure:"parm_bin_path"`
+
+	// how executables are linked into ParmBinPath: symlink, hardlink, copy or shim
+	LinkMode string `mapstructure:"link_mode"`
+
+	// number of versions replaced by updates to keep around for "parm rollback"
+	KeepGenerations int `mapstructure:"keep_generations"`
+
+	// how release assets are picked for every package
+	Assets AssetRules `mapstructure:"assets"`
+
+	// per package overrides, keyed by lowercase "owner/repo".
+	// package names can contain dots, so this table is loaded separately from the rest of the config.
+	Packages map[string]PackageRules `mapstructure:"-"`
+}
+
+type AssetRules struct {
+	// substrings of asset names to favour among equally good platform matches, in order, e.g. "musl"
+	Prefer []string `mapstructure:"prefer"`
+	// substrings of asset names that are never picked unless nothing else is left, e.g. ".deb"
+	Avoid []string `mapstructure:"avoid"`
+
+	// extra names for an OS or architecture, keyed by GOOS/GOARCH, e.g. amd64 = ["x86-64"]
+	OSAliases   map[string][]string `mapstructure:"os_aliases"`
+	ArchAliases map[string][]string `mapstructure:"arch_aliases"`
+}
+
+type PackageRules struct {
+	// glob the asset name must match, e.g. "*-x86_64-unknown-linux-musl.tar.gz"
+	Asset string `mapstructure:"asset"`
+	// regular expression the asset name must match, used if Asset is empty
+	AssetRegex string `mapstructure:"asset_regex"`
+
+	// checked before the global prefer and avoid lists
+	Prefer []string `mapstructure:"prefer"`
+	Avoid  []string `mapstructure:"avoid"`
+
+	// keys and identities the package's releases must be signed with for --verify=signature
+	Signature SignatureRules `mapstructure:"signature"`
+	// what the package's build provenance must say for --verify=attestation
+	Attestation AttestationRules `mapstructure:"attestation"`
+
+	// the executables to link, by name or path in the install dir, e.g. ["nvim"]. All of them if empty.
+	Bins []string `mapstructure:"bins"`
+	// names to link executables under instead of their own, e.g. {"tool-linux-amd64" = "tool"}
+	Rename map[string]string `mapstructure:"rename"`
+}
+
+type SignatureRules struct {
+	// minisign public key, e.g. "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+	MinisignKey string `mapstructure:"minisign_key"`
+	// path to a file of OpenPGP public keys, armored or binary
+	GPGKey string `mapstructure:"gpg_key"`
+	// path to a PEM public key used with "cosign sign-blob --key"
+	CosignKey string `mapstructure:"cosign_key"`
+
+	// keyless cosign signatures: the certificate identity, exact or as a regular expression,
+	// the OIDC issuer and a path to the PEM encoded Fulcio root and intermediate certificates
+	Identity      string `mapstructure:"identity"`
+	IdentityRegex string `mapstructure:"identity_regex"`
+	Issuer        string `mapstructure:"issuer"`
+	TrustedRoots  string `mapstructure:"trusted_roots"`
+}
+
+type AttestationRules struct {
+	// the workflow that must have signed the attestation, e.g. "slsa-framework/slsa-github-generator/.github/workflows/builder.yml".
+	// Any workflow of the package's own repo if empty.
+	SignerWorkflow string `mapstructure:"signer_workflow"`
+	// path to the PEM encoded Fulcio certificates the attestation's signing certificate must chain to.
+	// Required for --verify=attestation.
+	TrustedRoots string `mapstructure:"trusted_roots"`
+}
+
+// Returns the asset rules of a package, with the global prefer and avoid lists appended to its own.
+func (c *Config) RulesFor(owner, repo string) PackageRules {
+	rules := c.Packages[strings.ToLower(owner+"/"+repo)]
+	rules.Prefer = append(slices.Clone(rules.Prefer), c.Assets.Prefer...)
+	rules.Avoid = append(slices.Clone(rules.Avoid), c.Assets.Avoid...)
+	return rules
 }
 
 var defaultPkgDir = getOrCreateDefaultPkgDir()
@@ -26,6 +107,8 @@ var DefaultCfg = &Config{
 	GitHubApiTokenFallback: "",
 	ParmPkgPath:            defaultPkgDir,
 	ParmBinPath:            defaultBinDir,
+	LinkMode:               string(sysutil.DefaultLinkMode(runtime.GOOS)),
+	KeepGenerations:        2,
 }
 
 func setEnvVars(v *viper.Viper) {
@@ -108,3 +191,8 @@ func getOrCreatDefaultDir(addedPath string) string {
 	}
 	return path
 }
+
+// Reports whether the package has an asset glob or regex configured.
+func (r PackageRules) HasAssetMatcher() bool {
+	return r.Asset != "" || r.AssetRegex != ""
+}
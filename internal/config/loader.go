@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/viper"
 )
 
@@ -19,6 +22,23 @@ func GetParmConfigDir() (string, error) {
 	return cfgPath, nil
 }
 
+func GetParmCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find XDG_CACHE_HOME or LOCALAPPDATA: \n%w", err)
+	}
+	return filepath.Join(cacheDir, "parm"), nil
+}
+
+// where responses from the GitHub API are cached
+func GetHTTPCacheDir() (string, error) {
+	dir, err := GetParmCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "http"), nil
+}
+
 func Init() error {
 	cfgPath, err := GetParmConfigDir()
 	if err != nil {
@@ -54,7 +74,46 @@ func Init() error {
 	if err := v.Unmarshal(&Cfg); err != nil {
 		return fmt.Errorf("cannot unmarshal config file \n%w", err)
 	}
+	// unlike Unmarshal, Get doesn't split the table's keys on dots
+	if err := mapstructure.Decode(v.Get("packages"), &Cfg.Packages); err != nil {
+		return fmt.Errorf("cannot read package rules from config file \n%w", err)
+	}
+	if err := readRenames(v.ConfigFileUsed()); err != nil {
+		return fmt.Errorf("cannot read package rules from config file \n%w", err)
+	}
 
 	// watch for live reload ??
 	return nil
 }
+
+// rename tables are keyed by executable names, which viper would lowercase, so they're read from the file as is
+func readRenames(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var raw struct {
+		Packages map[string]struct {
+			Rename map[string]string `toml:"rename"`
+		} `toml:"packages"`
+	}
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for name, pkg := range raw.Packages {
+		if pkg.Rename == nil {
+			continue
+		}
+		if Cfg.Packages == nil {
+			Cfg.Packages = make(map[string]PackageRules)
+		}
+		key := strings.ToLower(name)
+		rules := Cfg.Packages[key]
+		rules.Rename = pkg.Rename
+		Cfg.Packages[key] = rules
+	}
+	return nil
+}
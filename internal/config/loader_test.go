@@ -131,3 +131,78 @@ func TestSetConfigDefaults(t *testing.T) {
 		t.Error("setConfigDefaults() did not set parm_bin_path")
 	}
 }
+
+func TestInit_ReadsAssetRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	os.MkdirAll(filepath.Join(tmpDir, "parm"), 0o700)
+	cfg := `
+[assets]
+prefer = ["musl"]
+avoid = [".deb"]
+
+[assets.arch_aliases]
+amd64 = ["x86-64"]
+
+[packages."BurntSushi/ripgrep"]
+asset = "*-x86_64-unknown-linux-musl.tar.gz"
+prefer = ["static"]
+
+[packages."BurntSushi/ripgrep".signature]
+identity_regex = "^https://github.com/BurntSushi/"
+issuer = "https://token.actions.githubusercontent.com"
+trusted_roots = "/etc/parm/fulcio.pem"
+
+[packages."folke/lazy.nvim"]
+asset_regex = "^lazy-.*\\.zip$"
+
+[packages."neovim/neovim"]
+bins = ["nvim"]
+
+[packages."neovim/neovim".rename]
+nvim = "vi"
+
+[packages."owner/MyTool".rename]
+"MyTool-linux" = "mytool"
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "parm", "config.toml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+
+	rules := Cfg.RulesFor("burntsushi", "RipGrep")
+	if rules.Asset != "*-x86_64-unknown-linux-musl.tar.gz" {
+		t.Errorf("RulesFor().Asset = %q", rules.Asset)
+	}
+	if len(rules.Prefer) != 2 || rules.Prefer[0] != "static" || rules.Prefer[1] != "musl" {
+		t.Errorf("RulesFor().Prefer = %v, want [static musl]", rules.Prefer)
+	}
+	if len(rules.Avoid) != 1 || rules.Avoid[0] != ".deb" {
+		t.Errorf("RulesFor().Avoid = %v, want [.deb]", rules.Avoid)
+	}
+	if sig := rules.Signature; sig.IdentityRegex != "^https://github.com/BurntSushi/" || sig.TrustedRoots != "/etc/parm/fulcio.pem" {
+		t.Errorf("RulesFor().Signature = %+v", sig)
+	}
+	// names with dots must not be split into nested tables
+	if got := Cfg.RulesFor("folke", "lazy.nvim").AssetRegex; got != `^lazy-.*\.zip$` {
+		t.Errorf("RulesFor(folke/lazy.nvim).AssetRegex = %q", got)
+	}
+	if got := Cfg.Assets.ArchAliases["amd64"]; len(got) != 1 || got[0] != "x86-64" {
+		t.Errorf("Assets.ArchAliases = %v", Cfg.Assets.ArchAliases)
+	}
+	if rules := Cfg.RulesFor("neovim", "neovim"); len(rules.Bins) != 1 || rules.Bins[0] != "nvim" || rules.Rename["nvim"] != "vi" {
+		t.Errorf("RulesFor(neovim/neovim) bins = %v, rename = %v", rules.Bins, rules.Rename)
+	}
+	// executable names are case sensitive
+	if rules := Cfg.RulesFor("owner", "mytool"); rules.Rename["MyTool-linux"] != "mytool" {
+		t.Errorf("RulesFor(owner/MyTool).Rename = %v, want MyTool-linux = mytool", rules.Rename)
+	}
+	if rules := Cfg.RulesFor("other", "pkg"); rules.HasAssetMatcher() {
+		t.Errorf("RulesFor(other/pkg) = %+v, want no asset matcher", rules)
+	}
+}
@@ -0,0 +1,134 @@
+package bins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"parm/internal/config"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// file in parm_pkg_path recording which package owns each link in parm_bin_path
+const INDEX_FILE_NAME string = ".bins.json"
+
+// Index records which package owns each link in parm_bin_path.
+type Index struct {
+	// the packages ("owner/repo") claiming a link, keyed by the link's name. The first one owns the link,
+	// the others ship an executable of the same name and get the link back once the owner releases it.
+	Claims map[string][]string `json:"claims"`
+}
+
+func GetIndexPath() string {
+	return filepath.Join(config.Cfg.ParmPkgPath, INDEX_FILE_NAME)
+}
+
+// Reads the index. A missing index is empty.
+func Load() (*Index, error) {
+	idx := &Index{Claims: make(map[string][]string)}
+	data, err := os.ReadFile(GetIndexPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read bin index: \n%w", err)
+	}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("cannot read bin index: \n%w", err)
+	}
+	if idx.Claims == nil {
+		idx.Claims = make(map[string][]string)
+	}
+	return idx, nil
+}
+
+func (idx *Index) Save() error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.Cfg.ParmPkgPath, 0o755); err != nil {
+		return err
+	}
+	path := GetIndexPath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("cannot write bin index: \n%w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Returns the package owning a link, or "" if no package does.
+func (idx *Index) Owner(name string) string {
+	if claims := idx.Claims[name]; len(claims) > 0 {
+		return claims[0]
+	}
+	return ""
+}
+
+// Reports whether pkg owns a link or is waiting for it.
+func (idx *Index) Claimed(name, pkg string) bool {
+	return slices.IndexFunc(idx.Claims[name], sameAs(pkg)) >= 0
+}
+
+// Lists the links pkg owns or is waiting for.
+func (idx *Index) Names(pkg string) []string {
+	var names []string
+	for name := range idx.Claims {
+		if idx.Claimed(name, pkg) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Adds pkg to the packages claiming a link. It only owns the link if no other package does.
+func (idx *Index) Claim(name, pkg string) {
+	if !idx.Claimed(name, pkg) {
+		idx.Claims[name] = append(idx.Claims[name], pkg)
+	}
+}
+
+// Makes pkg the owner of a link. The previous owner gets it back once pkg releases it.
+func (idx *Index) Take(name, pkg string) {
+	claims := slices.DeleteFunc(slices.Clone(idx.Claims[name]), sameAs(pkg))
+	idx.Claims[name] = append([]string{pkg}, claims...)
+}
+
+// Drops pkg's claim on a link. Returns the package the link passes to if pkg owned it, "" otherwise.
+func (idx *Index) Release(name, pkg string) string {
+	claims := idx.Claims[name]
+	i := slices.IndexFunc(claims, sameAs(pkg))
+	if i < 0 {
+		return ""
+	}
+	claims = slices.Delete(slices.Clone(claims), i, i+1)
+	if len(claims) == 0 {
+		delete(idx.Claims, name)
+		return ""
+	}
+	idx.Claims[name] = claims
+	if i == 0 {
+		return claims[0]
+	}
+	return ""
+}
+
+// Returns the "owner/repo" reference of a package, the way it's kept in the index.
+func Ref(owner, repo string) string {
+	return owner + "/" + repo
+}
+
+// Reports whether two references are to the same package. GitHub names are case-insensitive.
+func SamePackage(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+func sameAs(pkg string) func(string) bool {
+	return func(p string) bool {
+		return SamePackage(p, pkg)
+	}
+}
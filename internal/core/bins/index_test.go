@@ -0,0 +1,54 @@
+package bins
+
+import (
+	"parm/internal/config"
+	"slices"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	config.Cfg.ParmPkgPath = t.TempDir()
+
+	idx, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	idx.Claim("fd", "sharkdp/fd")
+	idx.Claim("fd", "other/fd")
+	idx.Claim("fd", "Sharkdp/FD")
+	if got := idx.Owner("fd"); got != "sharkdp/fd" {
+		t.Errorf("Owner() = %q, want the first claim", got)
+	}
+	if got := idx.Claims["fd"]; len(got) != 2 {
+		t.Errorf("Claim() = %v, want a claim per package", got)
+	}
+
+	idx.Take("fd", "other/fd")
+	if got := idx.Owner("fd"); got != "other/fd" {
+		t.Errorf("Owner() after Take() = %q, want other/fd", got)
+	}
+	if err := idx.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	idx, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got := idx.Names("sharkdp/fd"); !slices.Equal(got, []string{"fd"}) {
+		t.Errorf("Names() = %v, want [fd]", got)
+	}
+	if next := idx.Release("fd", "sharkdp/fd"); next != "" {
+		t.Errorf("Release() of a shadowed claim = %q, want none", next)
+	}
+	idx.Claim("fd", "sharkdp/fd")
+	if next := idx.Release("fd", "other/fd"); next != "sharkdp/fd" {
+		t.Errorf("Release() of the owner = %q, want sharkdp/fd", next)
+	}
+	if next := idx.Release("fd", "sharkdp/fd"); next != "" || idx.Claimed("fd", "sharkdp/fd") {
+		t.Errorf("Release() of the last claim = %q, want none", next)
+	}
+	if _, ok := idx.Claims["fd"]; ok {
+		t.Error("Release() kept a link no package claims")
+	}
+}
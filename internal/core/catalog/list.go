@@ -1,10 +1,15 @@
 package catalog
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"parm/internal/config"
 	"parm/internal/manifest"
+	"parm/internal/parmutil"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -45,7 +50,8 @@ func GetAllPkgManifest() ([]*manifest.Manifest, error) {
 	}
 
 	for _, file := range entries {
-		if !file.IsDir() {
+		// dot dirs like the journal aren't owners
+		if !file.IsDir() || strings.HasPrefix(file.Name(), ".") {
 			continue
 		}
 		path := filepath.Join(pkgDirPath, file.Name())
@@ -54,7 +60,7 @@ func GetAllPkgManifest() ([]*manifest.Manifest, error) {
 			continue
 		}
 		for _, pkg := range pkgs {
-			fullFilePath := filepath.Join(path, pkg.Name())
+			fullFilePath := parmutil.GetActiveDir(filepath.Join(path, pkg.Name()))
 			man, err := manifest.Read(fullFilePath)
 			if err != nil {
 				// cannot find manifest, assume it's not an installation folder and continue
@@ -66,3 +72,66 @@ func GetAllPkgManifest() ([]*manifest.Manifest, error) {
 
 	return mans, nil
 }
+
+// Returns the manifest of every installed version of a package, sorted from oldest to newest install.
+func GetInstalledVersions(owner, repo string) ([]*manifest.Manifest, error) {
+	dirs, err := parmutil.ListVersionDirs(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var mans []*manifest.Manifest
+	for _, dir := range dirs {
+		man, err := manifest.Read(filepath.Join(parmutil.GetPkgDir(owner, repo), dir))
+		if err != nil {
+			continue
+		}
+		mans = append(mans, man)
+	}
+	slices.SortStableFunc(mans, func(a, b *manifest.Manifest) int {
+		return strings.Compare(a.LastUpdated, b.LastUpdated)
+	})
+	return mans, nil
+}
+
+// Moves every package installed before side-by-side versions into its own version dir.
+// Only runs until every package has been migrated once, which is recorded in parm_pkg_path.
+func MigrateLegacyLayout() error {
+	pkgDirPath := config.Cfg.ParmPkgPath
+	if pkgDirPath == "" {
+		return nil
+	}
+	marker := filepath.Join(pkgDirPath, parmutil.LAYOUT_MIGRATED_FILE)
+	if _, err := os.Stat(marker); err == nil {
+		return nil
+	}
+	owners, err := os.ReadDir(pkgDirPath)
+	if err != nil {
+		return nil
+	}
+
+	var errs []error
+	for _, owner := range owners {
+		if !owner.IsDir() || strings.HasPrefix(owner.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(pkgDirPath, owner.Name())
+		repos, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, repo := range repos {
+			if !repo.IsDir() || strings.HasPrefix(repo.Name(), parmutil.STAGING_DIR_PREFIX) ||
+				strings.HasPrefix(repo.Name(), parmutil.MIGRATING_DIR_PREFIX) {
+				continue
+			}
+			if err := manifest.MigrateLegacyLayout(filepath.Join(path, repo.Name())); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return os.WriteFile(marker, nil, 0o644)
+}
@@ -5,7 +5,9 @@ import (
 	"path/filepath"
 	"testing"
 
+	"parm/internal/config"
 	"parm/internal/manifest"
+	"parm/internal/parmutil"
 
 	"github.com/spf13/viper"
 )
@@ -237,3 +239,75 @@ func anySubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestGetAllPkgManifest_ActiveVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	for _, ver := range []string{"v1.0.0", "v2.0.0"} {
+		dir := filepath.Join(tmpDir, "owner", "repo", ver)
+		os.MkdirAll(dir, 0755)
+		m := &manifest.Manifest{
+			Owner:       "owner",
+			Repo:        "repo",
+			Version:     ver,
+			InstallType: manifest.Release,
+		}
+		m.Write(dir)
+	}
+	parmutil.SetCurrentVersion("owner", "repo", "v1.0.0")
+
+	viper.Set("parm_pkg_path", tmpDir)
+	defer viper.Reset()
+
+	manifests, err := GetAllPkgManifest()
+	if err != nil {
+		t.Fatalf("GetAllPkgManifest() error: %v", err)
+	}
+	if len(manifests) != 1 {
+		t.Fatalf("GetAllPkgManifest() returned %d manifests, want 1", len(manifests))
+	}
+	if manifests[0].Version != "v1.0.0" {
+		t.Errorf("Manifest version = %v, want the active version v1.0.0", manifests[0].Version)
+	}
+
+	versions, err := GetInstalledVersions("owner", "repo")
+	if err != nil {
+		t.Fatalf("GetInstalledVersions() error: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("GetInstalledVersions() returned %d versions, want 2", len(versions))
+	}
+}
+
+func TestMigrateLegacyLayout_RunsOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	legacy := func(repo string) string {
+		pkgDir := filepath.Join(tmpDir, "owner", repo)
+		os.MkdirAll(pkgDir, 0755)
+		m := &manifest.Manifest{Owner: "owner", Repo: repo, Version: "v1.0.0", InstallType: manifest.Release}
+		if err := m.Write(pkgDir); err != nil {
+			t.Fatal(err)
+		}
+		return pkgDir
+	}
+
+	first := legacy("first")
+	if err := MigrateLegacyLayout(); err != nil {
+		t.Fatalf("MigrateLegacyLayout() error: %v", err)
+	}
+	if got := parmutil.GetInstallDir("owner", "first"); got != filepath.Join(first, "v1.0.0") {
+		t.Errorf("GetInstallDir() = %v, want the v1.0.0 dir", got)
+	}
+
+	// packages aren't scanned again once everything was migrated
+	second := legacy("second")
+	if err := MigrateLegacyLayout(); err != nil {
+		t.Fatalf("MigrateLegacyLayout() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(second, manifest.ManifestFileName)); err != nil {
+		t.Errorf("MigrateLegacyLayout() ran again: %v", err)
+	}
+}
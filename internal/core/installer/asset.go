@@ -0,0 +1,166 @@
+package installer
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"parm/internal/config"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-github/v74/github"
+)
+
+var ErrNoAssetChosen = errors.New("no asset chosen")
+
+// A release asset along with how well its name matches the current platform.
+type AssetCandidate struct {
+	Asset *github.ReleaseAsset
+	Score int
+
+	// the part of Score that comes from matching the OS and architecture
+	platformScore int
+}
+
+// Lets the user choose between release assets when the scoring can't tell which one to install.
+// Candidates are sorted by score, highest first.
+type AssetPicker func(tag string, candidates []AssetCandidate) (*github.ReleaseAsset, error)
+
+// Returned when no asset clearly matches the current platform and there is no picker to ask the user with.
+type AmbiguousAssetError struct {
+	Tag        string
+	Candidates []AssetCandidate
+}
+
+func (e *AmbiguousAssetError) Error() string {
+	return fmt.Sprintf("cannot choose an asset from release %s automatically, pick one with --asset:\n%s",
+		e.Tag, FormatCandidates(e.Candidates))
+}
+
+// Formats candidates as a numbered table of their names, sizes and scores.
+func FormatCandidates(candidates []AssetCandidate) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\t#\tASSET\tSIZE\tSCORE")
+	for i, c := range candidates {
+		fmt.Fprintf(w, "\t%d\t%s\t%s\t%d\n", i+1, c.Asset.GetName(), FormatSize(c.Asset.GetSize()), c.Score)
+	}
+	w.Flush()
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Formats a size in bytes for humans, e.g. "2.0 MiB".
+func FormatSize(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := unit, 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// picks the asset to install from a release. Assets are narrowed down by the package's asset rules first.
+// The top scoring asset is then used when it matches both the OS and the architecture and nothing else
+// ties with it, otherwise the user is asked to choose.
+func pickReleaseAsset(rel *github.RepositoryRelease, plat Platform, rules config.PackageRules, picker AssetPicker) (*github.ReleaseAsset, error) {
+	assets, err := matchAssetRule(rel.Assets, rules)
+	if err != nil {
+		return nil, err
+	}
+	if len(assets) == 0 && rules.HasAssetMatcher() {
+		return nil, fmt.Errorf("no asset in release %s matches the configured asset rule %q", rel.GetTagName(), cmp.Or(rules.Asset, rules.AssetRegex))
+	}
+
+	scored := scoreReleaseAssets(assets, plat)
+	if len(scored) == 0 {
+		return nil, fmt.Errorf("err: no compatible binary found for release %s", rel.GetTagName())
+	}
+	scored = applyPreferences(scored, rules.Prefer, rules.Avoid)
+
+	// an asset singled out by the configured rule needs no confirmation
+	if len(scored) == 1 && rules.HasAssetMatcher() {
+		return scored[0].Asset, nil
+	}
+	tied := len(scored) > 1 && scored[1].Score == scored[0].Score
+	if !tied && scored[0].platformScore >= minScoreMatch {
+		return scored[0].Asset, nil
+	}
+
+	if picker == nil {
+		return nil, &AmbiguousAssetError{Tag: rel.GetTagName(), Candidates: scored}
+	}
+	ass, err := picker(rel.GetTagName(), scored)
+	if err != nil {
+		return nil, err
+	}
+	if ass == nil {
+		return nil, ErrNoAssetChosen
+	}
+	return ass, nil
+}
+
+// keeps the assets whose name matches the asset glob or regex configured for a package
+func matchAssetRule(assets []*github.ReleaseAsset, rules config.PackageRules) ([]*github.ReleaseAsset, error) {
+	var match func(name string) bool
+	switch {
+	case rules.Asset != "":
+		if _, err := path.Match(rules.Asset, ""); err != nil {
+			return nil, fmt.Errorf("invalid asset glob %q in config: \n%w", rules.Asset, err)
+		}
+		match = func(name string) bool {
+			ok, _ := path.Match(rules.Asset, name)
+			return ok
+		}
+	case rules.AssetRegex != "":
+		re, err := regexp.Compile(rules.AssetRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset regex %q in config: \n%w", rules.AssetRegex, err)
+		}
+		match = re.MatchString
+	default:
+		return assets, nil
+	}
+
+	var matched []*github.ReleaseAsset
+	for _, a := range assets {
+		if match(a.GetName()) {
+			matched = append(matched, a)
+		}
+	}
+	return matched, nil
+}
+
+// drops avoided assets unless nothing else is left, then narrows the assets down to preferred ones,
+// as long as a preferred asset matches the platform as well as the best asset does.
+// Preferences are applied in order, so earlier ones win.
+func applyPreferences(scored []AssetCandidate, prefer, avoid []string) []AssetCandidate {
+	if len(avoid) > 0 {
+		kept := slices.DeleteFunc(slices.Clone(scored), func(c AssetCandidate) bool {
+			return containsAny(strings.ToLower(c.Asset.GetName()), lowerAll(avoid))
+		})
+		if len(kept) > 0 {
+			scored = kept
+		}
+	}
+
+	for _, pref := range lowerAll(prefer) {
+		best := 0
+		for _, c := range scored {
+			best = max(best, c.platformScore)
+		}
+		preferred := slices.DeleteFunc(slices.Clone(scored), func(c AssetCandidate) bool {
+			return c.platformScore < best || !strings.Contains(strings.ToLower(c.Asset.GetName()), pref)
+		})
+		if len(preferred) > 0 {
+			scored = preferred
+		}
+	}
+	return scored
+}
@@ -0,0 +1,158 @@
+package installer
+
+import (
+	"errors"
+	"parm/internal/config"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+)
+
+func releaseWithAssets(names ...string) *github.RepositoryRelease {
+	rel := &github.RepositoryRelease{TagName: github.Ptr("v1.0.0")}
+	for _, name := range names {
+		rel.Assets = append(rel.Assets, &github.ReleaseAsset{Name: github.Ptr(name), Size: github.Ptr(1 << 20)})
+	}
+	return rel
+}
+
+func TestPickReleaseAsset_ClearWinner(t *testing.T) {
+	rel := releaseWithAssets("app-linux-amd64.tar.gz", "app-darwin-arm64.tar.gz")
+	picker := func(string, []AssetCandidate) (*github.ReleaseAsset, error) {
+		t.Fatal("picker should not be called when the top asset is unambiguous")
+		return nil, nil
+	}
+
+	ass, err := pickReleaseAsset(rel, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}, config.PackageRules{}, picker)
+	if err != nil {
+		t.Fatalf("pickReleaseAsset() error: %v", err)
+	}
+	if ass.GetName() != "app-linux-amd64.tar.gz" {
+		t.Errorf("pickReleaseAsset() = %s, want app-linux-amd64.tar.gz", ass.GetName())
+	}
+}
+
+func TestPickReleaseAsset_Ambiguous(t *testing.T) {
+	tests := map[string]*github.RepositoryRelease{
+		"tie":       releaseWithAssets("app-linux-amd64.tar.gz", "app-linux-x86_64.tar.gz"),
+		"low score": releaseWithAssets("app-3.5a.tar.gz", "app-3.5a.zip"),
+	}
+	for name, rel := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []AssetCandidate
+			picker := func(tag string, candidates []AssetCandidate) (*github.ReleaseAsset, error) {
+				got = candidates
+				return candidates[1].Asset, nil
+			}
+			ass, err := pickReleaseAsset(rel, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}, config.PackageRules{}, picker)
+			if err != nil {
+				t.Fatalf("pickReleaseAsset() error: %v", err)
+			}
+			if len(got) != len(rel.Assets) {
+				t.Fatalf("picker got %d candidates, want %d", len(got), len(rel.Assets))
+			}
+			if ass != got[1].Asset {
+				t.Errorf("pickReleaseAsset() = %s, want the picked asset %s", ass.GetName(), got[1].Asset.GetName())
+			}
+
+			// without a picker the candidates end up in the error
+			_, err = pickReleaseAsset(rel, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}, config.PackageRules{}, nil)
+			var ambErr *AmbiguousAssetError
+			if !errors.As(err, &ambErr) {
+				t.Fatalf("pickReleaseAsset() error = %v, want AmbiguousAssetError", err)
+			}
+			for _, a := range rel.Assets {
+				if !strings.Contains(err.Error(), a.GetName()) {
+					t.Errorf("error does not list %s:\n%v", a.GetName(), err)
+				}
+			}
+		})
+	}
+}
+
+func TestPickReleaseAsset_Cancelled(t *testing.T) {
+	rel := releaseWithAssets("app.tar.gz")
+	picker := func(string, []AssetCandidate) (*github.ReleaseAsset, error) {
+		return nil, nil
+	}
+	if _, err := pickReleaseAsset(rel, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}, config.PackageRules{}, picker); !errors.Is(err, ErrNoAssetChosen) {
+		t.Errorf("pickReleaseAsset() error = %v, want ErrNoAssetChosen", err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int]string{
+		512:       "512 B",
+		2048:      "2.0 KiB",
+		5 << 20:   "5.0 MiB",
+		3<<30 + 1: "3.0 GiB",
+	}
+	for size, want := range tests {
+		if got := FormatSize(size); got != want {
+			t.Errorf("FormatSize(%d) = %s, want %s", size, got, want)
+		}
+	}
+}
+
+func TestPickReleaseAsset_Rules(t *testing.T) {
+	rel := releaseWithAssets(
+		"rg-x86_64-unknown-linux-gnu.tar.gz",
+		"rg-x86_64-unknown-linux-musl.tar.gz",
+		"rg-aarch64-unknown-linux-musl.tar.gz",
+		"rg_amd64.deb",
+	)
+	tests := []struct {
+		name  string
+		rules config.PackageRules
+		want  string
+	}{
+		{"no rules", config.PackageRules{}, "rg-x86_64-unknown-linux-gnu.tar.gz"},
+		{"glob", config.PackageRules{Asset: "*-x86_64-unknown-linux-musl.tar.gz"}, "rg-x86_64-unknown-linux-musl.tar.gz"},
+		{"regex", config.PackageRules{AssetRegex: `^rg_.*\.deb$`}, "rg_amd64.deb"},
+		{"glob narrowed by scoring", config.PackageRules{Asset: "*musl*"}, "rg-aarch64-unknown-linux-musl.tar.gz"},
+		{"prefer", config.PackageRules{Prefer: []string{"MUSL"}}, "rg-x86_64-unknown-linux-musl.tar.gz"},
+		{"prefer without a platform match", config.PackageRules{Prefer: []string{"aarch64"}}, "rg-x86_64-unknown-linux-gnu.tar.gz"},
+		{"avoid", config.PackageRules{Avoid: []string{"gnu", ".deb"}}, "rg-x86_64-unknown-linux-musl.tar.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goarch := "amd64"
+			if tt.name == "glob narrowed by scoring" {
+				goarch = "arm64"
+			}
+			ass, err := pickReleaseAsset(rel, Platform{OS: "linux", Arch: goarch, Libc: "gnu"}, tt.rules, nil)
+			if err != nil {
+				t.Fatalf("pickReleaseAsset() error: %v", err)
+			}
+			if ass.GetName() != tt.want {
+				t.Errorf("pickReleaseAsset() = %s, want %s", ass.GetName(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPickReleaseAsset_RuleWithoutMatch(t *testing.T) {
+	rel := releaseWithAssets("app-linux-amd64.tar.gz")
+	for _, rules := range []config.PackageRules{{Asset: "*.zip"}, {Asset: "[invalid"}, {AssetRegex: "("}} {
+		if _, err := pickReleaseAsset(rel, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}, rules, nil); err == nil {
+			t.Errorf("pickReleaseAsset(%+v) should fail", rules)
+		}
+	}
+}
+
+func TestScoreReleaseAssets_Aliases(t *testing.T) {
+	saved := config.Cfg.Assets
+	t.Cleanup(func() { config.Cfg.Assets = saved })
+	config.Cfg.Assets.OSAliases = map[string][]string{"linux": {"Alpine"}}
+	config.Cfg.Assets.ArchAliases = map[string][]string{"amd64": {"x86-64"}}
+
+	rel := releaseWithAssets("app-alpine-x86-64.tar.gz", "app-windows-x86-64.zip")
+	ass, err := pickReleaseAsset(rel, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}, config.PackageRules{}, nil)
+	if err != nil {
+		t.Fatalf("pickReleaseAsset() error: %v", err)
+	}
+	if ass.GetName() != "app-alpine-x86-64.tar.gz" {
+		t.Errorf("pickReleaseAsset() = %s, want app-alpine-x86-64.tar.gz", ass.GetName())
+	}
+}
@@ -0,0 +1,59 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"parm/internal/config"
+	"parm/internal/core/verify"
+	"parm/internal/manifest"
+	"path/filepath"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// Verifies that a downloaded asset has a GitHub build provenance attestation made by a workflow of its package's repo.
+// Attestations are looked up by the asset's digest, so they don't have to be published with the release.
+func (in *Installer) verifyAttestation(ctx context.Context, owner, repo, path string) (*manifest.Signer, error) {
+	rules := config.Cfg.RulesFor(owner, repo).Attestation
+	if rules.TrustedRoots == "" {
+		return nil, fmt.Errorf("%w; set packages.\"%s/%s\".attestation.trusted_roots to the Fulcio certificates the attestation must chain to",
+			verify.ErrNoTrustedRoots, owner, repo)
+	}
+	roots, err := os.ReadFile(rules.TrustedRoots)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read trusted roots: \n%w", err)
+	}
+	policy := verify.AttestationPolicy{Repo: owner + "/" + repo, SignerWorkflow: rules.SignerWorkflow, Roots: roots}
+
+	sum, err := verify.GetSha256(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash asset: \n%w", err)
+	}
+	digest := verify.Checksum{Algorithm: "sha256", Hex: sum}
+
+	name := filepath.Base(path)
+	res, _, err := in.client.ListAttestations(ctx, owner, repo, digest.String(), nil)
+	var ghErr *github.ErrorResponse
+	switch {
+	case errors.As(err, &ghErr) && ghErr.Response.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%s/%s has no attestation for %q; re-run with --verify=checksum to skip it", owner, repo, name)
+	case err != nil:
+		return nil, fmt.Errorf("cannot fetch attestations: \n%w", err)
+	}
+
+	var errs []error
+	for _, att := range res.Attestations {
+		signer, err := verify.VerifyAttestation(att.Bundle, digest, policy)
+		if err == nil {
+			return &manifest.Signer{Method: signer.Method, Identity: signer.Identity}, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%s/%s has no attestation for %q; re-run with --verify=checksum to skip it", owner, repo, name)
+	}
+	return nil, fmt.Errorf("fatal: no valid build provenance attestation for %q: \n%w", name, errors.Join(errs...))
+}
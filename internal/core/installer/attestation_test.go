@@ -0,0 +1,207 @@
+package installer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"parm/internal/config"
+	"parm/internal/core/verify"
+	"parm/internal/gh"
+	"parm/internal/manifest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+)
+
+// a stand-in for Fulcio, issuing the certificates attestations are signed with
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// writes the CA's certificate to a PEM file, for attestation.trusted_roots
+func (ca *testCA) rootsFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fulcio.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// records a GitHub attestation of build provenance for an artifact, signed by a workflow of repo
+func provenanceAttestation(t *testing.T, ca *testCA, repo, sha256Hex string) *github.Attestation {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	utf8 := func(s string) []byte {
+		b, _ := asn1.MarshalWithParams(s, "utf8")
+		return b
+	}
+	workflow, _ := url.Parse("https://github.com/" + repo + "/.github/workflows/release.yml@refs/tags/v1.0.0")
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(9 * time.Minute),
+		URIs:         []*url.URL{workflow},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}, Value: utf8("https://token.actions.githubusercontent.com")},
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}, Value: utf8("https://github.com/" + repo)},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, cert, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payloadType := "application/vnd.in-toto+json"
+	stmt, _ := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v1",
+		"subject":       []map[string]any{{"name": "asset", "digest": map[string]string{"sha256": sha256Hex}}},
+		"predicateType": "https://slsa.dev/provenance/v1",
+	})
+	pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(stmt), stmt)
+	digest := sha256.Sum256([]byte(pae))
+	sig, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+
+	bundle, _ := json.Marshal(map[string]any{
+		"mediaType":            "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"verificationMaterial": map[string]any{"certificate": map[string]any{"rawBytes": der}},
+		"dsseEnvelope": map[string]any{
+			"payload":     stmt,
+			"payloadType": payloadType,
+			"signatures":  []map[string]any{{"sig": sig}},
+		},
+	})
+	return &github.Attestation{Bundle: bundle}
+}
+
+func TestInstallFromRelease_Attestation(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	archivePath := createTestTarGzWithBinary(t, tmpDir)
+	sum, err := verify.GetSha256(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetName := fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, archivePath)
+	}))
+	defer server.Close()
+
+	ca, other := newTestCA(t), newTestCA(t)
+	roots := ca.rootsFile(t)
+	orig := config.Cfg.Packages
+	defer func() { config.Cfg.Packages = orig }()
+
+	tests := []struct {
+		name         string
+		attestations []*github.Attestation
+		roots        string
+		wantErr      string
+	}{
+		{"attested", []*github.Attestation{provenanceAttestation(t, ca, "owner/repo", sum)}, roots, ""},
+		{"one of several", []*github.Attestation{provenanceAttestation(t, ca, "fork/repo", sum), provenanceAttestation(t, ca, "owner/repo", sum)}, roots, ""},
+		{"built elsewhere", []*github.Attestation{provenanceAttestation(t, ca, "fork/repo", sum)}, roots, "no valid build provenance attestation"},
+		{"other artifact", []*github.Attestation{provenanceAttestation(t, ca, "owner/repo", strings.Repeat("0", 64))}, roots, "different artifact"},
+		{"untrusted issuer", []*github.Attestation{provenanceAttestation(t, other, "owner/repo", sum)}, roots, "untrusted signing certificate"},
+		{"no trusted roots", []*github.Attestation{provenanceAttestation(t, ca, "owner/repo", sum)}, "", "no trusted roots"},
+		{"none", nil, roots, "has no attestation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.Packages = map[string]config.PackageRules{
+				"owner/repo": {Attestation: config.AttestationRules{TrustedRoots: tt.roots}},
+			}
+			attestations := mock.WithRequestMatch(
+				mock.GetReposAttestationsByOwnerByRepoBySubjectDigest,
+				github.AttestationsResponse{Attestations: tt.attestations},
+			)
+			if tt.attestations == nil {
+				attestations = mock.WithRequestMatchHandler(
+					mock.GetReposAttestationsByOwnerByRepoBySubjectDigest,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if !strings.HasSuffix(r.URL.Path, "/sha256:"+sum) {
+							t.Errorf("attestations requested for %s, want the asset's digest", r.URL.Path)
+						}
+						mock.WriteError(w, http.StatusNotFound, "Not Found")
+					}),
+				)
+			}
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				attestations,
+				mock.WithRequestMatchHandler(
+					mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						http.Redirect(w, r, server.URL+"/asset", http.StatusFound)
+					}),
+				),
+			)
+
+			client := gh.New(context.Background(), "", gh.WithHTTPClient(mockedHTTPClient))
+			installer := New(client.Repos())
+			release := &github.RepositoryRelease{
+				TagName: github.Ptr("v1.0.0"),
+				Assets:  []*github.ReleaseAsset{{ID: github.Ptr(int64(1)), Name: github.Ptr(assetName)}},
+			}
+			pkgPath := filepath.Join(tmpDir, "owner", "repo")
+			opts := InstallFlags{Type: manifest.Release, VerifyLevel: verify.LevelAttestation}
+
+			res, err := installer.installFromRelease(context.Background(), pkgPath, "owner", "repo", release, opts, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("installFromRelease() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("installFromRelease() error: %v", err)
+			}
+			defer res.Abort()
+			if res.Signer == nil || res.Signer.Method != "attestation" || !strings.HasPrefix(res.Signer.Identity, "https://github.com/owner/repo/") {
+				t.Errorf("installFromRelease() signer = %+v, want a workflow of owner/repo", res.Signer)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package installer
+
+import (
+	"parm/internal/config"
+	"parm/internal/manifest"
+)
+
+// Works out which executables of a package to link and what to call them. Each is taken from the command line
+// if given there, else from the package's config, else from its previous install, so updates keep them.
+func BinSelection(owner, repo string, bins []string, renames map[string]string, prev *manifest.Manifest) ([]string, map[string]string) {
+	rules := config.Cfg.RulesFor(owner, repo)
+	if bins == nil {
+		bins = rules.Bins
+	}
+	if renames == nil {
+		renames = rules.Rename
+	}
+	if prev != nil {
+		if bins == nil {
+			bins = prev.Bins
+		}
+		if renames == nil {
+			renames = prev.Renames
+		}
+	}
+	return bins, renames
+}
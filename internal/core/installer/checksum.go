@@ -0,0 +1,166 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"parm/internal/core/verify"
+	"parm/internal/manifest"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// checksum files are small, anything bigger isn't one
+const maxChecksumFileSize = 4 << 20
+
+var checksumSidecarSuffixes = []string{".sha256", ".sha256sum", ".sha512", ".sha512sum"}
+
+var checksumListWords = []string{"checksum", "sha256sum", "sha512sum", "shasum"}
+
+var signatureSuffixes = []string{".sig", ".asc", ".gpg", ".pem", ".crt", ".cert", ".minisig", ".bundle", ".sigstore", ".sigstore.json"}
+
+// A release asset that may hold the checksum of another asset.
+type checksumSource struct {
+	asset *github.ReleaseAsset
+	// set for files that only belong to the asset, like "<asset>.sha256", which may hold a bare checksum
+	bare bool
+}
+
+// Finds the checksum files of a release that may list ass: its own checksum file like "<asset>.sha256"
+// first, then lists like "checksums.txt" or "SHA256SUMS".
+func checksumSources(rel *github.RepositoryRelease, ass *github.ReleaseAsset) []checksumSource {
+	name := strings.ToLower(ass.GetName())
+	names := make([]string, len(rel.Assets))
+	for i, a := range rel.Assets {
+		names[i] = strings.ToLower(a.GetName())
+	}
+
+	var sidecars, lists []checksumSource
+	for i, a := range rel.Assets {
+		lower := names[i]
+		if a == ass || hasAnySuffix(lower, signatureSuffixes) {
+			continue
+		}
+
+		trimmed, isSidecar := trimAnySuffix(lower, checksumSidecarSuffixes)
+		switch {
+		case isSidecar && trimmed == name:
+			sidecars = append(sidecars, checksumSource{asset: a, bare: true})
+		case isSidecar && slices.Contains(names, trimmed):
+			// belongs to another asset
+		case isSidecar || containsAny(lower, checksumListWords):
+			lists = append(lists, checksumSource{asset: a})
+		}
+	}
+	return append(sidecars, lists...)
+}
+
+// Returns the checksum ass should have and where it came from. Returns nil if the release has
+// neither a digest for the asset nor a checksum file that lists it.
+func (in *Installer) expectedChecksum(ctx context.Context, owner, repo string, rel *github.RepositoryRelease, ass *github.ReleaseAsset) (*verify.Checksum, string, error) {
+	if ass.Digest != nil {
+		// digests GitHub computes with algorithms parm doesn't know fall back to checksum files
+		if c, err := verify.ParseDigest(ass.GetDigest()); err == nil {
+			return &c, "GitHub digest", nil
+		}
+	}
+
+	for _, src := range checksumSources(rel, ass) {
+		data, err := in.fetchAsset(ctx, owner, repo, src.asset)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot download checksum file %q: \n%w", src.asset.GetName(), err)
+		}
+		if c, ok := verify.FindChecksum(data, ass.GetName(), src.bare); ok {
+			return &c, src.asset.GetName(), nil
+		}
+	}
+	return nil, "", nil
+}
+
+// Checks a downloaded asset against the checksum the release publishes for it, and its signature or attestation,
+// as strictly as level asks for. Returns who signed the asset if its signature or attestation was verified.
+func (in *Installer) verifyAsset(ctx context.Context, owner, repo string, rel *github.RepositoryRelease, ass *github.ReleaseAsset, path string, level verify.Level) (*manifest.Signer, error) {
+	if level == verify.LevelNone {
+		return nil, nil
+	}
+
+	var signer *manifest.Signer
+	var want *verify.Checksum
+	var source string
+	var err error
+	switch level {
+	case verify.LevelSignature:
+		// a signed checksum file takes precedence over GitHub's digest
+		if signer, want, source, err = in.verifySignature(ctx, owner, repo, rel, ass, path); err != nil {
+			return nil, err
+		}
+	case verify.LevelAttestation:
+		if signer, err = in.verifyAttestation(ctx, owner, repo, path); err != nil {
+			return nil, err
+		}
+	}
+	if want == nil {
+		if want, source, err = in.expectedChecksum(ctx, owner, repo, rel, ass); err != nil {
+			return nil, err
+		}
+	}
+	if want == nil {
+		// a signature or attestation of the asset itself vouches for it without a checksum
+		if level >= verify.LevelChecksum && signer == nil {
+			return nil, fmt.Errorf("no digest or checksum file available for %q; re-run with --verify=digest or --no-verify", ass.GetName())
+		}
+		return signer, nil
+	}
+
+	ok, got, err := verify.VerifyChecksum(path, *want)
+	if err != nil {
+		return nil, fmt.Errorf("could not verify checksum: \n%w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("fatal: checksum invalid:\n\thad %s\n\twanted %s (from %s)", got, want, source)
+	}
+	return signer, nil
+}
+
+// downloads a small asset, like a checksum file, into memory
+func (in *Installer) fetchAsset(ctx context.Context, owner, repo string, ass *github.ReleaseAsset) ([]byte, error) {
+	rc, redirURL, err := in.client.DownloadReleaseAsset(ctx, owner, repo, ass.GetID(), http.DefaultClient)
+	if err != nil {
+		return nil, err
+	}
+	if redirURL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, redirURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("GET %s: %s", redirURL, resp.Status)
+		}
+		rc = resp.Body
+	}
+	defer rc.Close()
+
+	return io.ReadAll(io.LimitReader(rc, maxChecksumFileSize))
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	_, ok := trimAnySuffix(s, suffixes)
+	return ok
+}
+
+func trimAnySuffix(s string, suffixes []string) (string, bool) {
+	for _, suffix := range suffixes {
+		if trimmed, ok := strings.CutSuffix(s, suffix); ok {
+			return trimmed, true
+		}
+	}
+	return s, false
+}
@@ -0,0 +1,116 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"parm/internal/config"
+	"parm/internal/core/verify"
+	"parm/internal/manifest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+)
+
+func TestChecksumSources(t *testing.T) {
+	rel := releaseWithAssets(
+		"tool-linux-amd64.tar.gz",
+		"tool-linux-amd64.tar.gz.sha256",
+		"tool-darwin-arm64.tar.gz",
+		"tool-darwin-arm64.tar.gz.sha256",
+		"tool_1.0.0_checksums.txt",
+		"tool_1.0.0_checksums.txt.sig",
+		"SHA512SUMS",
+		"README.md",
+	)
+	var got []string
+	for _, src := range checksumSources(rel, rel.Assets[0]) {
+		got = append(got, fmt.Sprintf("%s:%v", src.asset.GetName(), src.bare))
+	}
+	want := "tool-linux-amd64.tar.gz.sha256:true tool_1.0.0_checksums.txt:false SHA512SUMS:false"
+	if strings.Join(got, " ") != want {
+		t.Errorf("checksumSources() = %v, want %s", got, want)
+	}
+}
+
+func TestInstallFromRelease_ChecksumFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	archivePath := createTestTarGzWithBinary(t, tmpDir)
+	sum, err := verify.GetSha256(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetName := fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+
+	tests := []struct {
+		name      string
+		checksums string
+		level     verify.Level
+		wantErr   string
+	}{
+		{"listed", fmt.Sprintf("%s  other.zip\n%s  %s\n", strings.Repeat("0", 64), sum, assetName), verify.LevelChecksum, ""},
+		{"mismatch", fmt.Sprintf("%s  %s\n", strings.Repeat("0", 64), assetName), verify.LevelDigest, "checksum invalid"},
+		{"not listed, required", fmt.Sprintf("%s  other.zip\n", sum), verify.LevelChecksum, "no digest or checksum file"},
+		{"not listed, optional", fmt.Sprintf("%s  other.zip\n", sum), verify.LevelDigest, ""},
+		{"signature required", fmt.Sprintf("%s  %s\n", sum, assetName), verify.LevelSignature, "signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/checksums" {
+					fmt.Fprint(w, tt.checksums)
+					return
+				}
+				http.ServeFile(w, r, archivePath)
+			}))
+			defer server.Close()
+
+			release := &github.RepositoryRelease{
+				TagName: github.Ptr("v1.0.0"),
+				Assets: []*github.ReleaseAsset{
+					{ID: github.Ptr(int64(1)), Name: github.Ptr(assetName)},
+					{ID: github.Ptr(int64(2)), Name: github.Ptr("checksums.txt")},
+				},
+			}
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if strings.HasSuffix(r.URL.Path, "/2") {
+							http.Redirect(w, r, server.URL+"/checksums", http.StatusFound)
+							return
+						}
+						http.Redirect(w, r, server.URL+"/asset", http.StatusFound)
+					}),
+				),
+			)
+
+			installer := New(github.NewClient(mockedHTTPClient).Repositories)
+			pkgPath := filepath.Join(tmpDir, "owner", "repo")
+			opts := InstallFlags{Type: manifest.Release, VerifyLevel: tt.level}
+
+			res, err := installer.installFromRelease(context.Background(), pkgPath, "owner", "repo", release, opts, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("installFromRelease() error = %v, want %q", err, tt.wantErr)
+				}
+				if _, err := os.Stat(filepath.Join(pkgPath, "v1.0.0")); !os.IsNotExist(err) {
+					t.Error("failed install left its version dir behind")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("installFromRelease() error: %v", err)
+			}
+			res.Abort()
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package installer
+
+import (
+	"os"
+	"parm/internal/config"
+	"parm/pkg/sysutil"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// formats of assets holding a single executable, which is unpacked without the format's suffix
+var singleFileFormats = []string{".gz", ".xz", ".zst", ".bz2", ".appimage"}
+
+// words in executable names that only say what the executable was built for, e.g. "x86_64-unknown-linux-musl"
+var platformWords = []string{
+	"unknown", "pc", "none", "static", "portable",
+	"gnu", "glibc", "musl", "gnueabi", "gnueabihf", "musleabi", "musleabihf", "eabi", "eabihf",
+}
+
+// Returns the executable unpacked from an asset holding nothing but that executable, e.g. "jq-linux-amd64".
+func bareExecutable(dir, assetName string) (string, bool) {
+	candidates := []string{assetName}
+	for _, f := range formatSuffixes {
+		if strings.HasSuffix(strings.ToLower(assetName), f.suffix) && slices.Contains(singleFileFormats, f.format) {
+			candidates = append(candidates, assetName[:len(assetName)-len(f.suffix)])
+		}
+	}
+	for _, name := range candidates {
+		path := filepath.Join(dir, name)
+		if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if isExec, err := sysutil.IsValidBinaryExecutable(path); err == nil && isExec {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Strips the OS, architecture, libc and version of a release from the name of an executable,
+// e.g. "jq-linux-amd64" becomes "jq" and "tool_1.2.0_x86_64-unknown-linux-musl" becomes "tool".
+// Falls back to the repo name if nothing else is left.
+func commandName(name, repo, tag string) string {
+	osTab := osTable.with(config.Cfg.Assets.OSAliases)
+	archTab := archTable.with(config.Cfg.Assets.ArchAliases)
+
+	base, ext := name, ""
+	if i := len(name) - len(".exe"); i > 0 && strings.EqualFold(name[i:], ".exe") {
+		base, ext = name[:i], name[i:]
+	}
+
+	// tags like "jq-1.7.1" can hold the name itself
+	versionWords := slices.DeleteFunc(splitWords(strings.ToLower(tag)), func(word string) bool {
+		return strings.EqualFold(word, repo)
+	})
+
+	var b strings.Builder
+	start := 0
+	for i := 0; i <= len(base); i++ {
+		if i < len(base) && !isSeparator(base[i]) {
+			continue
+		}
+		word := base[start:i]
+		if word != "" && !isPlatformWord(strings.ToLower(word), osTab, archTab, versionWords) {
+			if b.Len() > 0 {
+				b.WriteByte(base[start-1])
+			}
+			b.WriteString(word)
+		}
+		start = i + 1
+	}
+
+	if b.Len() == 0 {
+		return repo + ext
+	}
+	return b.String() + ext
+}
+
+func isPlatformWord(word string, osTab, archTab aliasTable, versionWords []string) bool {
+	return slices.Contains(platformWords, word) || isVersionWord(word, versionWords) ||
+		matchesWhole(word, osTab, true) || matchesWhole(word, archTab, false)
+}
+
+// reports whether word is one of the aliases in tab, optionally followed by digits like "linux64"
+func matchesWhole(word string, tab aliasTable, digitsAfter bool) bool {
+	for _, e := range tab {
+		for _, alias := range e.aliases {
+			rest, ok := strings.CutPrefix(word, alias)
+			if ok && (rest == "" || digitsAfter && isNumber(rest)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reports whether word is a number, a number prefixed with "v", or part of the release's tag
+func isVersionWord(word string, versionWords []string) bool {
+	return isNumber(strings.TrimPrefix(word, "v")) || slices.Contains(versionWords, word)
+}
+
+func isNumber(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r < 0x80 && isSeparator(byte(r))
+	})
+}
+
+func isSeparator(c byte) bool {
+	return c == '-' || c == '_' || c == '.'
+}
@@ -0,0 +1,89 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"parm/internal/config"
+	"parm/internal/manifest"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+)
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		name string
+		repo string
+		tag  string
+		want string
+	}{
+		{"jq-linux-amd64", "jq", "jq-1.7.1", "jq"},
+		{"yq_linux_amd64", "yq", "v4.44.3", "yq"},
+		{"yq_windows_amd64.exe", "yq", "v4.44.3", "yq.exe"},
+		{"tool-v1.2.0-x86_64-unknown-linux-musl", "tool", "v1.2.0", "tool"},
+		{"tool_1.2.0_darwin_arm64", "tool", "v1.2.0", "tool"},
+		{"tool-linux64", "tool", "v1.0.0", "tool"},
+		{"mc.RELEASE.2024-10-02", "mc", "RELEASE.2024-10-02", "mc"},
+		{"k9s-linux-armv7", "k9s", "v0.32.0", "k9s"},
+		{"docker-compose-linux-x86_64", "compose", "v2.29.0", "docker-compose"},
+		{"kubectl", "kubernetes", "v1.31.0", "kubectl"},
+		{"linux-amd64", "tool", "v1.0.0", "tool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commandName(tt.name, tt.repo, tt.tag); got != tt.want {
+				t.Errorf("commandName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallFromRelease_BareExecutable(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = filepath.Join(tmpDir, "pkg")
+
+	assetName := fmt.Sprintf("jq-%s-%s", runtime.GOOS, runtime.GOARCH)
+	binPath := filepath.Join(tmpDir, assetName)
+	// ELF magic, padded to the minimum header size
+	if err := os.WriteFile(binPath, append([]byte{0x7f, 0x45, 0x4c, 0x46}, make([]byte, 60)...), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, binPath)
+	}))
+	defer server.Close()
+
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, server.URL+"/asset", http.StatusFound)
+			}),
+		),
+	)
+	installer := New(github.NewClient(mockedHTTPClient).Repositories)
+	release := &github.RepositoryRelease{
+		TagName: github.Ptr("jq-1.7.1"),
+		Assets:  []*github.ReleaseAsset{{ID: github.Ptr(int64(1)), Name: github.Ptr(assetName)}},
+	}
+
+	pkgPath := filepath.Join(config.Cfg.ParmPkgPath, "jqlang", "jq")
+	res, err := installer.installFromRelease(context.Background(), pkgPath, "jqlang", "jq", release, InstallFlags{Type: manifest.Release}, nil)
+	if err != nil {
+		t.Fatalf("installFromRelease() error: %v", err)
+	}
+	defer res.Abort()
+
+	if _, err := os.Stat(filepath.Join(res.InstallPath, "jq")); err != nil {
+		t.Errorf("executable not installed as jq: %v", err)
+	}
+	if res.Asset.Executable != assetName {
+		t.Errorf("Asset.Executable = %q, want %q", res.Asset.Executable, assetName)
+	}
+}
@@ -2,13 +2,17 @@ package installer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"parm/internal/core/uninstaller"
+	"parm/internal/core/journal"
+	"parm/internal/core/switcher"
+	"parm/internal/core/verify"
 	"parm/internal/gh"
 	"parm/internal/manifest"
+	"parm/internal/parmutil"
 	"parm/pkg/progress"
 	"path/filepath"
 
@@ -21,16 +25,37 @@ type Installer struct {
 }
 
 type InstallFlags struct {
-	Type        manifest.InstallType
-	Version     *string
-	Asset       *string
+	Type    manifest.InstallType
+	Version *string
+	Asset   *string
+	// asset name pattern recorded by a previous install, ignored if Asset is set
+	AssetPattern *string
+	// asks the user to choose when the asset can't be picked automatically.
+	// If nil, such installs fail with an *AmbiguousAssetError instead.
+	Picker      AssetPicker
 	Strict      bool
-	VerifyLevel uint8
+	VerifyLevel verify.Level
+	// the executables to link, by name or path in the version dir. All of them if empty.
+	Bins []string
+	// names to link executables under instead of their own, keyed by executable name or path
+	Renames map[string]string
+	// links in parm_bin_path the package may take over from other packages shipping an executable of the same name
+	TakeBins []string
 }
 
+// The result of an install that has been promoted into its version dir, but isn't active yet.
+// Either Commit or Abort must be called on it.
 type InstallResult struct {
 	InstallPath string
 	Version     string
+	Asset       *manifest.Asset
+	// set if the asset's signature was verified
+	Signer *manifest.Signer
+	// how strictly the asset was verified
+	VerifyLevel verify.Level
+
+	tx       *journal.Transaction
+	takeBins []string
 }
 
 func New(cli *github.RepositoriesService) *Installer {
@@ -39,16 +64,18 @@ func New(cli *github.RepositoriesService) *Installer {
 	}
 }
 
-func (in *Installer) Install(ctx context.Context, owner, repo string, installPath string, opts InstallFlags, hooks *progress.Hooks) (*InstallResult, error) {
-	f, _ := os.Stat(installPath)
-	var err error
-
-	// if error is something else, ignore it for now and hope it propogates downwards if it's actually serious
-	if f != nil {
-		if err := uninstaller.Uninstall(ctx, owner, repo); err != nil {
-			return nil, err
-		}
+// Installs a release of a package into its own version dir under pkgPath. Other installed versions are left untouched.
+func (in *Installer) Install(ctx context.Context, owner, repo string, pkgPath string, opts InstallFlags, hooks *progress.Hooks) (*InstallResult, error) {
+	rel, err := in.resolveRelease(ctx, owner, repo, &opts)
+	if err != nil {
+		return nil, err
 	}
+	return in.installFromRelease(ctx, pkgPath, owner, repo, rel, opts, hooks)
+}
+
+// resolves the release to install. May correct opts.Type to the channel of the release.
+func (in *Installer) resolveRelease(ctx context.Context, owner, repo string, opts *InstallFlags) (*github.RepositoryRelease, error) {
+	var err error
 
 	var rel *github.RepositoryRelease
 	if opts.Type == manifest.PreRelease {
@@ -81,7 +108,53 @@ func (in *Installer) Install(ctx context.Context, owner, repo string, installPat
 		}
 	}
 
-	return in.installFromRelease(ctx, installPath, owner, repo, rel, opts, hooks)
+	return rel, nil
+}
+
+// Writes the manifest of the install and makes it the active version of its package.
+// If either step fails, the install is undone and the previously active version is restored.
+func (res *InstallResult) Commit(man *manifest.Manifest) error {
+	if err := man.Write(res.InstallPath); err != nil {
+		return errors.Join(err, res.Abort())
+	}
+	if err := res.mark(journal.StepManifest); err != nil {
+		return err
+	}
+
+	prev, _ := parmutil.GetCurrentVersion(man.Owner, man.Repo)
+	if err := switcher.Activate(man, res.takeBins...); err != nil {
+		errs := []error{err, res.Abort()}
+		if prev != "" {
+			_, uErr := switcher.UseVersion(man.Owner, man.Repo, prev)
+			errs = append(errs, uErr)
+		} else {
+			errs = append(errs, switcher.Deactivate(man))
+		}
+		return errors.Join(errs...)
+	}
+	if err := res.mark(journal.StepLink); err != nil {
+		return err
+	}
+
+	if res.tx == nil {
+		return nil
+	}
+	return res.tx.Commit()
+}
+
+// Undoes the install, putting back whatever it replaced.
+func (res *InstallResult) Abort() error {
+	if res.tx == nil {
+		return nil
+	}
+	return res.tx.Abort()
+}
+
+func (res *InstallResult) mark(step journal.Step) error {
+	if res.tx == nil {
+		return nil
+	}
+	return res.tx.Mark(step)
 }
 
 func downloadToFromURL(ctx context.Context, destPath, url string, hooks *progress.Hooks) error {
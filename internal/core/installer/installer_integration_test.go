@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"parm/internal/config"
@@ -91,6 +92,124 @@ func TestInstall_FullWorkflow_Release(t *testing.T) {
 	}
 }
 
+func TestInstall_KeepsOtherVersions(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	archivePath := createTestTarGzWithBinary(t, tmpDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, archivePath)
+	}))
+	defer server.Close()
+
+	assetName := fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	release := func(tag string) *github.RepositoryRelease {
+		return &github.RepositoryRelease{
+			TagName: github.Ptr(tag),
+			Assets: []*github.ReleaseAsset{
+				{Name: github.Ptr(assetName)},
+			},
+		}
+	}
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetReposReleasesTagsByOwnerByRepoByTag,
+			release("v1.0.0"),
+			release("v2.0.0"),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, server.URL+"/asset", http.StatusFound)
+			}),
+		),
+	)
+
+	installer := New(github.NewClient(mockedHTTPClient).Repositories)
+	pkgPath := filepath.Join(tmpDir, "owner", "repo")
+
+	for _, tag := range []string{"v1.0.0", "v2.0.0"} {
+		opts := InstallFlags{
+			Type:    manifest.Release,
+			Version: github.Ptr(tag),
+		}
+		res, err := installer.Install(context.Background(), "owner", "repo", pkgPath, opts, nil)
+		if err != nil {
+			t.Fatalf("Install(%s) error: %v", tag, err)
+		}
+		if want := filepath.Join(pkgPath, tag); res.InstallPath != want {
+			t.Errorf("InstallPath = %v, want %v", res.InstallPath, want)
+		}
+	}
+
+	for _, tag := range []string{"v1.0.0", "v2.0.0"} {
+		if _, err := os.Stat(filepath.Join(pkgPath, tag)); err != nil {
+			t.Errorf("version %s is missing after installing both: %v", tag, err)
+		}
+	}
+}
+
+func TestInstall_AbortRestoresReplacedInstall(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	archivePath := createTestTarGzWithBinary(t, tmpDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, archivePath)
+	}))
+	defer server.Close()
+
+	assetName := fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	release := &github.RepositoryRelease{
+		TagName: github.Ptr("v1.0.0"),
+		Assets:  []*github.ReleaseAsset{{Name: github.Ptr(assetName)}},
+	}
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetReposReleasesTagsByOwnerByRepoByTag,
+			release,
+			release,
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, server.URL+"/asset", http.StatusFound)
+			}),
+		),
+	)
+
+	installer := New(github.NewClient(mockedHTTPClient).Repositories)
+	pkgPath := filepath.Join(tmpDir, "owner", "repo")
+	opts := InstallFlags{
+		Type:    manifest.Release,
+		Version: github.Ptr("v1.0.0"),
+	}
+
+	first, err := installer.Install(context.Background(), "owner", "repo", pkgPath, opts, nil)
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	marker := filepath.Join(first.InstallPath, "marker")
+	os.WriteFile(marker, []byte("first"), 0644)
+
+	second, err := installer.Install(context.Background(), "owner", "repo", pkgPath, opts, nil)
+	if err != nil {
+		t.Fatalf("reinstall error: %v", err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("reinstall should replace the version dir")
+	}
+
+	if err := second.Abort(); err != nil {
+		t.Fatalf("Abort() error: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("aborting a reinstall should restore the replaced install: %v", err)
+	}
+}
+
 func TestInstall_PreRelease(t *testing.T) {
 	tmpDir := t.TempDir()
 	config.Cfg.ParmPkgPath = tmpDir
@@ -282,7 +401,7 @@ func TestInstallFromRelease_TarGz(t *testing.T) {
 	}))
 	defer server.Close()
 
-	assetName := "test.tar.gz"
+	assetName := fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
 	release := &github.RepositoryRelease{
 		TagName: github.Ptr("v1.0.0"),
 		Assets: []*github.ReleaseAsset{
@@ -328,6 +447,101 @@ func TestInstallFromRelease_TarGz(t *testing.T) {
 	}
 }
 
+func TestInstallFromRelease_AssetPattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	archivePath := createTestTarGzWithBinary(t, tmpDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, archivePath)
+	}))
+	defer server.Close()
+
+	release := &github.RepositoryRelease{
+		TagName: github.Ptr("v2.0.0"),
+		Assets: []*github.ReleaseAsset{
+			{
+				ID:   github.Ptr(int64(1)),
+				Name: github.Ptr(fmt.Sprintf("tool-2.0.0-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)),
+			},
+			{
+				ID:                 github.Ptr(int64(2)),
+				Name:               github.Ptr("tool-2.0.0.tar.gz"),
+				BrowserDownloadURL: github.Ptr(server.URL + "/asset"),
+				Size:               github.Ptr(10),
+			},
+		},
+	}
+
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, server.URL+"/asset", http.StatusFound)
+			}),
+		),
+	)
+
+	client := github.NewClient(mockedHTTPClient)
+	installer := New(client.Repositories)
+	pkgPath := filepath.Join(tmpDir, "owner", "repo")
+
+	// recorded from a previous install of "tool-1.0.0.tar.gz" on v1.0.0
+	pattern := "tool-{version}.tar.gz"
+	opts := InstallFlags{
+		Type:         manifest.Release,
+		AssetPattern: &pattern,
+	}
+
+	result, err := installer.installFromRelease(context.Background(), pkgPath, "owner", "repo", release, opts, nil)
+	if err != nil {
+		t.Fatalf("installFromRelease() error: %v", err)
+	}
+
+	if result.Asset == nil {
+		t.Fatal("installFromRelease() did not record the asset")
+	}
+	if result.Asset.Name != "tool-2.0.0.tar.gz" {
+		t.Errorf("Asset.Name = %v, want tool-2.0.0.tar.gz", result.Asset.Name)
+	}
+	if result.Asset.ID != 2 {
+		t.Errorf("Asset.ID = %v, want 2", result.Asset.ID)
+	}
+	if result.Asset.Pattern != pattern {
+		t.Errorf("Asset.Pattern = %v, want %v", result.Asset.Pattern, pattern)
+	}
+	if !strings.HasPrefix(result.Asset.Digest, "sha256:") {
+		t.Errorf("Asset.Digest = %v, want sha256 digest", result.Asset.Digest)
+	}
+}
+
+func TestInstallFromRelease_AssetPatternNoMatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	release := &github.RepositoryRelease{
+		TagName: github.Ptr("v2.0.0"),
+		Assets: []*github.ReleaseAsset{
+			{Name: github.Ptr("tool-2.0.0-renamed.tar.gz")},
+		},
+	}
+
+	client := github.NewClient(nil)
+	installer := New(client.Repositories)
+
+	pattern := "tool-{version}.tar.gz"
+	opts := InstallFlags{
+		Type:         manifest.Release,
+		AssetPattern: &pattern,
+	}
+
+	_, err := installer.installFromRelease(context.Background(), filepath.Join(tmpDir, "owner", "repo"), "owner", "repo", release, opts, nil)
+	if err == nil {
+		t.Fatal("installFromRelease() should fail instead of picking a different asset")
+	}
+}
+
 func TestInstallFromRelease_Zip(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -338,7 +552,7 @@ func TestInstallFromRelease_Zip(t *testing.T) {
 	}))
 	defer server.Close()
 
-	assetName := "test.zip"
+	assetName := fmt.Sprintf("test-%s-%s.zip", runtime.GOOS, runtime.GOARCH)
 	release := &github.RepositoryRelease{
 		TagName: github.Ptr("v1.0.0"),
 		Assets: []*github.ReleaseAsset{
@@ -0,0 +1,141 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"parm/internal/core/linker"
+	"parm/internal/core/verify"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"path/filepath"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// What an install would do, worked out without downloading anything.
+type Plan struct {
+	Owner   string
+	Repo    string
+	Version string
+	Type    manifest.InstallType
+	Asset   *github.ReleaseAsset
+	// the version dir the asset would be unpacked into
+	InstallPath string
+	// whether InstallPath already holds an install that would be replaced
+	Replaces    bool
+	VerifyLevel verify.Level
+	// the first checksum file the asset would be looked up in if GitHub publishes no digest for it, if any
+	ChecksumFile string
+	// the signature files of the asset and its checksum files, only looked up at LevelSignature
+	Signatures []string
+	Links      []PlannedLink
+	// set when the package isn't installed yet, and Links were guessed from the repo name.
+	// Otherwise they're taken from the active version, since an asset's contents aren't known until it's unpacked.
+	LinksGuessed bool
+}
+
+type LinkAction string
+
+const (
+	LinkCreate    LinkAction = "create"
+	LinkOverwrite LinkAction = "overwrite"
+	LinkUnchanged LinkAction = "unchanged"
+	// the link belongs to another package, the install would fail without --rename or --take-bin
+	LinkConflict LinkAction = "conflict"
+)
+
+// A link in parm_bin_path that an install would create or overwrite.
+type PlannedLink struct {
+	Path   string
+	Target string
+	Action LinkAction
+	// what Path points to now, empty if it doesn't exist or isn't a symlink
+	Current string
+	// the package owning Path, if it's another package
+	Owner string
+}
+
+// Resolves the release and asset an install would use, without downloading or changing anything.
+func (in *Installer) Plan(ctx context.Context, owner, repo string, pkgPath string, opts InstallFlags) (*Plan, error) {
+	rel, err := in.resolveRelease(ctx, owner, repo, &opts)
+	if err != nil {
+		return nil, err
+	}
+	ass, err := chooseAsset(owner, repo, rel, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	versionDir := filepath.Join(pkgPath, parmutil.VersionDirName(rel.GetTagName()))
+	plan := &Plan{
+		Owner:       owner,
+		Repo:        repo,
+		Version:     rel.GetTagName(),
+		Type:        opts.Type,
+		Asset:       ass,
+		InstallPath: versionDir,
+		VerifyLevel: opts.VerifyLevel,
+	}
+	if srcs := checksumSources(rel, ass); len(srcs) > 0 {
+		plan.ChecksumFile = srcs[0].asset.GetName()
+	}
+	if opts.VerifyLevel == verify.LevelSignature {
+		for _, f := range signatureFiles(rel, ass) {
+			plan.Signatures = append(plan.Signatures, f.asset.GetName())
+		}
+		for _, src := range checksumSources(rel, ass) {
+			for _, f := range signatureFiles(rel, src.asset) {
+				plan.Signatures = append(plan.Signatures, f.asset.GetName())
+			}
+		}
+	}
+	if _, err := manifest.Read(versionDir); err == nil {
+		plan.Replaces = true
+	}
+
+	// the version as it would be linked, its executables aren't known until the asset is unpacked
+	next := &manifest.Manifest{
+		Owner:       owner,
+		Repo:        repo,
+		Version:     rel.GetTagName(),
+		Executables: []string{repo},
+		Renames:     opts.Renames,
+	}
+	if man, err := manifest.Read(parmutil.GetInstallDir(owner, repo)); err == nil && len(man.Executables) > 0 {
+		next.Executables, next.Asset, next.Bins = man.Executables, man.Asset, opts.Bins
+		// selected up front, the new version may well ship bins the active one doesn't
+		next.Executables, next.Bins = next.SelectExecutables(), nil
+	} else {
+		plan.LinksGuessed = true
+		if len(opts.Bins) > 0 {
+			next.Executables = opts.Bins
+		}
+	}
+	links, err := linker.PlanLink(next, opts.TakeBins...)
+	var conflict *linker.ConflictError
+	if err != nil && !errors.As(err, &conflict) {
+		return nil, err
+	}
+	for _, ch := range links.Changes {
+		action, ok := linkActions[ch.Action]
+		if !ok {
+			// links the active version has and this one wouldn't are left out
+			continue
+		}
+		plan.Links = append(plan.Links, PlannedLink{
+			Path:    ch.Path,
+			Target:  ch.Target,
+			Action:  action,
+			Current: ch.Current,
+			Owner:   ch.Owner,
+		})
+	}
+	return plan, nil
+}
+
+var linkActions = map[linker.Action]LinkAction{
+	linker.Create:   LinkCreate,
+	linker.Retarget: LinkOverwrite,
+	linker.Keep:     LinkUnchanged,
+	linker.Blocked:  LinkConflict,
+}
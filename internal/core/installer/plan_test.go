@@ -0,0 +1,82 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"parm/internal/config"
+	"parm/internal/core/verify"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+)
+
+func TestPlan(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+	config.Cfg.ParmBinPath = filepath.Join(tmpDir, "bin")
+
+	assetName := fmt.Sprintf("tool-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	rel := &github.RepositoryRelease{
+		TagName: github.Ptr("v2.0.0"),
+		Assets: []*github.ReleaseAsset{
+			{Name: github.Ptr(assetName), Size: github.Ptr(1 << 20), Digest: github.Ptr("sha256:abc")},
+			{Name: github.Ptr("checksums.txt")},
+		},
+	}
+	// no handler for downloads, planning must not download anything
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(mock.GetReposReleasesLatestByOwnerByRepo, rel, rel),
+	)
+	inst := New(github.NewClient(mockedHTTPClient).Repositories)
+	pkgPath := parmutil.GetPkgDir("owner", "tool")
+	opts := InstallFlags{Type: manifest.Release, VerifyLevel: verify.LevelChecksum}
+
+	plan, err := inst.Plan(context.Background(), "owner", "tool", pkgPath, opts)
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+	if plan.Version != "v2.0.0" || plan.Asset.GetName() != assetName || plan.ChecksumFile != "checksums.txt" {
+		t.Errorf("Plan() = %s %s (checksums in %q), want v2.0.0 %s (checksums in checksums.txt)", plan.Version, plan.Asset.GetName(), plan.ChecksumFile, assetName)
+	}
+	wantDir := filepath.Join(pkgPath, "v2.0.0")
+	if plan.InstallPath != wantDir || plan.Replaces {
+		t.Errorf("InstallPath = %s (replaces=%v), want %s", plan.InstallPath, plan.Replaces, wantDir)
+	}
+	if !plan.LinksGuessed || len(plan.Links) != 1 || plan.Links[0].Action != LinkCreate {
+		t.Fatalf("Links = %+v, want a single guessed link to create", plan.Links)
+	}
+	if _, err := os.Stat(pkgPath); !os.IsNotExist(err) {
+		t.Errorf("Plan() created %s", pkgPath)
+	}
+
+	// with an active version, its executables are planned and their links overwritten
+	oldDir := filepath.Join(pkgPath, "v1.0.0")
+	os.MkdirAll(oldDir, 0o755)
+	old := &manifest.Manifest{Owner: "owner", Repo: "tool", Version: "v1.0.0", Executables: []string{"bin/tool", "bin/toolctl"}}
+	if err := old.Write(oldDir); err != nil {
+		t.Fatal(err)
+	}
+	parmutil.SetCurrentVersion("owner", "tool", "v1.0.0")
+	os.MkdirAll(config.Cfg.ParmBinPath, 0o755)
+	os.Symlink(filepath.Join(oldDir, "bin", "tool"), filepath.Join(config.Cfg.ParmBinPath, "tool"))
+
+	plan, err = inst.Plan(context.Background(), "owner", "tool", pkgPath, opts)
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+	if plan.LinksGuessed || len(plan.Links) != 2 {
+		t.Fatalf("Links = %+v, want the executables of the active version", plan.Links)
+	}
+	if l := plan.Links[0]; l.Action != LinkOverwrite || l.Current != filepath.Join(oldDir, "bin", "tool") || l.Target != filepath.Join(wantDir, "bin", "tool") {
+		t.Errorf("Links[0] = %+v, want an overwrite of the link into v1.0.0", l)
+	}
+	if l := plan.Links[1]; l.Action != LinkCreate {
+		t.Errorf("Links[1] = %+v, want create", l)
+	}
+}
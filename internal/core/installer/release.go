@@ -3,52 +3,50 @@ package installer
 import (
 	"context"
 	"fmt"
-	"math"
 	"net/http"
 	"os"
+	"parm/internal/config"
+	"parm/internal/core/journal"
+	"parm/internal/core/uninstaller"
 	"parm/internal/core/verify"
+	"parm/internal/manifest"
 	"parm/internal/parmutil"
 	"parm/pkg/archive"
 	"parm/pkg/progress"
 	"path/filepath"
-	"runtime"
-	"slices"
 	"strings"
 
 	"github.com/google/go-github/v74/github"
 )
 
-// Does NOT validate the release.
-func (in *Installer) installFromRelease(ctx context.Context, pkgPath, owner, repo string, rel *github.RepositoryRelease, opts InstallFlags, hooks *progress.Hooks) (*InstallResult, error) {
-	var ass *github.ReleaseAsset
-	var err error
-	if opts.Asset == nil {
-		matches, err := selectReleaseAsset(rel.Assets, runtime.GOOS, runtime.GOARCH)
-		if err != nil {
-			return nil, err
-		}
-		if len(matches) == 0 {
-			// TODO: allow users to choose match
-			return nil, fmt.Errorf("err: no compatible binary found for release %s", rel.GetTagName())
-		}
-		// if len(matches) > 1 {
-		// 	// TODO: allow users to choose what asset they want installed instead
-		// 	return nil
-		// }
-		ass = matches[0]
-	} else {
-		ass, err = getAssetByName(rel, *opts.Asset)
-		if err != nil {
-			return nil, err
-		}
+// Does NOT validate the release. The release is installed into its own version dir under pkgPath.
+func (in *Installer) installFromRelease(ctx context.Context, pkgPath, owner, repo string, rel *github.RepositoryRelease, opts InstallFlags, hooks *progress.Hooks) (_ *InstallResult, err error) {
+	ass, err := chooseAsset(owner, repo, rel, opts)
+	if err != nil {
+		return nil, err
 	}
 
+	// begun before staging, so an interrupted install never leaves behind anything the journal doesn't know about
+	tx, err := journal.Begin(owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start install: \n%w", err)
+	}
+	tx.Version = rel.GetTagName()
+
 	tmpDir, err := parmutil.MakeStagingDir(owner, repo)
 	if err != nil {
+		_ = tx.Abort()
 		return nil, err
 	}
 	// TODO: Cleanup() instead
 	defer os.RemoveAll(tmpDir)
+	tx.StagingDir = tmpDir
+	// registered after the staging cleanup so it runs first, while the staging dir is still there
+	defer func() {
+		if err != nil {
+			_ = tx.Abort()
+		}
+	}()
 
 	archivePath := filepath.Join(tmpDir, ass.GetName()) // download destination
 	rc, redirURL, err := in.client.DownloadReleaseAsset(ctx, owner, repo, ass.GetID(), http.DefaultClient)
@@ -65,50 +63,67 @@ func (in *Installer) installFromRelease(ctx context.Context, pkgPath, owner, rep
 	if err != nil {
 		return nil, fmt.Errorf("failed to download asset: \n%w", err)
 	}
+	if err := tx.Mark(journal.StepStage); err != nil {
+		return nil, err
+	}
 
-	// TODO: change based on actual verify-level
-	if opts.VerifyLevel > 0 {
-		if ass.Digest == nil {
-			return nil, fmt.Errorf("no upstream digest available for %q; re-run with --no-verify", ass.GetName())
-		}
-		ok, gen, err := verify.VerifyLevel1(archivePath, *ass.Digest)
-		if err != nil {
-			return nil, fmt.Errorf("could not verify checksum:\n%q", err)
-		}
-		if !ok {
-			return nil, fmt.Errorf("fatal: checksum invalid:\n\thad %s\n\twanted %s", *gen, *ass.Digest)
-		}
+	signer, err := in.verifyAsset(ctx, owner, repo, rel, ass, archivePath, opts.VerifyLevel)
+	if err != nil {
+		return nil, err
 	}
 
-	switch {
-	case strings.HasSuffix(archivePath, ".tar.gz"), strings.HasSuffix(archivePath, ".tgz"):
-		if err := archive.ExtractTarGz(archivePath, tmpDir); err != nil {
-			return nil, fmt.Errorf("failed to extract tarball: \n%w", err)
-		}
-	case strings.HasSuffix(archivePath, ".zip"):
-		if err := archive.ExtractZip(archivePath, tmpDir); err != nil {
-			return nil, fmt.Errorf("failed to extract zip: \n%w", err)
-		}
-	default:
-		if runtime.GOOS != "windows" {
-			if err := os.Chmod(archivePath, 0o755); err != nil {
-				return nil, fmt.Errorf("failed to make binary executable: \n%w", err)
+	sum, err := verify.GetSha256(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash asset: \n%w", err)
+	}
+	assetInfo := &manifest.Asset{
+		Name:    ass.GetName(),
+		ID:      ass.GetID(),
+		URL:     ass.GetBrowserDownloadURL(),
+		Size:    ass.GetSize(),
+		Digest:  "sha256:" + sum,
+		Pattern: assetNamePattern(ass.GetName(), rel.GetTagName()),
+	}
+
+	if err := archive.Extract(archivePath, tmpDir); err != nil {
+		return nil, fmt.Errorf("cannot install asset %q: \n%w", ass.GetName(), err)
+	}
+	// a bare "jq-linux-amd64" is installed as "jq"
+	if exec, ok := bareExecutable(tmpDir, ass.GetName()); ok {
+		name := commandName(exec, repo, rel.GetTagName())
+		if _, err := os.Lstat(filepath.Join(tmpDir, name)); name != exec && os.IsNotExist(err) {
+			if err := os.Rename(filepath.Join(tmpDir, exec), filepath.Join(tmpDir, name)); err != nil {
+				return nil, fmt.Errorf("cannot install asset %q: \n%w", ass.GetName(), err)
 			}
+			assetInfo.Executable = exec
 		}
 	}
 
+	if err := tx.Mark(journal.StepVerify); err != nil {
+		return nil, err
+	}
+
 	// TODO: create manifest elsewhere for better separation of concerns?
 	// TODO: Return an InstallResult and let the CLI call a manifest writer service.
 	// will also help with symlinking
 
-	finalDir, err := parmutil.PromoteStagingDir(pkgPath, tmpDir)
-	if err != nil {
+	// reinstalling an already installed version replaces it, the old install is only removed once the new one is committed
+	versionDir := filepath.Join(pkgPath, parmutil.VersionDirName(rel.GetTagName()))
+	if err := uninstaller.EnsureNotRunning(versionDir); err != nil {
+		return nil, err
+	}
+	if err := tx.Promote(tmpDir, versionDir); err != nil {
 		return nil, err
 	}
 
 	return &InstallResult{
-		InstallPath: finalDir,
+		InstallPath: versionDir,
 		Version:     rel.GetTagName(),
+		Asset:       assetInfo,
+		Signer:      signer,
+		VerifyLevel: opts.VerifyLevel,
+		tx:          tx,
+		takeBins:    opts.TakeBins,
 	}, nil
 }
 
@@ -122,104 +137,57 @@ func getAssetByName(rel *github.RepositoryRelease, name string) (*github.Release
 	return nil, fmt.Errorf("no asset by the name of %s was found in release %s", name, rel)
 }
 
-// infers the proper release asset based on the name of the asset
-func selectReleaseAsset(assets []*github.ReleaseAsset, goos, goarch string) ([]*github.ReleaseAsset, error) {
-	type match struct {
-		asset *github.ReleaseAsset
-		score int
-	}
-
-	gooses := map[string][]string{
-		"windows": {"windows", "win64", "win32", "win"},
-		"darwin":  {"macos", "darwin", "mac", "osx"},
-		"linux":   {"linux"},
-	}
-	goarchs := map[string][]string{
-		"amd64": {"amd64", "x86_64", "x64", "64bit", "64-bit"},
-		"386":   {"386", "x86", "i386", "32bit", "32-bit"},
-		"arm64": {"arm64", "aarch64"},
-		"arm":   {"armv7", "armv6", "armhf", "armv7l"},
-	}
-
-	extPref := []string{".tar.gz", ".tgz", ".tar.xz", ".zip", ".bin", ".appimage"}
-	if goos == "windows" {
-		extPref = []string{".zip", ".exe", ".msi", ".bin"}
-	}
+const (
+	tagPlaceholder     = "{tag}"
+	versionPlaceholder = "{version}"
+)
 
-	// other score modifiers
-	scoreMods := map[string]int{
-		"musl": -1,
-	}
-	if goos == "windows" {
-		scoreMods = map[string]int{}
+// replaces the release tag in an asset name with placeholders, so the same asset can be found on another release.
+// e.g. "tmux-3.5a.tar.gz" on tag "3.5a" becomes "tmux-{version}.tar.gz"
+func assetNamePattern(name, tag string) string {
+	// very short tags like "1" would also match unrelated parts of the name
+	if len(tag) < 3 {
+		return name
 	}
-
-	// scoring
-	scoredMatches := make([]match, len(assets))
-	for i, a := range assets {
-		scoredMatches[i] = match{asset: a, score: 0}
+	pattern := strings.ReplaceAll(name, tag, tagPlaceholder)
+	if ver := strings.TrimPrefix(tag, "v"); ver != tag && len(ver) >= 3 {
+		pattern = strings.ReplaceAll(pattern, ver, versionPlaceholder)
 	}
+	return pattern
+}
 
-	const goosMatch = 11
-	const goarchMatch = 7
-	const prefMatch = 3 // actually a multiplier for preference match
-	// INFO: to be used later when adding interactive install.
-	// const minScoreMatch = goosMatch + goarchMatch
-
-	for i := range scoredMatches {
-		a := &scoredMatches[i]
-		name := strings.ToLower(a.asset.GetName())
-		if containsAny(name, gooses[goos]) {
-			a.score += goosMatch
-		}
-		if containsAny(name, goarchs[goarch]) {
-			a.score += goarchMatch
-		}
-
-		for j, ext := range extPref {
-			var mult = float64(prefMatch) * float64((len(extPref) - j))
-			var multRounded = int(math.Round(mult))
-			if strings.HasSuffix(name, ext) {
-				a.score += multRounded
-			}
-		}
+func expandAssetPattern(pattern, tag string) string {
+	name := strings.ReplaceAll(pattern, tagPlaceholder, tag)
+	return strings.ReplaceAll(name, versionPlaceholder, strings.TrimPrefix(tag, "v"))
+}
 
-		for j, m := range scoreMods {
-			if strings.Contains(name, j) {
-				a.score += m
-			}
-		}
+// infers the proper release asset based on the name of the asset, returning the top candidate(s)
+func selectReleaseAsset(assets []*github.ReleaseAsset, plat Platform) ([]*github.ReleaseAsset, error) {
+	scored := scoreReleaseAssets(assets, plat)
+	if len(scored) == 0 {
+		return nil, nil
 	}
 
-	// sort
-	slices.SortStableFunc(scoredMatches, func(a, b match) int {
-		if a.score < b.score {
-			return 1
-		}
-		if a.score > b.score {
-			return -1
-		}
-		return 0
-	})
-
-	minMatch := scoredMatches[0].score
-	// if minMatch < minScoreMatch {
-	// 	fmt.Println("warning: selected release asset may not be completely accurate")
-	// }
-
 	// find top candidate(s)
 	var candidates []*github.ReleaseAsset
-	for _, m := range scoredMatches {
-		if m.score == minMatch {
-			candidates = append(candidates, m.asset)
-			continue
+	for _, m := range scored {
+		if m.Score != scored[0].Score {
+			break
 		}
-		break
+		candidates = append(candidates, m.Asset)
 	}
 
 	return candidates, nil
 }
 
+func lowerAll(strs []string) []string {
+	lower := make([]string, len(strs))
+	for i, s := range strs {
+		lower[i] = strings.ToLower(s)
+	}
+	return lower
+}
+
 func containsAny(src string, tokens []string) bool {
 	for _, a := range tokens {
 		if strings.Contains(src, a) {
@@ -228,3 +196,23 @@ func containsAny(src string, tokens []string) bool {
 	}
 	return false
 }
+
+// picks the asset of a release to install: the one passed with --asset, the one matching the asset
+// recorded by a previous install, or the best scoring one
+func chooseAsset(owner, repo string, rel *github.RepositoryRelease, opts InstallFlags) (*github.ReleaseAsset, error) {
+	if opts.Asset != nil {
+		return getAssetByName(rel, *opts.Asset)
+	}
+
+	// asset rules from the config take precedence over the asset recorded by a previous install
+	rules := config.Cfg.RulesFor(owner, repo)
+	if opts.AssetPattern != nil && !rules.HasAssetMatcher() {
+		name := expandAssetPattern(*opts.AssetPattern, rel.GetTagName())
+		ass, err := getAssetByName(rel, name)
+		if err != nil {
+			return nil, fmt.Errorf("previously installed asset %q has no match in release %s, reinstall with --asset: \n%w", *opts.AssetPattern, rel.GetTagName(), err)
+		}
+		return ass, nil
+	}
+	return pickReleaseAsset(rel, HostPlatform(), rules, opts.Picker)
+}
@@ -1,6 +1,8 @@
 package installer
 
 import (
+	"errors"
+	"parm/internal/config"
 	"runtime"
 	"testing"
 
@@ -18,7 +20,7 @@ func TestSelectReleaseAsset_LinuxAmd64(t *testing.T) {
 		{Name: github.Ptr("app-windows-amd64.zip")},
 	}
 
-	matches, err := selectReleaseAsset(assets, "linux", "amd64")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -40,7 +42,7 @@ func TestSelectReleaseAsset_DarwinArm64(t *testing.T) {
 		{Name: github.Ptr("app-darwin-amd64.tar.gz")},
 	}
 
-	matches, err := selectReleaseAsset(assets, "darwin", "arm64")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "darwin", Arch: "arm64"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -62,7 +64,7 @@ func TestSelectReleaseAsset_WindowsAmd64(t *testing.T) {
 		{Name: github.Ptr("app-darwin-amd64.tar.gz")},
 	}
 
-	matches, err := selectReleaseAsset(assets, "windows", "amd64")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "windows", Arch: "amd64"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -83,7 +85,7 @@ func TestSelectReleaseAsset_PreferTarGz(t *testing.T) {
 		{Name: github.Ptr("app-linux-amd64.tar.gz")},
 	}
 
-	matches, err := selectReleaseAsset(assets, "linux", "amd64")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -105,7 +107,7 @@ func TestSelectReleaseAsset_NoMatch(t *testing.T) {
 	}
 
 	// Request Windows asset when only Linux/Darwin available
-	matches, err := selectReleaseAsset(assets, "windows", "amd64")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "windows", Arch: "amd64"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -123,7 +125,7 @@ func TestSelectReleaseAsset_AlternativeNames(t *testing.T) {
 	}
 
 	// Test alternative OS/arch names
-	matches, err := selectReleaseAsset(assets, "darwin", "amd64")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "darwin", Arch: "amd64"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -144,7 +146,7 @@ func TestSelectReleaseAsset_MuslPenalty(t *testing.T) {
 		{Name: github.Ptr("app-linux-amd64.tar.gz")},
 	}
 
-	matches, err := selectReleaseAsset(assets, "linux", "amd64")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -226,7 +228,7 @@ func TestSelectReleaseAsset_Arm32(t *testing.T) {
 		{Name: github.Ptr("app-linux-amd64.tar.gz")},
 	}
 
-	matches, err := selectReleaseAsset(assets, "linux", "arm")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "linux", Arch: "arm", Libc: "gnu"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -247,7 +249,7 @@ func TestSelectReleaseAsset_MultipleMatches(t *testing.T) {
 		{Name: github.Ptr("app-linux-x86_64.tar.gz")},
 	}
 
-	matches, err := selectReleaseAsset(assets, "linux", "amd64")
+	matches, err := selectReleaseAsset(assets, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"})
 	if err != nil {
 		t.Fatalf("selectReleaseAsset() error: %v", err)
 	}
@@ -259,3 +261,278 @@ func TestSelectReleaseAsset_MultipleMatches(t *testing.T) {
 	// Both should match, function returns top candidates
 	t.Logf("Found %d matches", len(matches))
 }
+
+func TestAssetNamePattern(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    string
+		newTag string
+		want   string
+		expand string
+	}{
+		{"tmux-3.5a.tar.gz", "3.5a", "3.6", "tmux-{tag}.tar.gz", "tmux-3.6.tar.gz"},
+		{"tool_1.2.3_linux_amd64.tar.gz", "v1.2.3", "v1.3.0", "tool_{version}_linux_amd64.tar.gz", "tool_1.3.0_linux_amd64.tar.gz"},
+		{"tool-v1.2.3-linux.zip", "v1.2.3", "v2.0.0", "tool-{tag}-linux.zip", "tool-v2.0.0-linux.zip"},
+		{"tool-linux-amd64", "v1.2.3", "v2.0.0", "tool-linux-amd64", "tool-linux-amd64"},
+		{"tool-1-linux-x86_64", "1", "2", "tool-1-linux-x86_64", "tool-1-linux-x86_64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := assetNamePattern(tt.name, tt.tag)
+			if got != tt.want {
+				t.Errorf("assetNamePattern() = %v, want %v", got, tt.want)
+			}
+			if exp := expandAssetPattern(got, tt.newTag); exp != tt.expand {
+				t.Errorf("expandAssetPattern() = %v, want %v", exp, tt.expand)
+			}
+		})
+	}
+}
+
+// asset lists of real releases, trimmed to the interesting parts
+var (
+	ripgrepAssets = []string{
+		"ripgrep-14.1.0-aarch64-apple-darwin.tar.gz",
+		"ripgrep-14.1.0-aarch64-apple-darwin.tar.gz.sha256",
+		"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz",
+		"ripgrep-14.1.0-arm-unknown-linux-gnueabihf.tar.gz",
+		"ripgrep-14.1.0-armv7-unknown-linux-gnueabihf.tar.gz",
+		"ripgrep-14.1.0-armv7-unknown-linux-musleabi.tar.gz",
+		"ripgrep-14.1.0-armv7-unknown-linux-musleabihf.tar.gz",
+		"ripgrep-14.1.0-i686-pc-windows-msvc.zip",
+		"ripgrep-14.1.0-i686-unknown-linux-gnu.tar.gz",
+		"ripgrep-14.1.0-powerpc64-unknown-linux-gnu.tar.gz",
+		"ripgrep-14.1.0-s390x-unknown-linux-gnu.tar.gz",
+		"ripgrep-14.1.0-x86_64-apple-darwin.tar.gz",
+		"ripgrep-14.1.0-x86_64-pc-windows-gnu.zip",
+		"ripgrep-14.1.0-x86_64-pc-windows-msvc.zip",
+		"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz",
+		"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz.sha256",
+		"ripgrep_14.1.0-1_amd64.deb",
+	}
+	fdAssets = []string{
+		"fd-v10.1.0-aarch64-unknown-linux-gnu.tar.gz",
+		"fd-v10.1.0-aarch64-unknown-linux-musl.tar.gz",
+		"fd-v10.1.0-arm-unknown-linux-gnueabihf.tar.gz",
+		"fd-v10.1.0-arm-unknown-linux-musleabihf.tar.gz",
+		"fd-v10.1.0-i686-pc-windows-msvc.zip",
+		"fd-v10.1.0-i686-unknown-linux-gnu.tar.gz",
+		"fd-v10.1.0-i686-unknown-linux-musl.tar.gz",
+		"fd-v10.1.0-x86_64-apple-darwin.tar.gz",
+		"fd-v10.1.0-x86_64-pc-windows-gnu.zip",
+		"fd-v10.1.0-x86_64-pc-windows-msvc.zip",
+		"fd-v10.1.0-x86_64-unknown-linux-gnu.tar.gz",
+		"fd-v10.1.0-x86_64-unknown-linux-musl.tar.gz",
+		"fd-musl_10.1.0_amd64.deb",
+		"fd_10.1.0_amd64.deb",
+		"fd_10.1.0_arm64.deb",
+		"fd_10.1.0_armhf.deb",
+	}
+	batAssets = []string{
+		"bat-v0.24.0-aarch64-unknown-linux-gnu.tar.gz",
+		"bat-v0.24.0-arm-unknown-linux-musleabihf.tar.gz",
+		"bat-v0.24.0-x86_64-apple-darwin.tar.gz",
+		"bat-v0.24.0-x86_64-unknown-linux-gnu.tar.gz",
+		"bat-v0.24.0-x86_64-unknown-linux-musl.tar.gz",
+		"bat-musl_0.24.0_amd64.deb",
+		"bat_0.24.0_amd64.deb",
+	}
+	ghAssets = []string{
+		"gh_2.60.0_checksums.txt",
+		"gh_2.60.0_linux_386.deb",
+		"gh_2.60.0_linux_386.rpm",
+		"gh_2.60.0_linux_386.tar.gz",
+		"gh_2.60.0_linux_amd64.deb",
+		"gh_2.60.0_linux_amd64.rpm",
+		"gh_2.60.0_linux_amd64.tar.gz",
+		"gh_2.60.0_linux_arm64.tar.gz",
+		"gh_2.60.0_linux_armv6.deb",
+		"gh_2.60.0_linux_armv6.tar.gz",
+		"gh_2.60.0_macOS_amd64.zip",
+		"gh_2.60.0_macOS_arm64.zip",
+		"gh_2.60.0_macOS_universal.pkg",
+		"gh_2.60.0_windows_386.msi",
+		"gh_2.60.0_windows_386.zip",
+		"gh_2.60.0_windows_amd64.msi",
+		"gh_2.60.0_windows_amd64.zip",
+		"gh_2.60.0_windows_arm64.zip",
+	}
+	lazygitAssets = []string{
+		"checksums.txt",
+		"lazygit_0.44.1_Darwin_arm64.tar.gz",
+		"lazygit_0.44.1_Darwin_x86_64.tar.gz",
+		"lazygit_0.44.1_Freebsd_32-bit.tar.gz",
+		"lazygit_0.44.1_Freebsd_x86_64.tar.gz",
+		"lazygit_0.44.1_Linux_32-bit.tar.gz",
+		"lazygit_0.44.1_Linux_arm64.tar.gz",
+		"lazygit_0.44.1_Linux_armv6.tar.gz",
+		"lazygit_0.44.1_Linux_x86_64.tar.gz",
+		"lazygit_0.44.1_Windows_32-bit.zip",
+		"lazygit_0.44.1_Windows_x86_64.zip",
+	}
+	neovimAssets = []string{
+		"nvim-linux64.tar.gz",
+		"nvim-linux64.tar.gz.sha256sum",
+		"nvim-macos-arm64.tar.gz",
+		"nvim-macos-x86_64.tar.gz",
+		"nvim-win64.msi",
+		"nvim-win64.zip",
+		"nvim.appimage",
+		"nvim.appimage.sha256sum",
+		"nvim.appimage.zsync",
+	}
+	zellijAssets = []string{
+		"zellij-aarch64-apple-darwin.sha256sum",
+		"zellij-aarch64-apple-darwin.tar.gz",
+		"zellij-aarch64-unknown-linux-musl.tar.gz",
+		"zellij-x86_64-apple-darwin.tar.gz",
+		"zellij-x86_64-unknown-linux-musl.sha256sum",
+		"zellij-x86_64-unknown-linux-musl.tar.gz",
+	}
+	universalAssets = []string{
+		"tool_1.0.0_checksums.txt",
+		"tool_1.0.0_checksums.txt.sig",
+		"tool_1.0.0_darwin_all.tar.gz",
+		"tool_1.0.0_linux_amd64.tar.gz",
+		"tool_1.0.0_linux_amd64.tar.gz.sbom.json",
+		"tool_1.0.0_linux_arm64.tar.gz",
+	}
+)
+
+func TestPickReleaseAsset_RealWorld(t *testing.T) {
+	var (
+		linuxGnu   = Platform{OS: "linux", Arch: "amd64", Libc: "gnu"}
+		linuxMusl  = Platform{OS: "linux", Arch: "amd64", Libc: "musl"}
+		linux386   = Platform{OS: "linux", Arch: "386", Libc: "gnu"}
+		armv6      = Platform{OS: "linux", Arch: "arm", Arm: 6, Libc: "gnu"}
+		armv7      = Platform{OS: "linux", Arch: "arm", Arm: 7, Libc: "gnu"}
+		macArm     = Platform{OS: "darwin", Arch: "arm64"}
+		macIntel   = Platform{OS: "darwin", Arch: "amd64"}
+		windows    = Platform{OS: "windows", Arch: "amd64"}
+		windowsArm = Platform{OS: "windows", Arch: "arm64"}
+		freebsd    = Platform{OS: "freebsd", Arch: "amd64"}
+	)
+
+	tests := []struct {
+		name   string
+		assets []string
+		plat   Platform
+		// empty if the user has to be asked
+		want string
+	}{
+		{"ripgrep musl build on glibc", ripgrepAssets, linuxGnu, "ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz"},
+		{"ripgrep on musl", ripgrepAssets, linuxMusl, "ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz"},
+		{"ripgrep armv7", ripgrepAssets, armv7, "ripgrep-14.1.0-armv7-unknown-linux-gnueabihf.tar.gz"},
+		{"ripgrep armv6 skips armv7", ripgrepAssets, armv6, "ripgrep-14.1.0-arm-unknown-linux-gnueabihf.tar.gz"},
+		{"ripgrep 386", ripgrepAssets, linux386, "ripgrep-14.1.0-i686-unknown-linux-gnu.tar.gz"},
+		{"ripgrep apple silicon", ripgrepAssets, macArm, "ripgrep-14.1.0-aarch64-apple-darwin.tar.gz"},
+		{"ripgrep msvc over mingw", ripgrepAssets, windows, "ripgrep-14.1.0-x86_64-pc-windows-msvc.zip"},
+		{"fd glibc", fdAssets, linuxGnu, "fd-v10.1.0-x86_64-unknown-linux-gnu.tar.gz"},
+		{"fd musl", fdAssets, linuxMusl, "fd-v10.1.0-x86_64-unknown-linux-musl.tar.gz"},
+		{"fd only intel on apple silicon", fdAssets, macArm, ""},
+		{"bat glibc", batAssets, linuxGnu, "bat-v0.24.0-x86_64-unknown-linux-gnu.tar.gz"},
+		{"bat arm without version", batAssets, armv7, "bat-v0.24.0-arm-unknown-linux-musleabihf.tar.gz"},
+		{"gh tarball over packages", ghAssets, linuxGnu, "gh_2.60.0_linux_amd64.tar.gz"},
+		{"gh macOS", ghAssets, macArm, "gh_2.60.0_macOS_arm64.zip"},
+		{"gh armv6 on armv7", ghAssets, armv7, "gh_2.60.0_linux_armv6.tar.gz"},
+		{"gh windows arm", ghAssets, windowsArm, "gh_2.60.0_windows_arm64.zip"},
+		{"lazygit 32-bit", lazygitAssets, linux386, "lazygit_0.44.1_Linux_32-bit.tar.gz"},
+		{"lazygit freebsd", lazygitAssets, freebsd, "lazygit_0.44.1_Freebsd_x86_64.tar.gz"},
+		{"lazygit windows", lazygitAssets, windows, "lazygit_0.44.1_Windows_x86_64.zip"},
+		{"neovim linux64", neovimAssets, linuxGnu, "nvim-linux64.tar.gz"},
+		{"neovim win64", neovimAssets, windows, "nvim-win64.zip"},
+		{"neovim macos", neovimAssets, macIntel, "nvim-macos-x86_64.tar.gz"},
+		{"zellij", zellijAssets, linuxGnu, "zellij-x86_64-unknown-linux-musl.tar.gz"},
+		{"zellij apple silicon", zellijAssets, macArm, "zellij-aarch64-apple-darwin.tar.gz"},
+		{"universal on apple silicon", universalAssets, macArm, "tool_1.0.0_darwin_all.tar.gz"},
+		{"universal on intel mac", universalAssets, macIntel, "tool_1.0.0_darwin_all.tar.gz"},
+		{"universal ignored on linux", universalAssets, linuxGnu, "tool_1.0.0_linux_amd64.tar.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel := releaseWithAssets(tt.assets...)
+			ass, err := pickReleaseAsset(rel, tt.plat, config.PackageRules{}, nil)
+			if tt.want == "" {
+				var ambErr *AmbiguousAssetError
+				if !errors.As(err, &ambErr) {
+					t.Fatalf("pickReleaseAsset() = %s, %v, want AmbiguousAssetError", ass.GetName(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pickReleaseAsset() error: %v", err)
+			}
+			if ass.GetName() != tt.want {
+				t.Errorf("pickReleaseAsset() = %s, want %s", ass.GetName(), tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreReleaseAssets_Excluded(t *testing.T) {
+	assets := []*github.ReleaseAsset{
+		{Name: github.Ptr("tool-linux-amd64.tar.gz")},
+		{Name: github.Ptr("tool-linux-amd64.tar.gz.sig")},
+		{Name: github.Ptr("tool-linux-amd64.tar.gz.sha256")},
+		{Name: github.Ptr("tool-linux-amd64.tar.gz.minisig")},
+		{Name: github.Ptr("tool-linux-amd64.sbom.json")},
+		{Name: github.Ptr("tool-linux-amd64.intoto.jsonl")},
+		{Name: github.Ptr("tool-linux-amd64.pem")},
+		{Name: github.Ptr("tool-1.0.0-src.tar.gz")},
+		{Name: github.Ptr("tool-linux-amd64-debug.tar.gz")},
+		{Name: github.Ptr("checksums.txt")},
+		{Name: github.Ptr("SHA256SUMS")},
+		{Name: github.Ptr("tool-linux-arm64.tar.gz")},
+		{Name: github.Ptr("tool-windows-amd64.zip")},
+	}
+
+	scored := scoreReleaseAssets(assets, Platform{OS: "linux", Arch: "amd64", Libc: "gnu"})
+	if len(scored) != 1 || scored[0].Asset.GetName() != "tool-linux-amd64.tar.gz" {
+		var names []string
+		for _, c := range scored {
+			names = append(names, c.Asset.GetName())
+		}
+		t.Errorf("scoreReleaseAssets() = %v, want only tool-linux-amd64.tar.gz", names)
+	}
+}
+
+func TestScoreReleaseAssets_ArmVersion(t *testing.T) {
+	assets := []*github.ReleaseAsset{
+		{Name: github.Ptr("app-linux-armv6.tar.gz")},
+		{Name: github.Ptr("app-linux-armv7.tar.gz")},
+	}
+	tests := map[int]string{
+		6: "app-linux-armv6.tar.gz",
+		7: "app-linux-armv7.tar.gz",
+		5: "",
+	}
+	for arm, want := range tests {
+		scored := scoreReleaseAssets(assets, Platform{OS: "linux", Arch: "arm", Arm: arm, Libc: "gnu"})
+		got := ""
+		if len(scored) > 0 {
+			got = scored[0].Asset.GetName()
+		}
+		if got != want {
+			t.Errorf("ARMv%d: top asset = %q, want %q", arm, got, want)
+		}
+	}
+}
+
+func TestParseAssetName(t *testing.T) {
+	tests := map[string]assetTraits{
+		"tool-darwin-amd64.tar.gz":       {os: "darwin", arch: "amd64", format: ".tar.gz"},
+		"tool-x86_64-pc-windows-gnu.zip": {os: "windows", arch: "amd64", libc: "gnu", format: ".zip"},
+		"tool-aarch64-linux-android.tgz": {os: "android", arch: "arm64", format: ".tar.gz"},
+		"tool_armhf.deb":                 {os: "linux", arch: "arm", arm: 7, format: ".deb"},
+		"tool-win32.exe":                 {os: "windows", arch: "386", format: ".exe"},
+		"tool-linux-armel":               {os: "linux", arch: "arm", arm: 5},
+		"tool-universal2-macos.zip":      {os: "darwin", arch: "universal", format: ".zip"},
+		"tool.dmg":                       {excluded: true},
+	}
+	for name, want := range tests {
+		if got := parseAssetName(name, osTable, archTable); got != want {
+			t.Errorf("parseAssetName(%q) = %+v, want %+v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package installer
+
+import (
+	"parm/internal/config"
+	"parm/pkg/sysutil"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// The platform release assets are picked for.
+type Platform struct {
+	OS   string // GOOS
+	Arch string // GOARCH
+	// ARM version (5, 6 or 7) when Arch is "arm", 0 if unknown
+	Arm int
+	// "gnu" or "musl" on Linux, empty elsewhere
+	Libc string
+}
+
+func HostPlatform() Platform {
+	return Platform{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+		Arm:  sysutil.ArmVersion(),
+		Libc: sysutil.DetectLibc(),
+	}
+}
+
+// Assets are scored on separate dimensions, from most to least important: OS, architecture,
+// how well the architecture fits (exact, universal or an older ARM version), whether it's a plain
+// archive or executable rather than a system package, libc and format.
+// Every dimension outweighs all of the ones below it combined.
+const (
+	osMatch   = 1024
+	archMatch = 512
+	archFit   = 128 // per level, 0 to 3
+	plainFit  = 64  // archives and bare executables over .deb, .rpm and AppImage
+	libcFit   = 16  // per level, 0 to 3
+	// the format adds 0 to 15
+
+	// assets whose OS and architecture score is below this didn't match both of them
+	minScoreMatch = osMatch + archMatch
+)
+
+type aliasTable []struct {
+	name    string
+	aliases []string
+}
+
+// checked in order, the first match wins. e.g. "android" has to come before "linux" for "aarch64-linux-android".
+var osTable = aliasTable{
+	{"android", []string{"android"}},
+	{"linux", []string{"linux"}},
+	{"darwin", []string{"darwin", "macos", "mac", "osx", "apple"}},
+	{"windows", []string{"windows", "win", "msvc", "mingw"}},
+	{"freebsd", []string{"freebsd"}},
+	{"openbsd", []string{"openbsd"}},
+	{"netbsd", []string{"netbsd"}},
+	{"illumos", []string{"illumos"}},
+	{"solaris", []string{"solaris"}},
+}
+
+// "x86" would also match "x86_64", so amd64 is checked first
+var archTable = aliasTable{
+	{"amd64", []string{"x86_64", "x86-64", "amd64", "x64", "win64", "linux64", "64bit", "64-bit"}},
+	{"arm64", []string{"aarch64", "arm64", "armv8"}},
+	{"arm", []string{"armv7l", "armv7a", "armv7", "armhf", "arm7", "armv6l", "armv6", "arm6", "armel", "armv5", "arm"}},
+	{"386", []string{"i386", "i686", "i586", "386", "x86", "ia32", "win32", "linux32", "32bit", "32-bit"}},
+	{"ppc64le", []string{"ppc64le", "powerpc64le"}},
+	{"ppc64", []string{"ppc64", "powerpc64"}},
+	{"s390x", []string{"s390x"}},
+	{"riscv64", []string{"riscv64"}},
+	{"loong64", []string{"loong64", "loongarch64"}},
+	{"mips64le", []string{"mips64le", "mips64el"}},
+	{"mipsle", []string{"mipsle", "mipsel"}},
+	{"mips64", []string{"mips64"}},
+	{"mips", []string{"mips"}},
+	// fat binaries that run on every architecture of their OS, only meaningful for macOS
+	{"universal", []string{"universal2", "universal", "all"}},
+}
+
+// architectures a platform can run through emulation or compatibility layers, like Rosetta 2
+var emulatedArchs = map[string][]string{
+	"darwin/arm64":  {"amd64"},
+	"windows/amd64": {"386"},
+	"windows/arm64": {"amd64", "386"},
+}
+
+// formats are listed with their longer spelling first, so ".tar.gz" wins over ".gz"
+var formatSuffixes = []struct{ suffix, format string }{
+	{".tar.gz", ".tar.gz"}, {".tgz", ".tar.gz"},
+	{".tar.xz", ".tar.xz"}, {".txz", ".tar.xz"},
+	{".tar.zst", ".tar.zst"}, {".tar.zstd", ".tar.zst"}, {".tzst", ".tar.zst"},
+	{".tar.bz2", ".tar.bz2"}, {".tbz2", ".tar.bz2"}, {".tbz", ".tar.bz2"},
+	{".tar", ".tar"},
+	{".zip", ".zip"},
+	{".gz", ".gz"}, {".xz", ".xz"}, {".zst", ".zst"}, {".bz2", ".bz2"},
+	{".appimage", ".appimage"}, {".deb", ".deb"}, {".rpm", ".rpm"},
+	{".exe", ".exe"},
+}
+
+// system packages, only used when there's no plain archive or executable
+var packageFormats = []string{".appimage", ".deb", ".rpm"}
+
+// formats that only exist on a single OS
+var formatOS = map[string]string{
+	".appimage": "linux",
+	".deb":      "linux",
+	".rpm":      "linux",
+	".exe":      "windows",
+}
+
+// most preferred first, "" is a bare executable
+var formatPrefs = map[string][]string{
+	"linux":   {".tar.gz", ".tar.xz", ".tar.zst", ".tar.bz2", ".tar", ".zip", "", ".gz", ".xz", ".zst", ".bz2", ".appimage", ".deb", ".rpm"},
+	"windows": {".zip", ".exe", ".tar.gz", ".tar.xz", ".tar.zst", ".tar.bz2", ".tar", ".gz", ".xz", ".zst", ".bz2"},
+	"default": {".tar.gz", ".tar.xz", ".tar.zst", ".tar.bz2", ".tar", ".zip", "", ".gz", ".xz", ".zst", ".bz2"},
+}
+
+// checksums, signatures, metadata, installers parm can't unpack, and packages for other ecosystems
+var excludedSuffixes = []string{
+	".sha256", ".sha256sum", ".sha512", ".sha512sum", ".sha1", ".md5", ".sum", ".b3",
+	".sig", ".asc", ".gpg", ".minisig", ".pem", ".crt", ".cert", ".pub", ".key", ".bundle", ".provenance",
+	".sbom", ".spdx", ".json", ".jsonl", ".txt", ".md", ".yaml", ".yml", ".xml", ".html", ".pdf", ".zsync",
+	".dmg", ".pkg", ".msi", ".apk", ".snap", ".flatpak", ".nupkg", ".vsix", ".whl", ".jar", ".pdb",
+	".sh", ".ps1",
+}
+
+var excludedWords = []string{
+	"checksum", "checksums", "sha256sums", "sha512sums", "shasums",
+	"src", "source", "sources", "debug", "dbgsym", "symbols", "dsym", "sbom", "license",
+}
+
+// what an asset's name says about the platform it was built for
+type assetTraits struct {
+	os     string
+	arch   string
+	arm    int
+	libc   string
+	format string
+	// files that can't be installed, like checksums and signatures
+	excluded bool
+}
+
+func parseAssetName(name string, osTab, archTab aliasTable) assetTraits {
+	name = strings.ToLower(name)
+	var t assetTraits
+
+	for _, suffix := range excludedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			t.excluded = true
+		}
+	}
+	for _, word := range excludedWords {
+		if containsWord(name, word, false) {
+			t.excluded = true
+		}
+	}
+
+	for _, f := range formatSuffixes {
+		if strings.HasSuffix(name, f.suffix) {
+			t.format = f.format
+			break
+		}
+	}
+	// trailing digits allow names like "linux64" and "win32"
+	t.os, _ = osTab.match(name, true)
+	if t.os == "" {
+		t.os = formatOS[t.format]
+	}
+
+	var alias string
+	t.arch, alias = archTab.match(name, false)
+	if t.arch == "arm" {
+		t.arm = armVersion(alias)
+	}
+
+	switch {
+	case strings.Contains(name, "musl"):
+		t.libc = sysutil.LibcMusl
+	case strings.Contains(name, "gnu") || strings.Contains(name, "glibc"):
+		t.libc = sysutil.LibcGnu
+	}
+	return t
+}
+
+func (tab aliasTable) match(name string, digitsAfter bool) (string, string) {
+	for _, e := range tab {
+		for _, alias := range e.aliases {
+			if containsWord(name, alias, digitsAfter) {
+				return e.name, alias
+			}
+		}
+	}
+	return "", ""
+}
+
+// extends a table with the aliases from the config
+func (tab aliasTable) with(extra map[string][]string) aliasTable {
+	if len(extra) == 0 {
+		return tab
+	}
+	ext := slices.Clone(tab)
+	for name, aliases := range extra {
+		i := slices.IndexFunc(ext, func(e struct {
+			name    string
+			aliases []string
+		}) bool {
+			return e.name == name
+		})
+		if i < 0 {
+			ext = append(ext, struct {
+				name    string
+				aliases []string
+			}{name, lowerAll(aliases)})
+			continue
+		}
+		// configured aliases are checked first
+		ext[i].aliases = append(lowerAll(aliases), ext[i].aliases...)
+	}
+	return ext
+}
+
+func armVersion(alias string) int {
+	switch {
+	case strings.HasPrefix(alias, "armv7"), alias == "armhf", alias == "arm7":
+		return 7
+	case strings.HasPrefix(alias, "armv6"), alias == "arm6":
+		return 6
+	case alias == "armel", alias == "armv5":
+		return 5
+	}
+	return 0
+}
+
+// reports whether word appears in name without being part of a longer word,
+// e.g. "win" is found in "tool-win.zip" but not in "tool-darwin.zip".
+func containsWord(name, word string, digitsAfter bool) bool {
+	for i := 0; i < len(name); {
+		j := strings.Index(name[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		before := start == 0 || !isAlnum(name[start-1])
+		after := end == len(name) || !isAlnum(name[end]) || (digitsAfter && isDigit(name[end]))
+		if before && after {
+			return true
+		}
+		i = start + 1
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'z')
+}
+
+// scores an asset for a platform. Returns false if the asset can't run on it at all.
+func (t assetTraits) score(plat Platform) (score, platform int, ok bool) {
+	if t.excluded {
+		return 0, 0, false
+	}
+
+	switch t.os {
+	case plat.OS:
+		platform += osMatch
+	case "":
+	default:
+		return 0, 0, false
+	}
+
+	fit := 0
+	switch {
+	case t.arch == plat.Arch:
+		platform += archMatch
+		fit = 3
+		if plat.Arch == "arm" {
+			switch {
+			case t.arm == 0 || plat.Arm == 0:
+				fit = 2
+			case t.arm > plat.Arm:
+				// needs a newer CPU
+				return 0, 0, false
+			case t.arm < plat.Arm:
+				fit = 1
+			}
+		}
+	case t.arch == "universal" && plat.OS == "darwin":
+		platform += archMatch
+		fit = 2
+	case t.arch == "" || t.arch == "universal":
+	case slices.Contains(emulatedArchs[plat.OS+"/"+plat.Arch], t.arch):
+		// runs, but doesn't count as a match
+		fit = 1
+	default:
+		return 0, 0, false
+	}
+
+	libc := 0
+	switch {
+	case plat.Libc == "":
+		// a libc in the name on other platforms means a MinGW or cross-compiled build
+		if t.libc == "" {
+			libc = 1
+		}
+	case t.libc == plat.Libc:
+		libc = 3
+	case t.libc == "":
+		libc = 2
+	case t.libc == sysutil.LibcMusl:
+		// usually statically linked, so it runs on glibc too
+		libc = 1
+	}
+
+	plain := 1
+	if slices.Contains(packageFormats, t.format) {
+		plain = 0
+	}
+
+	prefs, found := formatPrefs[plat.OS]
+	if !found {
+		prefs = formatPrefs["default"]
+	}
+	format := 0
+	if i := slices.Index(prefs, t.format); i >= 0 {
+		format = len(prefs) - i
+	}
+
+	return platform + fit*archFit + plain*plainFit + libc*libcFit + format, platform, true
+}
+
+// scores the assets of a release that can run on plat, highest score first
+func scoreReleaseAssets(assets []*github.ReleaseAsset, plat Platform) []AssetCandidate {
+	osTab := osTable.with(config.Cfg.Assets.OSAliases)
+	archTab := archTable.with(config.Cfg.Assets.ArchAliases)
+
+	var scored []AssetCandidate
+	for _, a := range assets {
+		t := parseAssetName(a.GetName(), osTab, archTab)
+		score, platform, ok := t.score(plat)
+		if !ok {
+			continue
+		}
+		scored = append(scored, AssetCandidate{Asset: a, Score: score, platformScore: platform})
+	}
+
+	slices.SortStableFunc(scored, func(a, b AssetCandidate) int {
+		return b.Score - a.Score
+	})
+	return scored
+}
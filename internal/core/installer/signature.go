@@ -0,0 +1,234 @@
+package installer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"parm/internal/config"
+	"parm/internal/core/verify"
+	"parm/internal/manifest"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// signature files are named after the file they sign, e.g. "checksums.txt.minisig"
+var signatureKinds = []struct{ suffix, kind string }{
+	{".minisig", "minisign"},
+	{".asc", "gpg"},
+	{".gpg", "gpg"},
+	// either a cosign or a binary OpenPGP signature, told apart by their contents
+	{".sig", "sig"},
+	{".sigstore.json", "bundle"},
+	{".sigstore", "bundle"},
+	{".bundle", "bundle"},
+}
+
+// certificates of keyless cosign signatures, published next to the .sig
+var certificateSuffixes = []string{".pem", ".crt", ".cert"}
+
+type signatureFile struct {
+	asset *github.ReleaseAsset
+	// "minisign", "gpg", "sig" or "bundle"
+	kind string
+	// the signing certificate of a keyless .sig, if any
+	cert *github.ReleaseAsset
+}
+
+// the methods a signature file may have been made with
+func (f signatureFile) methods() []string {
+	switch f.kind {
+	case "sig":
+		return []string{"cosign", "gpg"}
+	case "bundle":
+		return []string{"cosign"}
+	}
+	return []string{f.kind}
+}
+
+// Finds the signature files of a release asset.
+func signatureFiles(rel *github.RepositoryRelease, target *github.ReleaseAsset) []signatureFile {
+	name := strings.ToLower(target.GetName())
+
+	var certs []*github.ReleaseAsset
+	for _, a := range rel.Assets {
+		for _, suffix := range certificateSuffixes {
+			if strings.ToLower(a.GetName()) == name+suffix {
+				certs = append(certs, a)
+			}
+		}
+	}
+
+	var files []signatureFile
+	for _, a := range rel.Assets {
+		lower := strings.ToLower(a.GetName())
+		for _, k := range signatureKinds {
+			if lower != name+k.suffix {
+				continue
+			}
+			f := signatureFile{asset: a, kind: k.kind}
+			if k.kind == "sig" && len(certs) > 0 {
+				f.cert = certs[0]
+			}
+			files = append(files, f)
+			break
+		}
+	}
+	return files
+}
+
+// Reads the keys and identities configured for a package.
+func loadTrust(rules config.SignatureRules) (verify.Trust, error) {
+	trust := verify.Trust{
+		MinisignKey:   rules.MinisignKey,
+		Identity:      rules.Identity,
+		IdentityRegex: rules.IdentityRegex,
+		Issuer:        rules.Issuer,
+	}
+	files := []struct {
+		path string
+		dst  *[]byte
+	}{
+		{rules.GPGKey, &trust.GPGKeys},
+		{rules.CosignKey, &trust.CosignKey},
+		{rules.TrustedRoots, &trust.Roots},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return verify.Trust{}, fmt.Errorf("cannot read signing key: \n%w", err)
+		}
+		*f.dst = data
+	}
+	return trust, nil
+}
+
+// Verifies that ass, or a checksum file that lists it, is signed by a key or identity configured for the package.
+// When the checksum file is what's signed, the checksum it lists for ass is returned along with the name of the file,
+// so the asset can be checked against it.
+func (in *Installer) verifySignature(ctx context.Context, owner, repo string, rel *github.RepositoryRelease, ass *github.ReleaseAsset, path string) (*manifest.Signer, *verify.Checksum, string, error) {
+	trust, err := loadTrust(config.Cfg.RulesFor(owner, repo).Signature)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if trust.Empty() {
+		return nil, nil, "", fmt.Errorf("no signing keys or identities configured for %s/%s; add them to [packages.\"%s/%s\".signature] in the config or re-run with --verify=checksum", owner, repo, owner, repo)
+	}
+
+	signer, untrusted, err := in.checkSignatures(ctx, owner, repo, rel, ass, path, trust)
+	if err != nil || signer != nil {
+		return signer, nil, "", err
+	}
+
+	// a signed checksum file vouches for every asset it lists
+	for _, src := range checksumSources(rel, ass) {
+		if len(signatureFiles(rel, src.asset)) == 0 {
+			continue
+		}
+		data, err := in.fetchAsset(ctx, owner, repo, src.asset)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("cannot download checksum file %q: \n%w", src.asset.GetName(), err)
+		}
+		want, ok := verify.FindChecksum(data, ass.GetName(), src.bare)
+		if !ok {
+			continue
+		}
+
+		signer, skipped, err := in.checkSignatureOf(ctx, owner, repo, rel, src.asset, data, trust)
+		untrusted = append(untrusted, skipped...)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if signer != nil {
+			return signer, &want, src.asset.GetName(), nil
+		}
+	}
+
+	if len(untrusted) > 0 {
+		return nil, nil, "", fmt.Errorf("none of the keys or identities configured for %s/%s can check the signatures of %q: %s", owner, repo, ass.GetName(), strings.Join(untrusted, ", "))
+	}
+	return nil, nil, "", fmt.Errorf("neither %q nor a checksum file listing it is signed; re-run with --verify=checksum to skip the signature", ass.GetName())
+}
+
+// checks the signatures of a file downloaded into memory, like a checksum file
+func (in *Installer) checkSignatureOf(ctx context.Context, owner, repo string, rel *github.RepositoryRelease, target *github.ReleaseAsset, data []byte, trust verify.Trust) (*manifest.Signer, []string, error) {
+	tmp, err := os.CreateTemp("", "parm-signed-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return in.checkSignatures(ctx, owner, repo, rel, target, tmp.Name(), trust)
+}
+
+// Checks the signature files of target, downloaded to path, against trust. Returns the first signer that verifies,
+// or the names of the signature files no key or identity was configured for. Any signature that is checked must be valid.
+func (in *Installer) checkSignatures(ctx context.Context, owner, repo string, rel *github.RepositoryRelease, target *github.ReleaseAsset, path string, trust verify.Trust) (*manifest.Signer, []string, error) {
+	var untrusted []string
+	for _, f := range signatureFiles(rel, target) {
+		trusted := false
+		for _, m := range f.methods() {
+			trusted = trusted || trust.Trusts(m)
+		}
+		if !trusted {
+			untrusted = append(untrusted, f.asset.GetName())
+			continue
+		}
+
+		sig, err := in.fetchAsset(ctx, owner, repo, f.asset)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot download signature %q: \n%w", f.asset.GetName(), err)
+		}
+		var cert []byte
+		if f.cert != nil {
+			if cert, err = in.fetchAsset(ctx, owner, repo, f.cert); err != nil {
+				return nil, nil, fmt.Errorf("cannot download certificate %q: \n%w", f.cert.GetName(), err)
+			}
+		}
+
+		kind := f.kind
+		if kind == "sig" {
+			kind = "gpg"
+			if isBase64(sig) {
+				kind = "cosign"
+			}
+			if !trust.Trusts(kind) {
+				untrusted = append(untrusted, f.asset.GetName())
+				continue
+			}
+		}
+
+		var signer *verify.Signer
+		switch kind {
+		case "minisign":
+			signer, err = verify.VerifyMinisign(path, sig, trust.MinisignKey)
+		case "gpg":
+			signer, err = verify.VerifyGPG(path, sig, trust.GPGKeys)
+		case "cosign":
+			signer, err = verify.VerifyCosign(path, sig, cert, trust)
+		case "bundle":
+			signer, err = verify.VerifyBundle(path, sig, trust)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("fatal: cannot verify signature %q of %q: \n%w", f.asset.GetName(), target.GetName(), err)
+		}
+		return &manifest.Signer{Method: signer.Method, Identity: signer.Identity, File: f.asset.GetName()}, nil, nil
+	}
+	return nil, untrusted, nil
+}
+
+// cosign signatures are base64 encoded, OpenPGP ones are either binary or armored
+func isBase64(data []byte) bool {
+	_, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	return err == nil
+}
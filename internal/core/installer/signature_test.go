@@ -0,0 +1,158 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"parm/internal/config"
+	"parm/internal/core/verify"
+	"parm/internal/manifest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestSignatureFiles(t *testing.T) {
+	rel := releaseWithAssets(
+		"tool.tar.gz",
+		"tool.tar.gz.sig",
+		"tool.tar.gz.pem",
+		"tool.tar.gz.sigstore.json",
+		"checksums.txt",
+		"checksums.txt.minisig",
+		"tool.tar.gz.sha256",
+	)
+	var got []string
+	for _, f := range signatureFiles(rel, rel.Assets[0]) {
+		got = append(got, f.asset.GetName()+":"+f.kind)
+		if f.kind == "sig" && f.cert.GetName() != "tool.tar.gz.pem" {
+			t.Errorf("signatureFiles() cert of %s = %s, want tool.tar.gz.pem", f.asset.GetName(), f.cert.GetName())
+		}
+	}
+	want := "tool.tar.gz.sig:sig tool.tar.gz.sigstore.json:bundle"
+	if strings.Join(got, " ") != want {
+		t.Errorf("signatureFiles() = %v, want %s", got, want)
+	}
+
+	// signatures aren't mistaken for checksum files
+	for _, src := range checksumSources(rel, rel.Assets[0]) {
+		if strings.Contains(src.asset.GetName(), "sig") {
+			t.Errorf("checksumSources() returned %s", src.asset.GetName())
+		}
+	}
+}
+
+// returns a minisign public key and a prehashed signature of data made with it
+func minisign(t *testing.T, data []byte) (string, []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte("parmtest")
+	sum := blake2b.Sum512(data)
+	sig := ed25519.Sign(priv, sum[:])
+	comment := "file:checksums.txt"
+	global := ed25519.Sign(priv, append(bytes.Clone(sig), comment...))
+
+	pubKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+	sigFile := fmt.Sprintf("untrusted comment: test\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte("ED"), keyID...), sig...)),
+		comment, base64.StdEncoding.EncodeToString(global))
+	return pubKey, []byte(sigFile)
+}
+
+func TestInstallFromRelease_Signature(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+	savedPackages := config.Cfg.Packages
+	t.Cleanup(func() { config.Cfg.Packages = savedPackages })
+
+	archivePath := createTestTarGzWithBinary(t, tmpDir)
+	sum, err := verify.GetSha256(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetName := fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	checksums := []byte(fmt.Sprintf("%s  %s\n", sum, assetName))
+	key, sig := minisign(t, checksums)
+	otherKey, _ := minisign(t, checksums)
+
+	tests := []struct {
+		name    string
+		key     string
+		sigName string
+		wantErr string
+	}{
+		{"signed checksum file", key, "checksums.txt.minisig", ""},
+		{"other key", otherKey, "checksums.txt.minisig", "signature invalid"},
+		{"no key for the signature", key, "checksums.txt.asc", "none of the keys"},
+		{"unsigned", key, "checksums.txt.txt", "nor a checksum file listing it is signed"},
+		{"nothing configured", "", "checksums.txt.minisig", "no signing keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cfg.Packages = map[string]config.PackageRules{
+				"owner/repo": {Signature: config.SignatureRules{MinisignKey: tt.key}},
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/checksums":
+					w.Write(checksums)
+				case "/sig":
+					w.Write(sig)
+				default:
+					http.ServeFile(w, r, archivePath)
+				}
+			}))
+			defer server.Close()
+
+			release := &github.RepositoryRelease{
+				TagName: github.Ptr("v1.0.0"),
+				Assets: []*github.ReleaseAsset{
+					{ID: github.Ptr(int64(1)), Name: github.Ptr(assetName)},
+					{ID: github.Ptr(int64(2)), Name: github.Ptr("checksums.txt")},
+					{ID: github.Ptr(int64(3)), Name: github.Ptr(tt.sigName)},
+				},
+			}
+			paths := map[string]string{"1": "/asset", "2": "/checksums", "3": "/sig"}
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						http.Redirect(w, r, server.URL+paths[filepath.Base(r.URL.Path)], http.StatusFound)
+					}),
+				),
+			)
+
+			installer := New(github.NewClient(mockedHTTPClient).Repositories)
+			pkgPath := filepath.Join(tmpDir, "owner", "repo")
+			opts := InstallFlags{Type: manifest.Release, VerifyLevel: verify.LevelSignature}
+
+			res, err := installer.installFromRelease(context.Background(), pkgPath, "owner", "repo", release, opts, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("installFromRelease() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("installFromRelease() error: %v", err)
+			}
+			defer res.Abort()
+			if res.Signer == nil || res.Signer.Method != "minisign" || res.Signer.File != "checksums.txt.minisig" {
+				t.Errorf("installFromRelease() signer = %+v, want minisign from checksums.txt.minisig", res.Signer)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package journal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"parm/internal/config"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const JOURNAL_DIR_NAME string = ".journal"
+
+type Step string
+
+const (
+	// release asset downloaded into the staging dir
+	StepStage Step = "stage"
+	// asset verified and extracted in the staging dir
+	StepVerify Step = "verify"
+	// staging dir moved into the version dir
+	StepPromote Step = "promote"
+	// manifest written into the version dir
+	StepManifest Step = "manifest"
+	// version activated and symlinked into parm_bin_path
+	StepLink Step = "link"
+)
+
+// Transaction records the progress of a single install, so an interrupted install can be finished or undone.
+type Transaction struct {
+	ID      string `json:"id"`
+	PID     int    `json:"pid"`
+	Started string `json:"started"`
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Version string `json:"version"`
+
+	StagingDir string `json:"staging_dir"`
+	TargetDir  string `json:"target_dir"`
+	// previous install of the same version, moved aside while promoting
+	BackupDir string `json:"backup_dir,omitempty"`
+
+	Done []Step `json:"done"`
+}
+
+func GetJournalDir() string {
+	return filepath.Join(config.Cfg.ParmPkgPath, JOURNAL_DIR_NAME)
+}
+
+// Begin starts a new transaction for a package and writes it to the journal.
+func Begin(owner, repo string) (*Transaction, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	tx := &Transaction{
+		ID:      hex.EncodeToString(id),
+		PID:     os.Getpid(),
+		Started: time.Now().UTC().Format(time.DateTime),
+		Owner:   owner,
+		Repo:    repo,
+	}
+	if err := os.MkdirAll(GetJournalDir(), 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create journal dir: \n%w", err)
+	}
+	return tx, tx.write()
+}
+
+func (tx *Transaction) Has(step Step) bool {
+	return slices.Contains(tx.Done, step)
+}
+
+// Mark records a completed step. Fields set on tx before calling Mark are written along with it.
+func (tx *Transaction) Mark(step Step) error {
+	if !tx.Has(step) {
+		tx.Done = append(tx.Done, step)
+	}
+	return tx.write()
+}
+
+// Promote moves the staging dir into the target dir. An existing install in the target dir
+// is moved aside rather than removed, and is only deleted once the transaction is committed.
+func (tx *Transaction) Promote(staging, target string) error {
+	tx.StagingDir = staging
+	tx.TargetDir = target
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	if fi, err := os.Stat(target); err == nil && fi.IsDir() {
+		tx.BackupDir = filepath.Join(filepath.Dir(target), fmt.Sprintf(".backup-%s-%s", filepath.Base(target), tx.ID))
+		if err := tx.write(); err != nil {
+			return err
+		}
+		if err := os.Rename(target, tx.BackupDir); err != nil {
+			return fmt.Errorf("failed moving existing install aside: %w", err)
+		}
+	} else if err := tx.write(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(staging, target); err != nil {
+		return fmt.Errorf("staging promotion failed: %w", err)
+	}
+	return tx.Mark(StepPromote)
+}
+
+// Commit removes what the transaction replaced and closes it.
+func (tx *Transaction) Commit() error {
+	if tx.BackupDir != "" {
+		if err := os.RemoveAll(tx.BackupDir); err != nil {
+			return err
+		}
+	}
+	return tx.remove()
+}
+
+// Abort undoes everything the transaction did and closes it.
+// The previous install, if there was one, is put back in place.
+func (tx *Transaction) Abort() error {
+	var errs []error
+	// a missing staging dir proves nothing, it may have been cleaned up already. The target dir is only
+	// known to hold this install once promotion was recorded, or once the install it held was moved aside.
+	promoted := tx.Has(StepPromote)
+	if tx.BackupDir != "" {
+		if _, err := os.Stat(tx.BackupDir); err == nil {
+			promoted = true
+		}
+	}
+	if tx.StagingDir != "" {
+		if err := os.RemoveAll(tx.StagingDir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if promoted && tx.TargetDir != "" {
+		if err := os.RemoveAll(tx.TargetDir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if tx.BackupDir != "" {
+		if _, err := os.Stat(tx.BackupDir); err == nil {
+			if _, err := os.Stat(tx.TargetDir); os.IsNotExist(err) {
+				if err := os.Rename(tx.BackupDir, tx.TargetDir); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return tx.remove()
+}
+
+func (tx *Transaction) path() string {
+	return filepath.Join(GetJournalDir(), tx.ID+".json")
+}
+
+func (tx *Transaction) write() error {
+	data, err := json.MarshalIndent(tx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := tx.path() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, tx.path())
+}
+
+func (tx *Transaction) remove() error {
+	if err := os.Remove(tx.path()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Pending returns every transaction in the journal that hasn't been committed or aborted.
+func Pending() ([]*Transaction, error) {
+	entries, err := os.ReadDir(GetJournalDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var txs []*Transaction
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(GetJournalDir(), e.Name()))
+		if err != nil {
+			continue
+		}
+		var tx Transaction
+		if err := json.Unmarshal(data, &tx); err != nil {
+			// unreadable entry, nothing can be recovered from it
+			_ = os.Remove(filepath.Join(GetJournalDir(), e.Name()))
+			continue
+		}
+		txs = append(txs, &tx)
+	}
+	return txs, nil
+}
@@ -0,0 +1,278 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"parm/internal/config"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+)
+
+func setupDirs(t *testing.T) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = filepath.Join(tmpDir, "pkg")
+	config.Cfg.ParmBinPath = filepath.Join(tmpDir, "bin")
+	os.MkdirAll(config.Cfg.ParmBinPath, 0755)
+}
+
+// creates a staging dir holding a single fake ELF binary
+func makeStaging(t *testing.T, content string) string {
+	t.Helper()
+	dir, err := parmutil.MakeStagingDir("owner", "repo")
+	if err != nil {
+		t.Fatalf("MakeStagingDir() error: %v", err)
+	}
+	bin := append([]byte{0x7f, 0x45, 0x4c, 0x46}, make([]byte, 60)...)
+	bin = append(bin, content...)
+	if err := os.WriteFile(filepath.Join(dir, "tool"), bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readTool(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "tool"))
+	if err != nil {
+		t.Fatalf("cannot read binary in %s: %v", dir, err)
+	}
+	return string(data[64:])
+}
+
+func begin(t *testing.T, version string) *Transaction {
+	t.Helper()
+	tx, err := Begin("owner", "repo")
+	if err != nil {
+		t.Fatalf("Begin() error: %v", err)
+	}
+	tx.Version = version
+	return tx
+}
+
+func assertNoPending(t *testing.T) {
+	t.Helper()
+	txs, err := Pending()
+	if err != nil {
+		t.Fatalf("Pending() error: %v", err)
+	}
+	if len(txs) != 0 {
+		t.Errorf("Pending() = %d transactions, want 0", len(txs))
+	}
+}
+
+func TestPromote_Commit(t *testing.T) {
+	setupDirs(t)
+	target := parmutil.GetVersionDir("owner", "repo", "v1.0.0")
+	os.MkdirAll(target, 0755)
+	os.WriteFile(filepath.Join(target, "tool"), append(make([]byte, 64), "old"...), 0755)
+
+	tx := begin(t, "v1.0.0")
+	staging := makeStaging(t, "new")
+	if err := tx.Promote(staging, target); err != nil {
+		t.Fatalf("Promote() error: %v", err)
+	}
+	if tx.BackupDir == "" {
+		t.Fatal("Promote() should move the existing install aside")
+	}
+	if got := readTool(t, target); got != "new" {
+		t.Errorf("target holds %q, want new", got)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error: %v", err)
+	}
+	if _, err := os.Stat(tx.BackupDir); !os.IsNotExist(err) {
+		t.Error("backup of the replaced install should be removed on commit")
+	}
+	assertNoPending(t)
+}
+
+func TestAbort_RestoresReplacedInstall(t *testing.T) {
+	setupDirs(t)
+	target := parmutil.GetVersionDir("owner", "repo", "v1.0.0")
+	os.MkdirAll(target, 0755)
+	os.WriteFile(filepath.Join(target, "tool"), append(make([]byte, 64), "old"...), 0755)
+
+	tx := begin(t, "v1.0.0")
+	if err := tx.Promote(makeStaging(t, "new"), target); err != nil {
+		t.Fatalf("Promote() error: %v", err)
+	}
+
+	if err := tx.Abort(); err != nil {
+		t.Fatalf("Abort() error: %v", err)
+	}
+	if got := readTool(t, target); got != "old" {
+		t.Errorf("target holds %q after abort, want old", got)
+	}
+	assertNoPending(t)
+}
+
+func TestAbort_FailedPromoteKeepsExistingInstall(t *testing.T) {
+	setupDirs(t)
+	target := parmutil.GetVersionDir("owner", "repo", "v1.0.0")
+	os.MkdirAll(target, 0755)
+	os.WriteFile(filepath.Join(target, "tool"), append(make([]byte, 64), "old"...), 0755)
+
+	tx := begin(t, "v1.0.0")
+	staging := makeStaging(t, "new")
+	// the journal can't be written, so the existing install is never moved aside
+	os.Mkdir(tx.path()+".tmp", 0755)
+	if err := tx.Promote(staging, target); err == nil {
+		t.Fatal("Promote() should fail when the journal can't be written")
+	}
+	os.Remove(tx.path() + ".tmp")
+	// the installer may have cleaned up the staging dir already
+	os.RemoveAll(staging)
+
+	if err := tx.Abort(); err != nil {
+		t.Fatalf("Abort() error: %v", err)
+	}
+	if got := readTool(t, target); got != "old" {
+		t.Errorf("target holds %q after abort, want old", got)
+	}
+	assertNoPending(t)
+}
+
+func TestAbort_BeforePromote(t *testing.T) {
+	setupDirs(t)
+	tx := begin(t, "v1.0.0")
+	tx.StagingDir = makeStaging(t, "new")
+	if err := tx.Mark(StepStage); err != nil {
+		t.Fatalf("Mark() error: %v", err)
+	}
+
+	if err := tx.Abort(); err != nil {
+		t.Fatalf("Abort() error: %v", err)
+	}
+	if _, err := os.Stat(tx.StagingDir); !os.IsNotExist(err) {
+		t.Error("staging dir should be removed on abort")
+	}
+	if _, err := os.Stat(parmutil.GetVersionDir("owner", "repo", "v1.0.0")); !os.IsNotExist(err) {
+		t.Error("nothing should be installed after abort")
+	}
+	assertNoPending(t)
+}
+
+func TestRecover_UndoesInstallWithoutManifest(t *testing.T) {
+	setupDirs(t)
+	target := parmutil.GetVersionDir("owner", "repo", "v2.0.0")
+
+	tx := begin(t, "v2.0.0")
+	if err := tx.Promote(makeStaging(t, "new"), target); err != nil {
+		t.Fatalf("Promote() error: %v", err)
+	}
+	// simulate the process dying before the manifest was written
+	tx.PID = -1
+	tx.write()
+
+	recs, err := Recover()
+	if err != nil {
+		t.Fatalf("Recover() error: %v", err)
+	}
+	if len(recs) != 1 || recs[0].Finished {
+		t.Fatalf("Recover() = %+v, want one undone install", recs)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("half finished install should be removed")
+	}
+	assertNoPending(t)
+}
+
+func TestRecover_FinishesInstallWithManifest(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping Linux-specific test")
+	}
+	setupDirs(t)
+	target := parmutil.GetVersionDir("owner", "repo", "v2.0.0")
+
+	tx := begin(t, "v2.0.0")
+	if err := tx.Promote(makeStaging(t, "new"), target); err != nil {
+		t.Fatalf("Promote() error: %v", err)
+	}
+	man, err := manifest.New("owner", "repo", "v2.0.0", manifest.Release, target)
+	if err != nil {
+		t.Fatalf("manifest.New() error: %v", err)
+	}
+	if err := man.Write(target); err != nil {
+		t.Fatal(err)
+	}
+	tx.PID = -1
+	if err := tx.Mark(StepManifest); err != nil {
+		t.Fatal(err)
+	}
+
+	recs, err := Recover()
+	if err != nil {
+		t.Fatalf("Recover() error: %v", err)
+	}
+	if len(recs) != 1 || !recs[0].Finished {
+		t.Fatalf("Recover() = %+v, want one finished install", recs)
+	}
+	if cur, _ := parmutil.GetCurrentVersion("owner", "repo"); cur != "v2.0.0" {
+		t.Errorf("current version = %q, want v2.0.0", cur)
+	}
+	link, err := os.Readlink(parmutil.GetBinDir("tool"))
+	if err != nil || link != filepath.Join(target, "tool") {
+		t.Errorf("tool links to %q (%v), want %q", link, err, filepath.Join(target, "tool"))
+	}
+	assertNoPending(t)
+}
+
+func TestRecover_SkipsRunningProcess(t *testing.T) {
+	setupDirs(t)
+	tx := begin(t, "v1.0.0")
+	tx.StagingDir = makeStaging(t, "new")
+	// the test runner's parent is guaranteed to be alive
+	tx.PID = os.Getppid()
+	tx.write()
+
+	recs, err := Recover()
+	if err != nil {
+		t.Fatalf("Recover() error: %v", err)
+	}
+	if len(recs) != 0 {
+		t.Errorf("Recover() = %+v, want nothing recovered", recs)
+	}
+	if _, err := os.Stat(tx.StagingDir); err != nil {
+		t.Error("staging dir of a running install should be left alone")
+	}
+}
+
+func TestRecover_RemovesStaleStagingDirs(t *testing.T) {
+	setupDirs(t)
+	staging := makeStaging(t, "orphan")
+
+	if _, err := Recover(); err != nil {
+		t.Fatalf("Recover() error: %v", err)
+	}
+	if _, err := os.Stat(staging); !os.IsNotExist(err) {
+		t.Error("stale staging dir should be removed")
+	}
+}
+
+func TestRecover_FinishesInterruptedMigration(t *testing.T) {
+	setupDirs(t)
+
+	// interrupted after the legacy package dir was moved into the migrating dir
+	migrating := filepath.Join(config.Cfg.ParmPkgPath, "owner", parmutil.MIGRATING_DIR_PREFIX+"repo")
+	versionDir := filepath.Join(migrating, "v1.0.0")
+	os.MkdirAll(versionDir, 0755)
+	man := &manifest.Manifest{Owner: "owner", Repo: "repo", Version: "v1.0.0", InstallType: manifest.Release}
+	if err := man.Write(versionDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Recover(); err != nil {
+		t.Fatalf("Recover() error: %v", err)
+	}
+	if _, err := os.Stat(migrating); !os.IsNotExist(err) {
+		t.Error("migrating dir should be moved in place of the package dir")
+	}
+	if cur, err := parmutil.GetCurrentVersion("owner", "repo"); err != nil || cur != "v1.0.0" {
+		t.Errorf("GetCurrentVersion() = %q, %v, want v1.0.0", cur, err)
+	}
+}
@@ -0,0 +1,145 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"parm/internal/config"
+	"parm/internal/core/switcher"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"parm/pkg/sysutil"
+	"path/filepath"
+	"strings"
+)
+
+type Recovery struct {
+	Owner   string
+	Repo    string
+	Version string
+	// true if the install was finished, false if it was undone
+	Finished bool
+}
+
+// Finishes or undoes every install that was interrupted before it could complete,
+// finishes interrupted layout migrations, then removes any staging dirs left behind.
+// Transactions that belong to another parm process that is still running are left alone.
+func Recover() ([]Recovery, error) {
+	txs, err := Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Recovery
+	var errs []error
+	live := map[string]bool{}
+	for _, tx := range txs {
+		if tx.PID != os.Getpid() && sysutil.IsPidRunning(tx.PID) {
+			live[tx.Owner] = true
+			continue
+		}
+
+		finished, err := recoverTx(tx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not recover install of %s/%s: \n%w", tx.Owner, tx.Repo, err))
+			continue
+		}
+		res = append(res, Recovery{Owner: tx.Owner, Repo: tx.Repo, Version: tx.Version, Finished: finished})
+	}
+
+	if err := finishMigrations(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := removeStaleStagingDirs(live); err != nil {
+		errs = append(errs, err)
+	}
+	return res, errors.Join(errs...)
+}
+
+// an install is finished if its manifest made it to disk, and undone otherwise
+func recoverTx(tx *Transaction) (bool, error) {
+	if tx.Has(StepLink) {
+		return true, tx.Commit()
+	}
+
+	if tx.Has(StepManifest) {
+		man, err := manifest.Read(tx.TargetDir)
+		if err == nil {
+			if err := switcher.Activate(man); err != nil {
+				return false, err
+			}
+			if err := tx.Mark(StepLink); err != nil {
+				return false, err
+			}
+			return true, tx.Commit()
+		}
+	}
+
+	return false, tx.Abort()
+}
+
+// staging dirs of owners with an install in progress in another process are skipped,
+// since that install may not have recorded its staging dir yet
+func removeStaleStagingDirs(skipOwners map[string]bool) error {
+	owners, err := os.ReadDir(config.Cfg.ParmPkgPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []error
+	for _, owner := range owners {
+		if !owner.IsDir() || strings.HasPrefix(owner.Name(), ".") || skipOwners[owner.Name()] {
+			continue
+		}
+		ownerDir := filepath.Join(config.Cfg.ParmPkgPath, owner.Name())
+		entries, err := os.ReadDir(ownerDir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			path := filepath.Join(ownerDir, e.Name())
+			if !e.IsDir() || !strings.HasPrefix(e.Name(), parmutil.STAGING_DIR_PREFIX) {
+				continue
+			}
+			if err := os.RemoveAll(path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// finishes migrations to the versioned layout that were interrupted after the package dir was moved
+func finishMigrations() error {
+	owners, err := os.ReadDir(config.Cfg.ParmPkgPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []error
+	for _, owner := range owners {
+		if !owner.IsDir() || strings.HasPrefix(owner.Name(), ".") {
+			continue
+		}
+		ownerDir := filepath.Join(config.Cfg.ParmPkgPath, owner.Name())
+		entries, err := os.ReadDir(ownerDir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() || !strings.HasPrefix(e.Name(), parmutil.MIGRATING_DIR_PREFIX) {
+				continue
+			}
+			if err := manifest.FinishLegacyMigration(filepath.Join(ownerDir, e.Name())); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
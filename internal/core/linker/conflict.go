@@ -0,0 +1,34 @@
+package linker
+
+import (
+	"fmt"
+	"parm/internal/config"
+	"strings"
+)
+
+// A link in parm_bin_path a package wants that already belongs to something else.
+type Conflict struct {
+	Name string
+	// the package owning the link, empty if it's a file parm doesn't manage
+	Owner string
+}
+
+// Returned when a package ships executables whose links belong to other packages. Nothing is linked in that case.
+type ConflictError struct {
+	Pkg       string
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s ships executables that are already linked in %s:", e.Pkg, config.Cfg.ParmBinPath)
+	for _, c := range e.Conflicts {
+		if c.Owner == "" {
+			fmt.Fprintf(&b, "\n\t%s (not managed by parm)", c.Name)
+		} else {
+			fmt.Fprintf(&b, "\n\t%s (owned by %s)", c.Name, c.Owner)
+		}
+	}
+	b.WriteString("\nre-run with --rename <name>=<other-name> to link them under another name, or --take-bin <name> to link this package's instead")
+	return b.String()
+}
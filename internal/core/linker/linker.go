@@ -0,0 +1,314 @@
+// Package linker keeps the links in parm_bin_path in line with the installed packages.
+// It works out the links a version of a package wants, diffs them against what's in parm_bin_path
+// and the bin index, and creates, retargets and removes links to match.
+package linker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"parm/internal/config"
+	"parm/internal/core/bins"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"parm/pkg/sysutil"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+type Action string
+
+const (
+	Create   Action = "create"
+	Retarget Action = "retarget"
+	Keep     Action = "unchanged"
+	Remove   Action = "remove"
+	// the link is handed back to another package shipping an executable of the same name
+	Restore Action = "restore"
+	// the link belongs to another package or to a file parm doesn't manage
+	Blocked Action = "conflict"
+)
+
+// A change to a link in parm_bin_path.
+type Change struct {
+	Name   string
+	Path   string
+	Action Action
+	// the executable the link will run, empty if it's removed
+	Target string
+	// the executable the link runs now, empty if it doesn't exist or its target isn't known
+	Current string
+	// for Restore, the package the link is handed back to. For Blocked, the package owning it, if any.
+	Owner string
+
+	// the link is taken over from another package
+	take bool
+	// packages whose claim on the link is dropped
+	release []string
+}
+
+// The changes to parm_bin_path that give a package the links it wants.
+type Plan struct {
+	Pkg     string
+	Changes []Change
+
+	idx *bins.Index
+	// links the package was waiting for, but no longer wants
+	dropped []string
+}
+
+// Returns the links a version of a package wants in parm_bin_path, keyed by link name, pointing to its executables.
+func Desired(man *manifest.Manifest) map[string]string {
+	versionDir := parmutil.GetVersionDir(man.Owner, man.Repo, man.Version)
+	links := make(map[string]string)
+	for _, exec := range man.SelectExecutables() {
+		links[man.LinkName(exec)] = filepath.Join(versionDir, exec)
+	}
+	return links
+}
+
+// Works out how to point a package's links at the version described by man, without changing anything.
+// take lists links the package may take over from other packages. Links owned by another package are
+// planned as Blocked and returned with a *ConflictError.
+func PlanLink(man *manifest.Manifest, take ...string) (*Plan, error) {
+	if missing := man.UnmatchedBins(); len(missing) > 0 {
+		return nil, fmt.Errorf("%s/%s %s has no executable %s, it ships: %s",
+			man.Owner, man.Repo, man.Version, strings.Join(missing, ", "), strings.Join(man.Executables, ", "))
+	}
+	p, err := newPlan(man)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := linkMode()
+	if err != nil {
+		return nil, err
+	}
+
+	desired := Desired(man)
+	var conflicts []Conflict
+	for _, name := range sortedNames(desired) {
+		ch := Change{Name: name, Path: parmutil.GetBinDir(name), Target: desired[name]}
+		owner, exists := linkOwner(p.idx, name)
+		switch {
+		case !exists, bins.SamePackage(owner, p.Pkg), slices.Contains(take, name):
+			ch.take = exists && !bins.SamePackage(owner, p.Pkg)
+			ch.Current, ch.Action = diff(ch.Path, ch.Target, mode)
+		case p.idx.Claimed(name, p.Pkg):
+			// another package took the link over, it's handed back once that package releases it
+			continue
+		default:
+			ch.Action = Blocked
+			ch.Owner = owner
+			ch.Current, _ = sysutil.ReadBinLink(ch.Path)
+			conflicts = append(conflicts, Conflict{Name: name, Owner: owner})
+		}
+		p.Changes = append(p.Changes, ch)
+	}
+
+	// executables the previous version shipped but this one doesn't
+	for _, name := range p.idx.Names(p.Pkg) {
+		if _, ok := desired[name]; !ok {
+			p.release(name)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return p, &ConflictError{Pkg: p.Pkg, Conflicts: conflicts}
+	}
+	return p, nil
+}
+
+// Works out how to remove a package's links, handing the ones another package also ships back to it.
+func PlanUnlink(man *manifest.Manifest) (*Plan, error) {
+	p, err := newPlan(man)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range p.idx.Names(p.Pkg) {
+		p.release(name)
+	}
+	return p, nil
+}
+
+func newPlan(man *manifest.Manifest) (*Plan, error) {
+	idx, err := bins.Load()
+	if err != nil {
+		return nil, err
+	}
+	p := &Plan{Pkg: bins.Ref(man.Owner, man.Repo), idx: idx}
+
+	// links made before the index existed are added to it
+	for name := range Desired(man) {
+		linkOwner(idx, name)
+	}
+	if active, err := manifest.Read(parmutil.GetInstallDir(man.Owner, man.Repo)); err == nil {
+		for name := range Desired(active) {
+			linkOwner(idx, name)
+		}
+	}
+	return p, nil
+}
+
+// plans dropping the package's claim on a link. If it owns the link, the link is removed,
+// or handed to the next package waiting for it that still ships the executable.
+func (p *Plan) release(name string) {
+	claims := p.idx.Claims[name]
+	if len(claims) == 0 || !bins.SamePackage(claims[0], p.Pkg) {
+		p.dropped = append(p.dropped, name)
+		return
+	}
+
+	ch := Change{Name: name, Path: parmutil.GetBinDir(name), Action: Remove, release: []string{p.Pkg}}
+	ch.Current, _ = sysutil.ReadBinLink(ch.Path)
+	for _, next := range claims[1:] {
+		owner, repo, _ := strings.Cut(next, "/")
+		man, err := manifest.Read(parmutil.GetInstallDir(owner, repo))
+		if err == nil {
+			if target, ok := Desired(man)[name]; ok {
+				ch.Action, ch.Owner, ch.Target = Restore, next, target
+				break
+			}
+		}
+		// uninstalled behind parm's back, or no longer ships it
+		ch.release = append(ch.release, next)
+	}
+	p.Changes = append(p.Changes, ch)
+}
+
+// Applies the plan and records the package's links in the bin index.
+func (p *Plan) Apply() error {
+	mode, err := linkMode()
+	if err != nil {
+		return err
+	}
+	for _, ch := range p.Changes {
+		switch ch.Action {
+		case Blocked:
+			return fmt.Errorf("cannot link %s, it belongs to another package", ch.Name)
+		case Keep, Create, Retarget:
+			if ch.take {
+				p.idx.Take(ch.Name, p.Pkg)
+			} else {
+				p.idx.Claim(ch.Name, p.Pkg)
+			}
+			if ch.Action == Keep {
+				continue
+			}
+			if err := sysutil.LinkBinToPath(mode, ch.Target, ch.Path); err != nil {
+				return err
+			}
+		case Remove, Restore:
+			for _, pkg := range ch.release {
+				p.idx.Release(ch.Name, pkg)
+			}
+			if ch.Action == Restore {
+				if err := sysutil.LinkBinToPath(mode, ch.Target, ch.Path); err != nil {
+					return err
+				}
+			} else if err := removeLink(ch.Path, p.Pkg); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range p.dropped {
+		p.idx.Release(name, p.Pkg)
+	}
+	return p.idx.Save()
+}
+
+// Points a package's links at the version described by man. Nothing changes if any of them conflict.
+func Link(man *manifest.Manifest, take ...string) error {
+	p, err := PlanLink(man, take...)
+	if err != nil {
+		return err
+	}
+	return p.Apply()
+}
+
+// Removes a package's links from parm_bin_path, handing the ones another package also ships back to it.
+func Unlink(man *manifest.Manifest) error {
+	p, err := PlanUnlink(man)
+	if err != nil {
+		return err
+	}
+	return p.Apply()
+}
+
+// compares a link to the executable it should run, and returns what it runs now and what to do about it
+func diff(path, target string, mode sysutil.LinkMode) (string, Action) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return "", Create
+	}
+	current, err := sysutil.ReadBinLink(path)
+	if err != nil {
+		// hardlinks and copies are always replaced, there's no telling what they were made from
+		return "", Retarget
+	}
+	isSymlink := fi.Mode()&os.ModeSymlink != 0
+	if current == target && isSymlink == (mode == sysutil.LinkSymlink) {
+		return current, Keep
+	}
+	return current, Retarget
+}
+
+// removes a link owned by pkg, unless it has been pointed somewhere else behind parm's back
+func removeLink(path, pkg string) error {
+	_, err := sysutil.ReadBinLink(path)
+	if linked, ok := linkedPackage(path); ok && bins.SamePackage(linked, pkg) || errors.Is(err, sysutil.ErrUnknownTarget) {
+		return sysutil.RemoveBinLink(path)
+	}
+	return nil
+}
+
+// Works out who a link belongs to. Links parm made before it kept an index are added to it.
+func linkOwner(idx *bins.Index, name string) (owner string, exists bool) {
+	if owner := idx.Owner(name); owner != "" {
+		return owner, true
+	}
+	path := parmutil.GetBinDir(name)
+	if _, err := os.Lstat(path); err != nil {
+		return "", false
+	}
+	if pkg, ok := linkedPackage(path); ok {
+		idx.Claim(name, pkg)
+		return pkg, true
+	}
+	return "", true
+}
+
+// returns the package a link points into, if parm made it
+func linkedPackage(path string) (string, bool) {
+	target, err := sysutil.ReadBinLink(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(config.Cfg.ParmPkgPath, target)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts) < 3 {
+		return "", false
+	}
+	return bins.Ref(parts[0], parts[1]), true
+}
+
+// returns the configured link mode
+func linkMode() (sysutil.LinkMode, error) {
+	if config.Cfg.LinkMode == "" {
+		return sysutil.DefaultLinkMode(runtime.GOOS), nil
+	}
+	return sysutil.ParseLinkMode(config.Cfg.LinkMode)
+}
+
+func sortedNames(links map[string]string) []string {
+	names := make([]string, 0, len(links))
+	for name := range links {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
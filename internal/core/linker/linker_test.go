@@ -0,0 +1,328 @@
+package linker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"parm/internal/config"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"parm/internal/testutil"
+	"parm/pkg/sysutil"
+)
+
+func setupBins(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping Linux-specific test")
+	}
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = filepath.Join(tmpDir, "pkg")
+	config.Cfg.ParmBinPath = filepath.Join(tmpDir, "bin")
+	os.MkdirAll(config.Cfg.ParmBinPath, 0755)
+}
+
+func TestLink_BinConflict(t *testing.T) {
+	setupBins(t)
+
+	fd := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd")
+	if err := link(fd); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	other := testutil.InstallPkgVersion(t, "other", "fd", "v1.0.0", "fd", "other")
+
+	var conflict *ConflictError
+	if err := link(other); !errors.As(err, &conflict) {
+		t.Fatalf("Link() error = %v, want a *ConflictError", err)
+	}
+	if len(conflict.Conflicts) != 1 || conflict.Conflicts[0] != (Conflict{Name: "fd", Owner: "sharkdp/fd"}) {
+		t.Errorf("Link() conflicts = %v, want fd owned by sharkdp/fd", conflict.Conflicts)
+	}
+	// nothing changes on a conflict
+	testutil.AssertLink(t, "fd", parmutil.GetVersionDir("sharkdp", "fd", "v1.0.0"))
+	if _, err := os.Lstat(parmutil.GetBinDir("other")); !os.IsNotExist(err) {
+		t.Error("Link() linked executables of a conflicting package")
+	}
+}
+
+func TestLink_BinUnmanaged(t *testing.T) {
+	setupBins(t)
+
+	os.WriteFile(parmutil.GetBinDir("fd"), []byte("#!/bin/sh\n"), 0755)
+	fd := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd")
+
+	var conflict *ConflictError
+	if err := link(fd); !errors.As(err, &conflict) {
+		t.Fatalf("Link() error = %v, want a *ConflictError", err)
+	}
+	if conflict.Conflicts[0].Owner != "" {
+		t.Errorf("Link() conflict owner = %q, want none", conflict.Conflicts[0].Owner)
+	}
+}
+
+func TestLink_BinAlias(t *testing.T) {
+	setupBins(t)
+
+	fd := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd")
+	if err := link(fd); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	other := testutil.InstallPkgVersion(t, "other", "fd", "v1.0.0", "fd")
+	other.Renames = map[string]string{"fd": "fdfind"}
+	if err := link(other); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+
+	testutil.AssertLink(t, "fd", parmutil.GetVersionDir("sharkdp", "fd", "v1.0.0"))
+	testutil.AssertLink(t, "fdfind", parmutil.GetVersionDir("other", "fd", "v1.0.0"))
+}
+
+func TestLink_TakeBin(t *testing.T) {
+	setupBins(t)
+
+	fd := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd")
+	if err := link(fd); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	other := testutil.InstallPkgVersion(t, "other", "fd", "v1.0.0", "fd")
+	if err := link(other, "fd"); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	testutil.AssertLink(t, "fd", parmutil.GetVersionDir("other", "fd", "v1.0.0"))
+
+	// updating the shadowed package doesn't take the link back
+	fd2 := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v2.0.0", "fd")
+	if err := link(fd2); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	testutil.AssertLink(t, "fd", parmutil.GetVersionDir("other", "fd", "v1.0.0"))
+
+	// removing the owner hands the link back
+	if err := Unlink(other); err != nil {
+		t.Fatalf("Unlink() error: %v", err)
+	}
+	testutil.AssertLink(t, "fd", parmutil.GetVersionDir("sharkdp", "fd", "v2.0.0"))
+
+	if err := Unlink(fd2); err != nil {
+		t.Fatalf("Unlink() error: %v", err)
+	}
+	if _, err := os.Lstat(parmutil.GetBinDir("fd")); !os.IsNotExist(err) {
+		t.Error("Unlink() left the link of the last package shipping it")
+	}
+}
+
+func TestLink_AdoptsLegacyLinks(t *testing.T) {
+	setupBins(t)
+
+	// linked by a parm version without the index
+	fd := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd")
+	os.Symlink(filepath.Join(parmutil.GetVersionDir("sharkdp", "fd", "v1.0.0"), "fd"), parmutil.GetBinDir("fd"))
+
+	if err := link(fd); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	other := testutil.InstallPkgVersion(t, "other", "fd", "v1.0.0", "fd")
+	var conflict *ConflictError
+	if err := link(other); !errors.As(err, &conflict) || conflict.Conflicts[0].Owner != "sharkdp/fd" {
+		t.Errorf("Link() error = %v, want fd owned by sharkdp/fd", err)
+	}
+}
+
+func TestLink_SelectedBins(t *testing.T) {
+	setupBins(t)
+
+	tool := testutil.InstallPkgVersion(t, "owner", "tool", "v1.0.0", "tool-linux-amd64", "helper")
+	tool.Bins = []string{"tool-linux-amd64"}
+	tool.Renames = map[string]string{"tool-linux-amd64": "tool"}
+	if err := link(tool); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	testutil.AssertLink(t, "tool", parmutil.GetVersionDir("owner", "tool", "v1.0.0"))
+	for _, name := range []string{"helper", "tool-linux-amd64"} {
+		if _, err := os.Lstat(parmutil.GetBinDir(name)); !os.IsNotExist(err) {
+			t.Errorf("Link() linked %s, which wasn't selected", name)
+		}
+	}
+
+	// a release that no longer ships a selected executable isn't activated
+	v2 := testutil.InstallPkgVersion(t, "owner", "tool", "v2.0.0", "tool")
+	v2.Bins = tool.Bins
+	if err := link(v2); err == nil || !strings.Contains(err.Error(), "no executable tool-linux-amd64") {
+		t.Errorf("Link() error = %v, want the missing executable", err)
+	}
+	testutil.AssertLink(t, "tool", parmutil.GetVersionDir("owner", "tool", "v1.0.0"))
+}
+
+func TestLink_BareExecutable(t *testing.T) {
+	setupBins(t)
+
+	// "jq-linux-amd64" installed as "jq", selected and renamed by its name in the release
+	bare := func(version string) *manifest.Manifest {
+		man := testutil.InstallPkgVersion(t, "jqlang", "jq", version, "jq")
+		man.Asset = &manifest.Asset{Name: "jq-linux-amd64", Executable: "jq-linux-amd64"}
+		man.Bins = []string{"jq-linux-amd64"}
+		man.Renames = map[string]string{"jq-linux-amd64": "jq1"}
+		return man
+	}
+	if err := link(bare("v1.0.0")); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	testutil.AssertLink(t, "jq1", parmutil.GetVersionDir("jqlang", "jq", "v1.0.0"))
+
+	// updates keep matching the selection
+	if err := link(bare("v2.0.0")); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	testutil.AssertLink(t, "jq1", parmutil.GetVersionDir("jqlang", "jq", "v2.0.0"))
+	if _, err := os.Lstat(parmutil.GetBinDir("jq")); !os.IsNotExist(err) {
+		t.Error("Link() linked jq under its own name despite the rename")
+	}
+}
+
+func TestLink_LinkModes(t *testing.T) {
+	for _, mode := range []sysutil.LinkMode{sysutil.LinkHardlink, sysutil.LinkCopy, sysutil.LinkShim} {
+		t.Run(string(mode), func(t *testing.T) {
+			setupBins(t)
+			config.Cfg.LinkMode = string(mode)
+			defer func() { config.Cfg.LinkMode = "" }()
+			launcher := filepath.Join(t.TempDir(), "parm-shim")
+			os.WriteFile(launcher, append([]byte{0x7f, 0x45, 0x4c, 0x46}, make([]byte, 60)...), 0755)
+			orig := sysutil.ShimLauncher
+			sysutil.ShimLauncher = func() (string, error) { return launcher, nil }
+			defer func() { sysutil.ShimLauncher = orig }()
+
+			fd := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd")
+			other := testutil.InstallPkgVersion(t, "other", "fd", "v1.0.0", "fd")
+			if err := link(fd); err != nil {
+				t.Fatalf("Link() error: %v", err)
+			}
+			if err := link(other, "fd"); err != nil {
+				t.Fatalf("Link() error: %v", err)
+			}
+			if err := Unlink(other); err != nil {
+				t.Fatalf("Unlink() error: %v", err)
+			}
+			if target, err := sysutil.ReadBinLink(parmutil.GetBinDir("fd")); mode == sysutil.LinkShim && filepath.Dir(target) != parmutil.GetVersionDir("sharkdp", "fd", "v1.0.0") {
+				t.Errorf("shim points to %q, %v, want sharkdp/fd", target, err)
+			}
+
+			if err := Unlink(fd); err != nil {
+				t.Fatalf("Unlink() error: %v", err)
+			}
+			entries, _ := os.ReadDir(config.Cfg.ParmBinPath)
+			if len(entries) != 0 {
+				t.Errorf("Unlink() left %d files in the bin dir", len(entries))
+			}
+		})
+	}
+}
+
+func TestPlanLink(t *testing.T) {
+	setupBins(t)
+
+	v1 := testutil.InstallPkgVersion(t, "owner", "tool", "v1.0.0", "tool", "old")
+	if err := link(v1); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	fd := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd")
+	if err := link(fd); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+
+	plan, err := PlanLink(v1)
+	if err != nil {
+		t.Fatalf("PlanLink() error: %v", err)
+	}
+	for _, ch := range plan.Changes {
+		if ch.Action != Keep {
+			t.Errorf("PlanLink() of the linked version: %s = %q, want %q", ch.Name, ch.Action, Keep)
+		}
+	}
+
+	v2 := testutil.InstallPkgVersion(t, "owner", "tool", "v2.0.0", "tool", "new", "fd")
+	plan, err = PlanLink(v2)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("PlanLink() error = %v, want a *ConflictError", err)
+	}
+	got := make(map[string]Action)
+	for _, ch := range plan.Changes {
+		got[ch.Name] = ch.Action
+	}
+	want := map[string]Action{
+		"tool": Retarget,
+		"new":  Create,
+		"fd":   Blocked,
+		"old":  Remove,
+	}
+	for name, action := range want {
+		if got[name] != action {
+			t.Errorf("PlanLink() %s = %q, want %q", name, got[name], action)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("PlanLink() changes = %v, want %v", got, want)
+	}
+
+	// planning changes nothing
+	testutil.AssertLink(t, "tool", parmutil.GetVersionDir("owner", "tool", "v1.0.0"))
+	testutil.AssertLink(t, "old", parmutil.GetVersionDir("owner", "tool", "v1.0.0"))
+	if _, err := os.Lstat(parmutil.GetBinDir("new")); !os.IsNotExist(err) {
+		t.Error("PlanLink() created a link")
+	}
+	if err := plan.Apply(); err == nil {
+		t.Error("Apply() of a conflicting plan should fail")
+	}
+}
+
+func TestPlanUnlink(t *testing.T) {
+	setupBins(t)
+
+	fd := testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd", "fd-helper")
+	other := testutil.InstallPkgVersion(t, "other", "fd", "v1.0.0", "fd")
+	if err := link(fd); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+	if err := link(other, "fd"); err != nil {
+		t.Fatalf("Link() error: %v", err)
+	}
+
+	plan, err := PlanUnlink(other)
+	if err != nil {
+		t.Fatalf("PlanUnlink() error: %v", err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Action != Restore || plan.Changes[0].Owner != "sharkdp/fd" {
+		t.Fatalf("PlanUnlink() = %+v, want fd restored to sharkdp/fd", plan.Changes)
+	}
+
+	plan, err = PlanUnlink(fd)
+	if err != nil {
+		t.Fatalf("PlanUnlink() error: %v", err)
+	}
+	// fd belongs to other/fd, so only the helper goes
+	if len(plan.Changes) != 1 || plan.Changes[0].Name != "fd-helper" || plan.Changes[0].Action != Remove {
+		t.Fatalf("PlanUnlink() = %+v, want fd-helper removed", plan.Changes)
+	}
+	if err := Unlink(fd); err != nil {
+		t.Fatalf("Unlink() error: %v", err)
+	}
+	testutil.AssertLink(t, "fd", parmutil.GetVersionDir("other", "fd", "v1.0.0"))
+	if err := Unlink(other); err != nil {
+		t.Fatalf("Unlink() error: %v", err)
+	}
+	if _, err := os.Lstat(parmutil.GetBinDir("fd")); !os.IsNotExist(err) {
+		t.Error("Unlink() handed fd back to a package that no longer wants it")
+	}
+}
+
+// links a version and makes it the active one, like the switcher does
+func link(man *manifest.Manifest, take ...string) error {
+	if err := Link(man, take...); err != nil {
+		return err
+	}
+	return parmutil.SetCurrentVersion(man.Owner, man.Repo, man.Version)
+}
@@ -0,0 +1,51 @@
+package switcher
+
+import (
+	"fmt"
+	"os"
+	"parm/internal/core/linker"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"path/filepath"
+)
+
+// Switches the active version of a package to an already installed version.
+func UseVersion(owner, repo, version string) (*manifest.Manifest, error) {
+	man, err := manifest.Read(parmutil.GetVersionDir(owner, repo, version))
+	if err != nil {
+		return nil, fmt.Errorf("version %s of %s/%s is not installed: \n%w", version, owner, repo, err)
+	}
+	if err := Activate(man); err != nil {
+		return nil, err
+	}
+	return man, nil
+}
+
+// Makes the version described by man the active version of its package,
+// and points the package's links in parm_bin_path at it. take lists links the package
+// may take over from other packages; without it, links owned by another package are a *linker.ConflictError.
+func Activate(man *manifest.Manifest, take ...string) error {
+	// planned before anything changes, so a refused version leaves parm_bin_path as it was
+	plan, err := linker.PlanLink(man, take...)
+	if err != nil {
+		return err
+	}
+	if err := parmutil.SetCurrentVersion(man.Owner, man.Repo, man.Version); err != nil {
+		return fmt.Errorf("cannot set active version: \n%w", err)
+	}
+	return plan.Apply()
+}
+
+// Removes the links of a version and clears the active version of its package if it was that version.
+func Deactivate(man *manifest.Manifest) error {
+	if err := linker.Unlink(man); err != nil {
+		return err
+	}
+	if curr, err := parmutil.GetCurrentVersion(man.Owner, man.Repo); err == nil && curr == man.Version {
+		path := filepath.Join(parmutil.GetPkgDir(man.Owner, man.Repo), parmutil.CURRENT_VERSION_FILE)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package switcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"parm/internal/config"
+	"parm/internal/core/linker"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"parm/internal/testutil"
+)
+
+func TestUseVersion(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping Linux-specific test")
+	}
+
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = filepath.Join(tmpDir, "pkg")
+	config.Cfg.ParmBinPath = filepath.Join(tmpDir, "bin")
+	os.MkdirAll(config.Cfg.ParmBinPath, 0755)
+
+	v1 := installVersion(t, "v1.0.0", "tool", "old")
+	v2 := installVersion(t, "v2.0.0", "tool", "new")
+
+	if err := Activate(v1); err != nil {
+		t.Fatalf("Activate() error: %v", err)
+	}
+	testutil.AssertLink(t, "tool", parmutil.GetVersionDir("owner", "repo", "v1.0.0"))
+
+	man, err := UseVersion("owner", "repo", "v2.0.0")
+	if err != nil {
+		t.Fatalf("UseVersion() error: %v", err)
+	}
+	if man.Version != v2.Version {
+		t.Errorf("UseVersion() = %v, want %v", man.Version, v2.Version)
+	}
+	testutil.AssertLink(t, "tool", parmutil.GetVersionDir("owner", "repo", "v2.0.0"))
+
+	// binaries only shipped by the old version are unlinked
+	if _, err := os.Lstat(parmutil.GetBinDir("old")); !os.IsNotExist(err) {
+		t.Error("link to a binary of the previous version still exists")
+	}
+	testutil.AssertLink(t, "new", parmutil.GetVersionDir("owner", "repo", "v2.0.0"))
+
+	if cur, _ := parmutil.GetCurrentVersion("owner", "repo"); cur != "v2.0.0" {
+		t.Errorf("current version = %v, want v2.0.0", cur)
+	}
+}
+
+func TestUseVersion_NotInstalled(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	if _, err := UseVersion("owner", "repo", "v9.9.9"); err == nil {
+		t.Error("UseVersion() should fail for a version that is not installed")
+	}
+}
+
+func installVersion(t *testing.T, version string, bins ...string) *manifest.Manifest {
+	t.Helper()
+	return testutil.InstallPkgVersion(t, "owner", "repo", version, bins...)
+}
+
+func TestActivate_Conflict(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping Linux-specific test")
+	}
+
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = filepath.Join(tmpDir, "pkg")
+	config.Cfg.ParmBinPath = filepath.Join(tmpDir, "bin")
+	os.MkdirAll(config.Cfg.ParmBinPath, 0755)
+
+	if err := Activate(testutil.InstallPkgVersion(t, "sharkdp", "fd", "v1.0.0", "fd")); err != nil {
+		t.Fatalf("Activate() error: %v", err)
+	}
+
+	var conflict *linker.ConflictError
+	if err := Activate(testutil.InstallPkgVersion(t, "other", "fd", "v1.0.0", "fd")); !errors.As(err, &conflict) {
+		t.Fatalf("Activate() error = %v, want a *linker.ConflictError", err)
+	}
+	if _, err := parmutil.GetCurrentVersion("other", "fd"); err == nil {
+		t.Error("Activate() set the active version of a conflicting package")
+	}
+}
@@ -0,0 +1,116 @@
+package syncer
+
+import (
+	"fmt"
+	"parm/internal/manifest"
+	"parm/internal/parmfile"
+	"slices"
+	"strings"
+)
+
+type ActionKind string
+
+const (
+	// package is declared but not installed
+	ActionInstall ActionKind = "install"
+	// installed package is on a different version or asset than the one declared
+	ActionReinstall ActionKind = "reinstall"
+	// only the channel or pinned status differs, no download needed
+	ActionConfigure ActionKind = "configure"
+	// package is installed but not declared
+	ActionRemove ActionKind = "remove"
+)
+
+type Action struct {
+	Kind  ActionKind
+	Owner string
+	Repo  string
+	// nil when Kind is ActionRemove
+	Package *parmfile.Package
+	// nil when Kind is ActionInstall
+	Installed *manifest.Manifest
+}
+
+func (a Action) String() string {
+	switch a.Kind {
+	case ActionInstall:
+		return fmt.Sprintf("install %s/%s%s", a.Owner, a.Repo, versionSuffix(a.Package.Version))
+	case ActionReinstall:
+		return fmt.Sprintf("reinstall %s/%s: %s -> %s", a.Owner, a.Repo, a.Installed.Version, a.Package.Version)
+	case ActionConfigure:
+		return fmt.Sprintf("configure %s/%s (channel: %s, pinned: %t)", a.Owner, a.Repo, a.Package.Channel, a.Package.Pinned)
+	case ActionRemove:
+		return fmt.Sprintf("remove %s/%s", a.Owner, a.Repo)
+	}
+	return string(a.Kind)
+}
+
+// Plan diffs the declared packages against the installed ones and returns
+// the actions needed to make the machine match the parmfile.
+// A package declared without a version matches any installed version, so it's never updated.
+// Installs and reinstalls come first, removals last.
+func Plan(pf *parmfile.Parmfile, installed []*manifest.Manifest) ([]Action, error) {
+	byName := make(map[string]*manifest.Manifest, len(installed))
+	for _, man := range installed {
+		byName[key(man.Owner, man.Repo)] = man
+	}
+
+	var actions []Action
+	declared := make(map[string]bool, len(pf.Packages))
+	for i := range pf.Packages {
+		pkg := &pf.Packages[i]
+		owner, repo, err := pkg.OwnerRepo()
+		if err != nil {
+			return nil, err
+		}
+		k := key(owner, repo)
+		declared[k] = true
+
+		man, ok := byName[k]
+		if !ok {
+			actions = append(actions, Action{Kind: ActionInstall, Owner: owner, Repo: repo, Package: pkg})
+			continue
+		}
+
+		if (pkg.Version != "" && pkg.Version != man.Version) || assetChanged(pkg, man) {
+			actions = append(actions, Action{Kind: ActionReinstall, Owner: owner, Repo: repo, Package: pkg, Installed: man})
+			continue
+		}
+
+		if pkg.Channel != man.InstallType || pkg.Pinned != man.Pinned {
+			actions = append(actions, Action{Kind: ActionConfigure, Owner: owner, Repo: repo, Package: pkg, Installed: man})
+		}
+	}
+
+	var removals []Action
+	for _, man := range installed {
+		if declared[key(man.Owner, man.Repo)] {
+			continue
+		}
+		removals = append(removals, Action{Kind: ActionRemove, Owner: man.Owner, Repo: man.Repo, Installed: man})
+	}
+	slices.SortFunc(removals, func(a, b Action) int {
+		return strings.Compare(key(a.Owner, a.Repo), key(b.Owner, b.Repo))
+	})
+
+	return append(actions, removals...), nil
+}
+
+func assetChanged(pkg *parmfile.Package, man *manifest.Manifest) bool {
+	if pkg.Asset == "" || man.Asset == nil {
+		return false
+	}
+	return pkg.Asset != man.Asset.Name
+}
+
+// GitHub owner and repo names are case-insensitive
+func key(owner, repo string) string {
+	return strings.ToLower(owner + "/" + repo)
+}
+
+func versionSuffix(ver string) string {
+	if ver == "" {
+		return ""
+	}
+	return "@" + ver
+}
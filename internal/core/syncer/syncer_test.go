@@ -0,0 +1,92 @@
+package syncer
+
+import (
+	"testing"
+
+	"parm/internal/manifest"
+	"parm/internal/parmfile"
+)
+
+func TestPlan(t *testing.T) {
+	pf := &parmfile.Parmfile{
+		Packages: []parmfile.Package{
+			{Name: "owner/new", Channel: manifest.Release},
+			{Name: "owner/stale", Channel: manifest.Release, Version: "v2.0.0"},
+			{Name: "Owner/Current", Channel: manifest.Release, Version: "v1.0.0"},
+			{Name: "owner/pinme", Channel: manifest.Release, Version: "v1.0.0", Pinned: true},
+			{Name: "owner/floating", Channel: manifest.Release},
+		},
+	}
+	installed := []*manifest.Manifest{
+		{Owner: "owner", Repo: "stale", Version: "v1.0.0", InstallType: manifest.Release},
+		{Owner: "owner", Repo: "current", Version: "v1.0.0", InstallType: manifest.Release},
+		{Owner: "owner", Repo: "pinme", Version: "v1.0.0", InstallType: manifest.Release},
+		// no version declared, any installed one will do
+		{Owner: "owner", Repo: "floating", Version: "v0.1.0", InstallType: manifest.Release},
+		{Owner: "owner", Repo: "zextra", Version: "v1.0.0", InstallType: manifest.Release},
+		{Owner: "owner", Repo: "extra", Version: "v1.0.0", InstallType: manifest.PreRelease},
+	}
+
+	actions, err := Plan(pf, installed)
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+
+	want := []struct {
+		kind ActionKind
+		repo string
+	}{
+		{ActionInstall, "new"},
+		{ActionReinstall, "stale"},
+		{ActionConfigure, "pinme"},
+		{ActionRemove, "extra"},
+		{ActionRemove, "zextra"},
+	}
+
+	if len(actions) != len(want) {
+		t.Fatalf("Plan() returned %d actions, want %d: %v", len(actions), len(want), actions)
+	}
+	for i, w := range want {
+		if actions[i].Kind != w.kind || actions[i].Repo != w.repo {
+			t.Errorf("actions[%d] = %s %s, want %s %s", i, actions[i].Kind, actions[i].Repo, w.kind, w.repo)
+		}
+	}
+}
+
+func TestPlan_ChannelChange(t *testing.T) {
+	pf := &parmfile.Parmfile{
+		Packages: []parmfile.Package{
+			{Name: "owner/repo", Channel: manifest.PreRelease},
+		},
+	}
+	installed := []*manifest.Manifest{
+		{Owner: "owner", Repo: "repo", Version: "v1.0.0", InstallType: manifest.Release},
+	}
+
+	actions, err := Plan(pf, installed)
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Kind != ActionConfigure {
+		t.Fatalf("Plan() = %v, want a single configure action", actions)
+	}
+}
+
+func TestPlan_InSync(t *testing.T) {
+	pf := &parmfile.Parmfile{
+		Packages: []parmfile.Package{
+			{Name: "owner/repo", Channel: manifest.Release, Version: "v1.0.0"},
+		},
+	}
+	installed := []*manifest.Manifest{
+		{Owner: "owner", Repo: "repo", Version: "v1.0.0", InstallType: manifest.Release},
+	}
+
+	actions, err := Plan(pf, installed)
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Errorf("Plan() = %v, want no actions", actions)
+	}
+}
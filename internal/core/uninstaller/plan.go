@@ -0,0 +1,77 @@
+package uninstaller
+
+import (
+	"fmt"
+	"os"
+	"parm/internal/core/linker"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"slices"
+)
+
+// What removing a package, or one of its versions, would do.
+type Plan struct {
+	Owner string
+	Repo  string
+	// dirs that would be deleted
+	Dirs []string
+	// links in parm_bin_path that would be deleted
+	Links []string
+	// links in parm_bin_path that would be handed back to another package shipping the same executable,
+	// keyed by link name
+	Restores map[string]string
+}
+
+// Works out what Uninstall would remove, without changing anything.
+func PlanUninstall(owner, repo string) (*Plan, error) {
+	man, err := manifest.Read(parmutil.GetInstallDir(owner, repo))
+	if err != nil {
+		return nil, fmt.Errorf("could not read manifest: \n%w", err)
+	}
+
+	plan := &Plan{
+		Owner: owner,
+		Repo:  repo,
+		Dirs:  []string{parmutil.GetPkgDir(owner, repo)},
+	}
+	links, err := linker.PlanUnlink(man)
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range links.Changes {
+		switch ch.Action {
+		case linker.Restore:
+			if plan.Restores == nil {
+				plan.Restores = make(map[string]string)
+			}
+			plan.Restores[ch.Name] = ch.Owner
+		case linker.Remove:
+			if _, err := os.Lstat(ch.Path); err == nil {
+				plan.Links = append(plan.Links, ch.Path)
+			}
+		}
+	}
+	slices.Sort(plan.Links)
+	return plan, nil
+}
+
+// Works out what RemoveVersion would remove, without changing anything.
+func PlanRemoveVersion(owner, repo, version string) (*Plan, error) {
+	dir := parmutil.GetVersionDir(owner, repo, version)
+	if _, err := manifest.Read(dir); err != nil {
+		return nil, fmt.Errorf("version %s of %s/%s is not installed: \n%w", version, owner, repo, err)
+	}
+
+	current, _ := parmutil.GetCurrentVersion(owner, repo)
+	if current == version {
+		versions, err := parmutil.ListVersionDirs(owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) > 1 {
+			return nil, fmt.Errorf("%s is the active version of %s/%s, switch to another version with \"parm use\" first", version, owner, repo)
+		}
+		return PlanUninstall(owner, repo)
+	}
+	return &Plan{Owner: owner, Repo: repo, Dirs: []string{dir}}, nil
+}
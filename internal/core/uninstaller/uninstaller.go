@@ -1,19 +1,19 @@
 package uninstaller
 
 import (
-	"context"
 	"fmt"
 	"os"
-	"parm/internal/config"
+	"parm/internal/core/linker"
 	"parm/internal/manifest"
 	"parm/internal/parmutil"
 	"parm/pkg/sysutil"
 	"path/filepath"
 )
 
-// TODO: when version management is added?, have an option to remove a specific version
+// Removes every installed version of a package.
 // remove concurrently?
-func Uninstall(ctx context.Context, owner, repo string) error {
+func Uninstall(owner, repo string) error {
+	pkgDir := parmutil.GetPkgDir(owner, repo)
 	dir := parmutil.GetInstallDir(owner, repo)
 	fi, err := os.Stat(dir)
 	if err != nil {
@@ -23,61 +23,102 @@ func Uninstall(ctx context.Context, owner, repo string) error {
 		return fmt.Errorf("selected item is not a dir: \n%w", err)
 	}
 
-	manifest, err := manifest.Read(dir)
-	if err != nil {
+	if _, err := manifest.Read(dir); err != nil {
 		return fmt.Errorf("could not read manifest: \n%w", err)
 	}
 
-	var execPaths []string
-	for _, path := range manifest.Executables {
-		fullPath := filepath.Join(dir, path)
-		execPaths = append(execPaths, fullPath)
+	versions, _ := parmutil.ListVersionDirs(owner, repo)
+	for _, ver := range versions {
+		if err := EnsureNotRunning(filepath.Join(pkgDir, ver)); err != nil {
+			return err
+		}
+	}
+	// pre-versioned installs keep their manifest in the package dir itself
+	if err := EnsureNotRunning(pkgDir); err != nil {
+		return err
 	}
 
-	for _, path := range execPaths {
-		isRunning, err := sysutil.IsProcessRunning(path)
+	if err = os.RemoveAll(pkgDir); err != nil {
+		return fmt.Errorf("cannot remove dir: %s: \n%w", pkgDir, err)
+	}
+
+	removeIfEmpty(pkgDir)
+	return nil
+}
+
+// Removes a single installed version of a package. The active version can only be removed if it's the only one left,
+// in which case the package is uninstalled entirely.
+func RemoveVersion(owner, repo, version string) error {
+	dir := parmutil.GetVersionDir(owner, repo, version)
+	if _, err := manifest.Read(dir); err != nil {
+		return fmt.Errorf("version %s of %s/%s is not installed: \n%w", version, owner, repo, err)
+	}
+
+	current, _ := parmutil.GetCurrentVersion(owner, repo)
+	if current == version {
+		versions, err := parmutil.ListVersionDirs(owner, repo)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Could not check for process: %s: %v\n", path, err)
-			continue
+			return err
 		}
-		if isRunning {
-			return fmt.Errorf("cannot uninstall process %s because it is currently running", filepath.Base(path))
+		if len(versions) > 1 {
+			return fmt.Errorf("%s is the active version of %s/%s, switch to another version with \"parm use\" first", version, owner, repo)
+		}
+		if err := RemovePkgSymlinks(owner, repo); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not remove symlinks for %s/%s: %v\n", owner, repo, err)
 		}
+		return Uninstall(owner, repo)
 	}
 
-	if err = os.RemoveAll(dir); err != nil {
+	if err := EnsureNotRunning(dir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
 		return fmt.Errorf("cannot remove dir: %s: \n%w", dir, err)
 	}
+	return nil
+}
 
+// Returns an error if any executable of the install in dir is currently running.
+// Does nothing if dir doesn't hold an install.
+func EnsureNotRunning(dir string) error {
+	man, err := manifest.Read(dir)
+	if err != nil {
+		return nil
+	}
+
+	for _, path := range man.Executables {
+		fullPath := filepath.Join(dir, path)
+		isRunning, err := sysutil.IsProcessRunning(fullPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not check for process: %s: %v\n", fullPath, err)
+			continue
+		}
+		if isRunning {
+			return fmt.Errorf("cannot replace or remove %s because it is currently running", filepath.Base(fullPath))
+		}
+	}
+	return nil
+}
+
+func removeIfEmpty(dir string) {
 	parentDir, err := sysutil.GetParentDir(dir)
 	// NOTE: don't want to error out here if it fails
 	if err != nil {
-		return nil
+		return
 	}
 
 	entries, err := os.ReadDir(parentDir)
 	if err == nil && len(entries) == 0 {
 		_ = os.Remove(parentDir)
-		return nil
 	}
-
-	return nil
 }
 
-func RemovePkgSymlinks(ctx context.Context, owner, repo string) error {
+// Removes the links of a package from parm_bin_path. Links another installed package also
+// ships are pointed at that package's executable instead.
+func RemovePkgSymlinks(owner, repo string) error {
 	man, err := manifest.Read(parmutil.GetInstallDir(owner, repo))
 	if err != nil {
 		return err
 	}
-	exDirs := man.GetFullExecPaths()
-
-	for _, dir := range exDirs {
-		binPath := filepath.Join(config.Cfg.ParmBinPath, filepath.Base(dir))
-		if _, err := os.Lstat(binPath); err != nil {
-			return err
-		}
-		_ = os.Remove(binPath) // continue if there's an error
-	}
-
-	return nil
+	return linker.Unlink(man)
 }
@@ -1,13 +1,13 @@
 package uninstaller
 
 import (
-	"context"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"parm/internal/config"
 	"parm/internal/manifest"
+	"parm/internal/parmutil"
 )
 
 func TestUninstall_Success(t *testing.T) {
@@ -33,8 +33,7 @@ func TestUninstall_Success(t *testing.T) {
 	testFile := filepath.Join(pkgDir, "test.txt")
 	os.WriteFile(testFile, []byte("test"), 0644)
 
-	ctx := context.Background()
-	err := Uninstall(ctx, "owner", "repo")
+	err := Uninstall("owner", "repo")
 	if err != nil {
 		t.Fatalf("Uninstall() error: %v", err)
 	}
@@ -49,8 +48,7 @@ func TestUninstall_NonExistent(t *testing.T) {
 	tmpDir := t.TempDir()
 	config.Cfg.ParmPkgPath = tmpDir
 
-	ctx := context.Background()
-	err := Uninstall(ctx, "owner", "nonexistent")
+	err := Uninstall("owner", "nonexistent")
 	if err == nil {
 		t.Error("Uninstall() should return error for non-existent package")
 	}
@@ -64,8 +62,7 @@ func TestUninstall_NoManifest(t *testing.T) {
 	pkgDir := filepath.Join(tmpDir, "owner", "repo")
 	os.MkdirAll(pkgDir, 0755)
 
-	ctx := context.Background()
-	err := Uninstall(ctx, "owner", "repo")
+	err := Uninstall("owner", "repo")
 	if err == nil {
 		t.Error("Uninstall() should return error when manifest is missing")
 	}
@@ -95,8 +92,7 @@ func TestUninstall_WithExecutables(t *testing.T) {
 	}
 	m.Write(pkgDir)
 
-	ctx := context.Background()
-	err := Uninstall(ctx, "owner", "repo")
+	err := Uninstall("owner", "repo")
 	if err != nil {
 		t.Fatalf("Uninstall() error: %v", err)
 	}
@@ -122,8 +118,7 @@ func TestRemovePkgSymlinks_Success(t *testing.T) {
 		t.Skipf("Cannot create symlink: %v", err)
 	}
 
-	ctx := context.Background()
-	err = RemovePkgSymlinks(ctx, "owner", "repo")
+	err = RemovePkgSymlinks("owner", "repo")
 	if err != nil {
 		t.Logf("RemovePkgSymlinks() error: %v", err)
 	}
@@ -138,8 +133,7 @@ func TestRemovePkgSymlinks_NonExistent(t *testing.T) {
 	tmpDir := t.TempDir()
 	config.Cfg.ParmBinPath = tmpDir
 
-	ctx := context.Background()
-	err := RemovePkgSymlinks(ctx, "owner", "nonexistent")
+	err := RemovePkgSymlinks("owner", "nonexistent")
 	// Should not error on non-existent symlink
 	if err != nil {
 		t.Logf("RemovePkgSymlinks() returned error (acceptable): %v", err)
@@ -165,8 +159,7 @@ func TestUninstall_CleansUpParentDir(t *testing.T) {
 	}
 	m.Write(pkgDir)
 
-	ctx := context.Background()
-	err := Uninstall(ctx, "owner", "repo")
+	err := Uninstall("owner", "repo")
 	if err != nil {
 		t.Fatalf("Uninstall() error: %v", err)
 	}
@@ -178,3 +171,97 @@ func TestUninstall_CleansUpParentDir(t *testing.T) {
 		t.Log("Parent directory is empty (will be cleaned up)")
 	}
 }
+
+func TestRemoveVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	for _, ver := range []string{"v1.0.0", "v2.0.0"} {
+		dir := parmutil.GetVersionDir("owner", "repo", ver)
+		os.MkdirAll(dir, 0755)
+		m := &manifest.Manifest{
+			Owner:       "owner",
+			Repo:        "repo",
+			Version:     ver,
+			InstallType: manifest.Release,
+			Executables: []string{},
+		}
+		m.Write(dir)
+	}
+	parmutil.SetCurrentVersion("owner", "repo", "v2.0.0")
+
+	// active version can't be removed while others are installed
+	if err := RemoveVersion("owner", "repo", "v2.0.0"); err == nil {
+		t.Error("RemoveVersion() should refuse to remove the active version")
+	}
+
+	if err := RemoveVersion("owner", "repo", "v1.0.0"); err != nil {
+		t.Fatalf("RemoveVersion() error: %v", err)
+	}
+	if _, err := os.Stat(parmutil.GetVersionDir("owner", "repo", "v1.0.0")); !os.IsNotExist(err) {
+		t.Error("version dir still exists after RemoveVersion()")
+	}
+
+	// last remaining version uninstalls the package
+	if err := RemoveVersion("owner", "repo", "v2.0.0"); err != nil {
+		t.Fatalf("RemoveVersion() error: %v", err)
+	}
+	if _, err := os.Stat(parmutil.GetPkgDir("owner", "repo")); !os.IsNotExist(err) {
+		t.Error("package dir still exists after removing the last version")
+	}
+}
+
+func TestRemoveVersion_NotInstalled(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	if err := RemoveVersion("owner", "repo", "v1.0.0"); err == nil {
+		t.Error("RemoveVersion() should return error for a version that is not installed")
+	}
+}
+
+func TestPlanRemoveVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+	config.Cfg.ParmBinPath = filepath.Join(tmpDir, "bin")
+
+	for _, ver := range []string{"v1.0.0", "v2.0.0"} {
+		dir := parmutil.GetVersionDir("owner", "repo", ver)
+		os.MkdirAll(dir, 0755)
+		m := &manifest.Manifest{
+			Owner:       "owner",
+			Repo:        "repo",
+			Version:     ver,
+			InstallType: manifest.Release,
+			Executables: []string{"repo"},
+		}
+		m.Write(dir)
+	}
+	parmutil.SetCurrentVersion("owner", "repo", "v2.0.0")
+	os.MkdirAll(config.Cfg.ParmBinPath, 0755)
+	binPath := filepath.Join(config.Cfg.ParmBinPath, "repo")
+	os.Symlink(filepath.Join(parmutil.GetVersionDir("owner", "repo", "v2.0.0"), "repo"), binPath)
+
+	if _, err := PlanRemoveVersion("owner", "repo", "v2.0.0"); err == nil {
+		t.Error("PlanRemoveVersion() should refuse to remove the active version")
+	}
+
+	plan, err := PlanRemoveVersion("owner", "repo", "v1.0.0")
+	if err != nil {
+		t.Fatalf("PlanRemoveVersion() error: %v", err)
+	}
+	if len(plan.Dirs) != 1 || plan.Dirs[0] != parmutil.GetVersionDir("owner", "repo", "v1.0.0") || len(plan.Links) != 0 {
+		t.Errorf("PlanRemoveVersion() = %+v, want only the v1.0.0 dir", plan)
+	}
+
+	plan, err = PlanUninstall("owner", "repo")
+	if err != nil {
+		t.Fatalf("PlanUninstall() error: %v", err)
+	}
+	if len(plan.Dirs) != 1 || plan.Dirs[0] != parmutil.GetPkgDir("owner", "repo") || len(plan.Links) != 1 || plan.Links[0] != binPath {
+		t.Errorf("PlanUninstall() = %+v, want the package dir and its link", plan)
+	}
+	if _, err := os.Lstat(binPath); err != nil {
+		t.Error("planning removed the link")
+	}
+}
@@ -0,0 +1,129 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"parm/internal/gh"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"parm/pkg/cmdparser"
+	"parm/pkg/progress"
+	"sync"
+)
+
+type Status string
+
+const (
+	StatusUpdated Status = "updated"
+	StatusSkipped Status = "up to date"
+	StatusPinned  Status = "pinned"
+	StatusFailed  Status = "failed"
+)
+
+type BatchResult struct {
+	Owner  string
+	Repo   string
+	From   string
+	To     string
+	Status Status
+	Err    error
+}
+
+type BatchFlags struct {
+	UpdateFlags
+	// max number of packages updated at once
+	Jobs int
+	// returns the progress hooks for a package, may be nil
+	Hooks func(owner, repo string) *progress.Hooks
+}
+
+// Updates many packages with at most flags.Jobs updates in flight. Release resolution and downloads run in parallel,
+// while commit, which should activate the new version, is called for one finished update at a time.
+// pkgs are given as "owner/repo", and results are returned in the same order.
+func (up *Updater) UpdateAll(ctx context.Context, pkgs []string, flags *BatchFlags, commit func(*UpdateResult) error) []BatchResult {
+	results := make([]BatchResult, len(pkgs))
+	mans := make([]*manifest.Manifest, len(pkgs))
+	var toCheck []*manifest.Manifest
+
+	for i, pkg := range pkgs {
+		results[i] = BatchResult{Owner: pkg, Status: StatusFailed}
+		owner, repo, err := cmdparser.ParseRepoRef(pkg)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Owner, results[i].Repo = owner, repo
+
+		man, err := manifest.Read(parmutil.GetInstallDir(owner, repo))
+		if err != nil {
+			results[i].Err = fmt.Errorf("cannot read manifest file for %s/%s: \n%w", owner, repo, err)
+			continue
+		}
+		results[i].From = man.Version
+
+		if man.Pinned {
+			results[i].Status = StatusPinned
+			continue
+		}
+		mans[i] = man
+		toCheck = append(toCheck, man)
+	}
+
+	prefetched := up.prefetch(ctx, toCheck)
+	var commitMu sync.Mutex
+	forEach(len(pkgs), flags.Jobs, func(i int) {
+		if mans[i] == nil {
+			return
+		}
+		rels := prefetched[gh.RepoRef{Owner: mans[i].Owner, Repo: mans[i].Repo}]
+		up.updateOne(ctx, mans[i], rels, flags, &results[i], func(res *UpdateResult) error {
+			commitMu.Lock()
+			defer commitMu.Unlock()
+			return commit(res)
+		})
+	})
+	return results
+}
+
+// calls fn for every index in [0, n), running at most jobs calls at once
+func forEach(n, jobs int, fn func(i int)) {
+	sem := make(chan struct{}, max(jobs, 1))
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+func (up *Updater) updateOne(ctx context.Context, man *manifest.Manifest, rels *gh.RepoReleases, flags *BatchFlags, res *BatchResult, commit func(*UpdateResult) error) {
+	owner, repo := man.Owner, man.Repo
+
+	var hooks *progress.Hooks
+	if flags.Hooks != nil {
+		hooks = flags.Hooks(owner, repo)
+	}
+
+	upd, err := up.update(ctx, owner, repo, parmutil.GetPkgDir(owner, repo), man, &flags.UpdateFlags, hooks, rels)
+	if errors.Is(err, ErrUpToDate) {
+		res.Status = StatusSkipped
+		return
+	}
+	if err != nil {
+		res.Err = gh.ExplainRateLimit(err)
+		return
+	}
+	res.To = upd.Version
+
+	if err := commit(upd); err != nil {
+		res.Err = err
+		return
+	}
+	res.Status = StatusUpdated
+}
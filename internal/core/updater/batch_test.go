@@ -0,0 +1,156 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"parm/internal/config"
+	"parm/internal/core/installer"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+)
+
+func TestUpdateAll(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	installed := map[string]bool{"outdated": false, "current": false, "pinned": true}
+	for repo, pinned := range installed {
+		dir := parmutil.GetVersionDir("owner", repo, "v1.0.0")
+		os.MkdirAll(dir, 0755)
+		m := &manifest.Manifest{
+			Owner:       "owner",
+			Repo:        repo,
+			Version:     "v1.0.0",
+			InstallType: manifest.Release,
+			VerifyLevel: "none",
+			Pinned:      pinned,
+			Executables: []string{},
+		}
+		m.Write(dir)
+		parmutil.SetCurrentVersion("owner", repo, "v1.0.0")
+	}
+
+	archivePath := createTestArchive(t, tmpDir)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, archivePath)
+	}))
+	defer server.Close()
+
+	assetName := fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	release := func(tag string) *github.RepositoryRelease {
+		return &github.RepositoryRelease{
+			TagName: github.Ptr(tag),
+			Assets:  []*github.ReleaseAsset{{Name: github.Ptr(assetName)}},
+		}
+	}
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposReleasesLatestByOwnerByRepo,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tag := "v1.0.0"
+				if strings.Contains(r.URL.Path, "/outdated/") {
+					tag = "v2.0.0"
+				}
+				w.Write(mock.MustMarshal(release(tag)))
+			}),
+		),
+		mock.WithRequestMatch(
+			mock.GetReposReleasesTagsByOwnerByRepoByTag,
+			release("v2.0.0"),
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, server.URL+"/asset", http.StatusFound)
+			}),
+		),
+	)
+
+	client := github.NewClient(mockedHTTPClient)
+	up := New(client.Repositories, installer.New(client.Repositories))
+
+	var mu sync.Mutex
+	var committed []string
+	pkgs := []string{"owner/outdated", "owner/current", "owner/pinned", "owner/missing"}
+	results := up.UpdateAll(context.Background(), pkgs, &BatchFlags{Jobs: 2}, func(res *UpdateResult) error {
+		mu.Lock()
+		defer mu.Unlock()
+		committed = append(committed, res.OldManifest.Repo+"@"+res.Version)
+		return nil
+	})
+
+	want := []Status{StatusUpdated, StatusSkipped, StatusPinned, StatusFailed}
+	if len(results) != len(want) {
+		t.Fatalf("UpdateAll() returned %d results, want %d", len(results), len(want))
+	}
+	for i, res := range results {
+		if res.Status != want[i] {
+			t.Errorf("%s/%s: status = %v, want %v (err: %v)", res.Owner, res.Repo, res.Status, want[i], res.Err)
+		}
+	}
+	if results[0].From != "v1.0.0" || results[0].To != "v2.0.0" {
+		t.Errorf("outdated: %s -> %s, want v1.0.0 -> v2.0.0", results[0].From, results[0].To)
+	}
+	if results[3].Err == nil {
+		t.Error("missing package should report an error")
+	}
+	if len(committed) != 1 || committed[0] != "outdated@v2.0.0" {
+		t.Errorf("committed = %v, want [outdated@v2.0.0]", committed)
+	}
+	if _, err := os.Stat(filepath.Join(parmutil.GetPkgDir("owner", "outdated"), "v2.0.0")); err != nil {
+		t.Errorf("new version was not installed: %v", err)
+	}
+}
+
+func TestUpdateAll_CommitError(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	dir := parmutil.GetVersionDir("owner", "repo", "v1.0.0")
+	os.MkdirAll(dir, 0755)
+	(&manifest.Manifest{Owner: "owner", Repo: "repo", Version: "v1.0.0", InstallType: manifest.Release, Executables: []string{}}).Write(dir)
+	parmutil.SetCurrentVersion("owner", "repo", "v1.0.0")
+
+	archivePath := createTestArchive(t, tmpDir)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, archivePath)
+	}))
+	defer server.Close()
+
+	rel := &github.RepositoryRelease{
+		TagName: github.Ptr("v2.0.0"),
+		Assets:  []*github.ReleaseAsset{{Name: github.Ptr(fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH))}},
+	}
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(mock.GetReposReleasesLatestByOwnerByRepo, rel),
+		mock.WithRequestMatch(mock.GetReposReleasesTagsByOwnerByRepoByTag, rel),
+		mock.WithRequestMatchHandler(
+			mock.GetReposReleasesAssetsByOwnerByRepoByAssetId,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, server.URL+"/asset", http.StatusFound)
+			}),
+		),
+	)
+
+	client := github.NewClient(mockedHTTPClient)
+	up := New(client.Repositories, installer.New(client.Repositories))
+
+	results := up.UpdateAll(context.Background(), []string{"owner/repo"}, &BatchFlags{Jobs: 1}, func(res *UpdateResult) error {
+		return fmt.Errorf("activation failed")
+	})
+	if results[0].Status != StatusFailed || results[0].Err == nil {
+		t.Errorf("status = %v (err: %v), want failed", results[0].Status, results[0].Err)
+	}
+}
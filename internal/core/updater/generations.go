@@ -0,0 +1,136 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"parm/internal/core/switcher"
+	"parm/internal/core/uninstaller"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+	"path/filepath"
+	"time"
+)
+
+type RollbackResult struct {
+	From *manifest.Manifest
+	To   *manifest.Manifest
+}
+
+// Keeps a version that was just replaced by an update as the newest generation of a package,
+// and removes the oldest generations beyond keep.
+func RetainGeneration(owner, repo, version string, keep int) error {
+	gens, err := readGenerations(owner, repo)
+	if err != nil {
+		return err
+	}
+	gens = append(removeGeneration(gens, version), version)
+
+	var errs []error
+	for len(gens) > max(keep, 0) {
+		oldest := gens[0]
+		if err := uninstaller.RemoveVersion(owner, repo, oldest); err != nil && versionInstalled(owner, repo, oldest) {
+			errs = append(errs, err)
+			break
+		}
+		gens = gens[1:]
+	}
+
+	if err := writeGenerations(owner, repo, gens); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Swaps the active version of a package with its newest generation.
+// The version that was active becomes the newest generation, so rolling back twice undoes the rollback.
+func Rollback(owner, repo string) (*RollbackResult, error) {
+	curr, err := manifest.Read(parmutil.GetInstallDir(owner, repo))
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s is not installed: \n%w", owner, repo, err)
+	}
+
+	gens, err := readGenerations(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	// generations may have been removed by hand with "parm remove <owner>/<repo>@<tag>"
+	for len(gens) > 0 && !versionInstalled(owner, repo, gens[len(gens)-1]) {
+		gens = gens[:len(gens)-1]
+	}
+	if len(gens) == 0 {
+		return nil, fmt.Errorf("no previous version of %s/%s to roll back to", owner, repo)
+	}
+	target := gens[len(gens)-1]
+
+	dir := parmutil.GetVersionDir(owner, repo, target)
+	prev, err := manifest.Read(dir)
+	if err != nil {
+		return nil, err
+	}
+	prev.Pinned = curr.Pinned
+	prev.LastUpdated = time.Now().UTC().Format(time.DateTime)
+	if err := prev.Write(dir); err != nil {
+		return nil, err
+	}
+
+	if err := switcher.Activate(prev); err != nil {
+		return nil, err
+	}
+
+	gens = append(removeGeneration(gens[:len(gens)-1], curr.Version), curr.Version)
+	if err := writeGenerations(owner, repo, gens); err != nil {
+		return nil, err
+	}
+
+	return &RollbackResult{From: curr, To: prev}, nil
+}
+
+func versionInstalled(owner, repo, version string) bool {
+	_, err := os.Stat(filepath.Join(parmutil.GetVersionDir(owner, repo, version), manifest.ManifestFileName))
+	return err == nil
+}
+
+func removeGeneration(gens []string, version string) []string {
+	var res []string
+	for _, g := range gens {
+		if g != version {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+func readGenerations(owner, repo string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(parmutil.GetPkgDir(owner, repo), parmutil.GENERATIONS_FILE))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var gens []string
+	if err := json.Unmarshal(data, &gens); err != nil {
+		return nil, fmt.Errorf("cannot read generations of %s/%s: \n%w", owner, repo, err)
+	}
+	return gens, nil
+}
+
+func writeGenerations(owner, repo string, gens []string) error {
+	path := filepath.Join(parmutil.GetPkgDir(owner, repo), parmutil.GENERATIONS_FILE)
+	if len(gens) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(gens)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
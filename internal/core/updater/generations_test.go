@@ -0,0 +1,137 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"parm/internal/config"
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+)
+
+func TestRetainGeneration_PrunesOldest(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	for _, ver := range []string{"v1.0.0", "v2.0.0", "v3.0.0", "v4.0.0"} {
+		writeVersion(t, ver)
+	}
+	parmutil.SetCurrentVersion("owner", "repo", "v4.0.0")
+
+	for _, ver := range []string{"v1.0.0", "v2.0.0", "v3.0.0"} {
+		if err := RetainGeneration("owner", "repo", ver, 2); err != nil {
+			t.Fatalf("RetainGeneration(%s) error: %v", ver, err)
+		}
+	}
+
+	if versionInstalled("owner", "repo", "v1.0.0") {
+		t.Error("oldest generation v1.0.0 was not pruned")
+	}
+	for _, ver := range []string{"v2.0.0", "v3.0.0", "v4.0.0"} {
+		if !versionInstalled("owner", "repo", ver) {
+			t.Errorf("version %s was removed", ver)
+		}
+	}
+
+	gens, err := readGenerations("owner", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 2 || gens[0] != "v2.0.0" || gens[1] != "v3.0.0" {
+		t.Errorf("generations = %v, want [v2.0.0 v3.0.0]", gens)
+	}
+}
+
+func TestRetainGeneration_KeepNone(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	writeVersion(t, "v1.0.0")
+	writeVersion(t, "v2.0.0")
+	parmutil.SetCurrentVersion("owner", "repo", "v2.0.0")
+
+	if err := RetainGeneration("owner", "repo", "v1.0.0", 0); err != nil {
+		t.Fatalf("RetainGeneration() error: %v", err)
+	}
+	if versionInstalled("owner", "repo", "v1.0.0") {
+		t.Error("replaced version was kept with keep = 0")
+	}
+	if _, err := os.Stat(filepath.Join(parmutil.GetPkgDir("owner", "repo"), parmutil.GENERATIONS_FILE)); !os.IsNotExist(err) {
+		t.Error("empty generations file was not removed")
+	}
+}
+
+func TestRollback(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = filepath.Join(tmpDir, "pkg")
+	config.Cfg.ParmBinPath = filepath.Join(tmpDir, "bin")
+
+	writeVersion(t, "v1.0.0")
+	curr := writeVersion(t, "v2.0.0")
+	curr.Pinned = true
+	curr.Write(parmutil.GetVersionDir("owner", "repo", "v2.0.0"))
+	parmutil.SetCurrentVersion("owner", "repo", "v2.0.0")
+
+	if err := RetainGeneration("owner", "repo", "v1.0.0", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Rollback("owner", "repo")
+	if err != nil {
+		t.Fatalf("Rollback() error: %v", err)
+	}
+	if res.From.Version != "v2.0.0" || res.To.Version != "v1.0.0" {
+		t.Errorf("Rollback() = %s -> %s, want v2.0.0 -> v1.0.0", res.From.Version, res.To.Version)
+	}
+	if cur, _ := parmutil.GetCurrentVersion("owner", "repo"); cur != "v1.0.0" {
+		t.Errorf("current version = %v, want v1.0.0", cur)
+	}
+
+	man, err := manifest.Read(parmutil.GetInstallDir("owner", "repo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !man.Pinned {
+		t.Error("pinned status was not carried over by the rollback")
+	}
+
+	// rolling back again undoes the rollback
+	res, err = Rollback("owner", "repo")
+	if err != nil {
+		t.Fatalf("second Rollback() error: %v", err)
+	}
+	if res.To.Version != "v2.0.0" {
+		t.Errorf("second Rollback() restored %s, want v2.0.0", res.To.Version)
+	}
+}
+
+func TestRollback_NoGenerations(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	writeVersion(t, "v1.0.0")
+	parmutil.SetCurrentVersion("owner", "repo", "v1.0.0")
+
+	if _, err := Rollback("owner", "repo"); err == nil {
+		t.Error("Rollback() should fail without a previous version")
+	}
+}
+
+func writeVersion(t *testing.T, version string) *manifest.Manifest {
+	t.Helper()
+	dir := parmutil.GetVersionDir("owner", "repo", version)
+	os.MkdirAll(dir, 0755)
+	m := &manifest.Manifest{
+		Owner:       "owner",
+		Repo:        "repo",
+		Version:     version,
+		InstallType: manifest.Release,
+		Executables: []string{},
+		LastUpdated: "2025-01-01 12:00:00",
+	}
+	if err := m.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
@@ -0,0 +1,36 @@
+package updater
+
+import (
+	"context"
+	"parm/internal/gh"
+	"parm/internal/manifest"
+)
+
+type OutdatedResult struct {
+	Manifest *manifest.Manifest
+	// tag of the release the package would be updated to
+	Latest string
+	Err    error
+}
+
+func (res *OutdatedResult) Outdated() bool {
+	return res.Err == nil && res.Latest != res.Manifest.Version
+}
+
+// Resolves the latest release of every package the same way Update does, without installing anything.
+// At most jobs lookups run at once, and results are returned in the same order as mans.
+func (up *Updater) CheckOutdated(ctx context.Context, mans []*manifest.Manifest, flags *UpdateFlags, jobs int) []OutdatedResult {
+	results := make([]OutdatedResult, len(mans))
+	prefetched := up.prefetch(ctx, mans)
+	forEach(len(mans), jobs, func(i int) {
+		man := mans[i]
+		results[i].Manifest = man
+		rel, err := up.resolve(ctx, man, flags, prefetched[gh.RepoRef{Owner: man.Owner, Repo: man.Repo}])
+		if err != nil {
+			results[i].Err = gh.ExplainRateLimit(err)
+			return
+		}
+		results[i].Latest = rel.GetTagName()
+	})
+	return results
+}
@@ -0,0 +1,157 @@
+package updater
+
+import (
+	"context"
+	"testing"
+
+	"parm/internal/core/installer"
+	"parm/internal/gh"
+	"parm/internal/manifest"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+)
+
+func TestCheckOutdated(t *testing.T) {
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetReposReleasesLatestByOwnerByRepo,
+			&github.RepositoryRelease{TagName: github.Ptr("v2.0.0")},
+			&github.RepositoryRelease{TagName: github.Ptr("v2.0.0")},
+		),
+	)
+	client := github.NewClient(mockedHTTPClient)
+	up := New(client.Repositories, installer.New(client.Repositories))
+
+	mans := []*manifest.Manifest{
+		{Owner: "owner", Repo: "old", Version: "v1.0.0", InstallType: manifest.Release},
+		{Owner: "owner", Repo: "new", Version: "v2.0.0", InstallType: manifest.Release},
+	}
+	results := up.CheckOutdated(context.Background(), mans, &UpdateFlags{}, 1)
+
+	if len(results) != 2 {
+		t.Fatalf("CheckOutdated() returned %d results, want 2", len(results))
+	}
+	if !results[0].Outdated() || results[0].Latest != "v2.0.0" {
+		t.Errorf("old: Outdated() = %v, Latest = %v, want true, v2.0.0", results[0].Outdated(), results[0].Latest)
+	}
+	if results[1].Outdated() {
+		t.Error("new: Outdated() = true, want false")
+	}
+}
+
+func TestCheckOutdated_PreRelease(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+		want   string
+	}{
+		{"newer stable release wins", false, "v2.0.0"},
+		{"strict keeps pre-release", true, "v2.0.0-beta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(
+					mock.GetReposReleasesByOwnerByRepo,
+					[]*github.RepositoryRelease{
+						{TagName: github.Ptr("v2.0.0-beta"), Prerelease: github.Ptr(true)},
+					},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposReleasesLatestByOwnerByRepo,
+					&github.RepositoryRelease{TagName: github.Ptr("v2.0.0")},
+				),
+			)
+			client := github.NewClient(mockedHTTPClient)
+			up := New(client.Repositories, installer.New(client.Repositories))
+
+			mans := []*manifest.Manifest{
+				{Owner: "owner", Repo: "repo", Version: "v1.0.0", InstallType: manifest.PreRelease},
+			}
+			results := up.CheckOutdated(context.Background(), mans, &UpdateFlags{Strict: tt.strict}, 1)
+			if results[0].Err != nil {
+				t.Fatalf("CheckOutdated() error: %v", results[0].Err)
+			}
+			if results[0].Latest != tt.want {
+				t.Errorf("Latest = %v, want %v", results[0].Latest, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOutdated_LookupError(t *testing.T) {
+	client := github.NewClient(mock.NewMockedHTTPClient())
+	up := New(client.Repositories, installer.New(client.Repositories))
+
+	mans := []*manifest.Manifest{
+		{Owner: "owner", Repo: "repo", Version: "v1.0.0", InstallType: manifest.Release},
+	}
+	results := up.CheckOutdated(context.Background(), mans, &UpdateFlags{}, 1)
+	if results[0].Err == nil {
+		t.Error("CheckOutdated() should report lookup errors")
+	}
+	if results[0].Outdated() {
+		t.Error("a package that couldn't be checked shouldn't count as outdated")
+	}
+}
+
+type fakeLookup struct {
+	rels  map[gh.RepoRef]*gh.RepoReleases
+	calls int
+}
+
+func (f *fakeLookup) LatestReleases(ctx context.Context, repos []gh.RepoRef) (map[gh.RepoRef]*gh.RepoReleases, error) {
+	f.calls++
+	return f.rels, nil
+}
+
+func TestCheckOutdated_BulkLookup(t *testing.T) {
+	// only the repo the bulk lookup misses, and the pre-release it didn't find, are looked up over REST
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetReposReleasesLatestByOwnerByRepo,
+			&github.RepositoryRelease{TagName: github.Ptr("v5.0.0")},
+			&github.RepositoryRelease{TagName: github.Ptr("v5.0.0")},
+		),
+		mock.WithRequestMatch(
+			mock.GetReposReleasesByOwnerByRepo,
+			[]*github.RepositoryRelease{{TagName: github.Ptr("v6.0.0-rc1"), Prerelease: github.Ptr(true)}},
+		),
+	)
+	client := github.NewClient(mockedHTTPClient)
+	lookup := &fakeLookup{rels: map[gh.RepoRef]*gh.RepoReleases{
+		{Owner: "owner", Repo: "a"}: {Stable: &github.RepositoryRelease{TagName: github.Ptr("v2.0.0")}},
+		{Owner: "owner", Repo: "b"}: {
+			Stable:     &github.RepositoryRelease{TagName: github.Ptr("v1.0.0")},
+			PreRelease: &github.RepositoryRelease{TagName: github.Ptr("v1.1.0-rc1")},
+		},
+		{Owner: "owner", Repo: "d"}: {Stable: &github.RepositoryRelease{TagName: github.Ptr("v1.0.0")}},
+	}}
+	up := New(client.Repositories, installer.New(client.Repositories)).WithBulkLookup(lookup)
+
+	mans := []*manifest.Manifest{
+		{Owner: "owner", Repo: "a", Version: "v1.0.0", InstallType: manifest.Release},
+		{Owner: "owner", Repo: "b", Version: "v1.0.0", InstallType: manifest.PreRelease},
+		{Owner: "owner", Repo: "c", Version: "v1.0.0", InstallType: manifest.Release},
+		{Owner: "owner", Repo: "d", Version: "v1.0.0", InstallType: manifest.PreRelease},
+	}
+	results := up.CheckOutdated(context.Background(), mans, &UpdateFlags{}, 2)
+
+	if lookup.calls != 1 {
+		t.Errorf("bulk lookup called %d times, want 1", lookup.calls)
+	}
+	want := []string{"v2.0.0", "v1.1.0-rc1", "v5.0.0", "v6.0.0-rc1"}
+	for i, res := range results {
+		if res.Err != nil {
+			t.Errorf("%s: error: %v", res.Manifest.Repo, res.Err)
+		}
+		if res.Latest != want[i] {
+			t.Errorf("%s: Latest = %v, want %v", res.Manifest.Repo, res.Latest, want[i])
+		}
+	}
+	if up.LookupCost(mans, &UpdateFlags{}) != 0 {
+		t.Error("LookupCost() should not count REST requests when a bulk lookup is set")
+	}
+}
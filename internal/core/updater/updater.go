@@ -1,9 +1,12 @@
 package updater
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"parm/internal/core/installer"
+	"parm/internal/core/verify"
 	"parm/internal/gh"
 	"parm/internal/manifest"
 	"parm/pkg/progress"
@@ -15,6 +18,8 @@ import (
 type Updater struct {
 	client    *github.RepositoriesService
 	installer installer.Installer
+	// optional, resolves the releases of many packages at once for UpdateAll and CheckOutdated
+	bulk gh.ReleaseLookup
 }
 
 type UpdateResult struct {
@@ -33,69 +38,198 @@ func New(cli *github.RepositoriesService, rel *installer.Installer) *Updater {
 	}
 }
 
-// TODO: update concurrently?
-func (up *Updater) Update(ctx context.Context, owner, repo string, installPath string, man *manifest.Manifest, flags *UpdateFlags, hooks *progress.Hooks) (*UpdateResult, error) {
-	var rel *github.RepositoryRelease
-	var err error
+// Makes batch operations resolve releases with a single lookup, falling back to per-package requests
+// for packages it can't resolve.
+func (up *Updater) WithBulkLookup(lookup gh.ReleaseLookup) *Updater {
+	up.bulk = lookup
+	return up
+}
+
+// Returned by Update when the installed version is already the latest.
+var ErrUpToDate = errors.New("already up to date")
+
+// Safe to call concurrently for different packages, see UpdateAll.
+func (up *Updater) Update(ctx context.Context, owner, repo string, pkgPath string, man *manifest.Manifest, flags *UpdateFlags, hooks *progress.Hooks) (*UpdateResult, error) {
+	if man == nil {
+		return nil, fmt.Errorf("cannot fetch manifest for %s/%s", owner, repo)
+	}
+	return up.update(ctx, owner, repo, pkgPath, man, flags, hooks, nil)
+}
+
+// same as Update, but uses prefetched releases if there are any
+func (up *Updater) update(ctx context.Context, owner, repo string, pkgPath string, man *manifest.Manifest, flags *UpdateFlags, hooks *progress.Hooks, prefetched *gh.RepoReleases) (*UpdateResult, error) {
+	rel, err := up.nextRelease(ctx, man, flags, prefetched)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := up.installer.Install(ctx, owner, repo, pkgPath, installFlags(man, rel.GetTagName(), flags), hooks)
+	if err != nil {
+		return nil, err
+	}
+	actual := UpdateResult{
+		OldManifest:   man,
+		InstallResult: res,
+	}
+	return &actual, nil
+}
 
+// Works out what updating a package would do, without downloading or changing anything.
+func (up *Updater) Plan(ctx context.Context, owner, repo string, pkgPath string, man *manifest.Manifest, flags *UpdateFlags) (*installer.Plan, error) {
 	if man == nil {
 		return nil, fmt.Errorf("cannot fetch manifest for %s/%s", owner, repo)
 	}
+	rel, err := up.nextRelease(ctx, man, flags, nil)
+	if err != nil {
+		return nil, err
+	}
+	return up.installer.Plan(ctx, owner, repo, pkgPath, installFlags(man, rel.GetTagName(), flags))
+}
+
+// resolves the release a package would be updated to, or returns an error wrapping ErrUpToDate
+func (up *Updater) nextRelease(ctx context.Context, man *manifest.Manifest, flags *UpdateFlags, prefetched *gh.RepoReleases) (*github.RepositoryRelease, error) {
+	rel, err := up.resolve(ctx, man, flags, prefetched)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch latest release for %s/%s: %w", man.Owner, man.Repo, err)
+	}
+
+	// only need to check for equality
+	if man.Version == rel.GetTagName() {
+		return nil, fmt.Errorf("%s/%s is %w (ver %s)", man.Owner, man.Repo, ErrUpToDate, man.Version)
+	}
+	return rel, nil
+}
+
+// the install options for updating a package to version
+func installFlags(man *manifest.Manifest, version string, flags *UpdateFlags) installer.InstallFlags {
+	opts := installer.InstallFlags{
+		Type:        man.InstallType,
+		Version:     &version,
+		Asset:       nil,
+		Strict:      flags.Strict,
+		VerifyLevel: verifyLevel(man),
+	}
+	// same as what the update is committed with
+	opts.Bins, opts.Renames = installer.BinSelection(man.Owner, man.Repo, nil, nil, man)
+	if man.Asset != nil && man.Asset.Pattern != "" {
+		opts.AssetPattern = &man.Asset.Pattern
+	}
+	return opts
+}
+
+// updates verify as strictly as the package was installed. Packages installed before the level was recorded
+// keep being verified by their signature or attestation if they had one, and by checksum otherwise.
+func verifyLevel(man *manifest.Manifest) verify.Level {
+	if level, err := verify.ParseLevel(man.VerifyLevel); err == nil {
+		return level
+	}
+	switch {
+	case man.Signer == nil:
+		return verify.LevelChecksum
+	case man.Signer.Method == "attestation":
+		return verify.LevelAttestation
+	default:
+		return verify.LevelSignature
+	}
+}
 
-	switch man.InstallType {
+// Resolves the release a package on the given channel would be updated to.
+func (up *Updater) LatestRelease(ctx context.Context, owner, repo string, channel manifest.InstallType, flags *UpdateFlags) (*github.RepositoryRelease, error) {
+	switch channel {
 	case manifest.Release:
-		rel, _, err = up.client.GetLatestRelease(ctx, owner, repo)
-		if err != nil {
-			return nil, err
-		}
+		rel, _, err := up.client.GetLatestRelease(ctx, owner, repo)
+		return rel, err
 	case manifest.PreRelease:
-		rel, err = gh.GetLatestPreRelease(ctx, up.client, owner, repo)
+		rel, err := gh.GetLatestPreRelease(ctx, up.client, owner, repo)
 		if err != nil {
 			return nil, err
 		}
-		// TODO: DRY @installer.go
-		if !flags.Strict {
-			// expensive!
-			relStable, _, err := up.client.GetLatestRelease(ctx, owner, repo)
-			if err != nil {
-				return nil, err
-			}
+		if flags != nil && flags.Strict {
+			return pickRelease(owner, repo, channel, flags, nil, rel)
+		}
 
-			// TODO: abstract elsewhere cuz it's similar to updater.NeedsUpdate?
-			currVer, _ := semver.NewVersion(rel.GetTagName())
-			stableVer, _ := semver.NewVersion(relStable.GetTagName())
-			if stableVer.GreaterThan(currVer) {
-				rel = relStable
-			}
+		// expensive!
+		relStable, _, err := up.client.GetLatestRelease(ctx, owner, repo)
+		if err != nil {
+			return nil, err
 		}
+		return pickRelease(owner, repo, channel, flags, relStable, rel)
 	}
+	return nil, fmt.Errorf("unknown release channel %q", channel)
+}
 
-	newVer := rel.GetTagName()
-
-	// only need to check for equality
-	if man.Version == newVer {
-		return nil, fmt.Errorf("%s/%s is already up to date (ver %s)", owner, repo, man.Version)
+// resolves the latest release from prefetched releases, or with per-package requests if there are none
+func (up *Updater) resolve(ctx context.Context, man *manifest.Manifest, flags *UpdateFlags, prefetched *gh.RepoReleases) (*github.RepositoryRelease, error) {
+	// the bulk lookup only sees the newest few releases, an older pre-release is looked up on its own
+	if prefetched == nil || (man.InstallType == manifest.PreRelease && prefetched.PreRelease == nil) {
+		return up.LatestRelease(ctx, man.Owner, man.Repo, man.InstallType, flags)
 	}
+	return pickRelease(man.Owner, man.Repo, man.InstallType, flags, prefetched.Stable, prefetched.PreRelease)
+}
 
+// Looks up the releases of every package at once. Returns nil if there is no bulk lookup or it fails,
+// in which case every package is looked up on its own.
+func (up *Updater) prefetch(ctx context.Context, mans []*manifest.Manifest) map[gh.RepoRef]*gh.RepoReleases {
+	if up.bulk == nil || len(mans) == 0 {
+		return nil
+	}
+	refs := make([]gh.RepoRef, len(mans))
+	for i, man := range mans {
+		refs[i] = gh.RepoRef{Owner: man.Owner, Repo: man.Repo}
+	}
+	rels, err := up.bulk.LatestReleases(ctx, refs)
 	if err != nil {
-		return nil, fmt.Errorf("could not fetch latest release for %s/%s: %w", owner, repo, err)
+		return nil
 	}
+	return rels
+}
 
-	opts := installer.InstallFlags{
-		Type:        man.InstallType,
-		Version:     &newVer,
-		Asset:       nil,
-		Strict:      flags.Strict,
-		VerifyLevel: 0,
+// picks the release to update to on a channel, given the newest stable release and pre-release of a package
+func pickRelease(owner, repo string, channel manifest.InstallType, flags *UpdateFlags, stable, pre *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	switch channel {
+	case manifest.Release:
+		if stable == nil {
+			return nil, fmt.Errorf("no release found for %s/%s", owner, repo)
+		}
+		return stable, nil
+	case manifest.PreRelease:
+		if flags != nil && flags.Strict {
+			if pre == nil {
+				return nil, fmt.Errorf("no pre-release found for %s/%s", owner, repo)
+			}
+			return pre, nil
+		}
+		if pre == nil || stable == nil {
+			if rel := cmp.Or(pre, stable); rel != nil {
+				return rel, nil
+			}
+			return nil, fmt.Errorf("no release found for %s/%s", owner, repo)
+		}
+
+		// TODO: abstract elsewhere cuz it's similar to updater.NeedsUpdate?
+		currVer, cErr := semver.NewVersion(pre.GetTagName())
+		stableVer, sErr := semver.NewVersion(stable.GetTagName())
+		if cErr == nil && sErr == nil && stableVer.GreaterThan(currVer) {
+			return stable, nil
+		}
+		return pre, nil
 	}
+	return nil, fmt.Errorf("unknown release channel %q", channel)
+}
 
-	res, err := up.installer.Install(ctx, owner, repo, installPath, opts, hooks)
-	if err != nil {
-		return nil, err
+// Estimates how many REST API requests resolving the latest release of every package takes.
+// Bulk lookups go through the GraphQL API, which has its own rate limit.
+func (up *Updater) LookupCost(mans []*manifest.Manifest, flags *UpdateFlags) int {
+	if up.bulk != nil {
+		return 0
 	}
-	actual := UpdateResult{
-		OldManifest:   man,
-		InstallResult: res,
+	cost := 0
+	for _, man := range mans {
+		if man.InstallType == manifest.PreRelease && (flags == nil || !flags.Strict) {
+			cost += 2
+		} else {
+			cost++
+		}
 	}
-	return &actual, nil
+	return cost
 }
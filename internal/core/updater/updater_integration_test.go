@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +15,7 @@ import (
 
 	"parm/internal/config"
 	"parm/internal/core/installer"
+	"parm/internal/core/verify"
 	"parm/internal/manifest"
 
 	"github.com/google/go-github/v74/github"
@@ -33,6 +35,7 @@ func TestUpdate_Success(t *testing.T) {
 		Repo:        "repo",
 		Version:     "v1.0.0",
 		InstallType: manifest.Release,
+		VerifyLevel: "none",
 		Executables: []string{},
 		LastUpdated: "2025-01-01 12:00:00",
 	}
@@ -124,6 +127,7 @@ func TestUpdate_AlreadyUpToDate(t *testing.T) {
 		Repo:        "repo",
 		Version:     "v1.0.0",
 		InstallType: manifest.Release,
+		VerifyLevel: "none",
 		Executables: []string{},
 		LastUpdated: "2025-01-01 12:00:00",
 	}
@@ -200,6 +204,7 @@ func TestUpdate_PreReleaseChannel(t *testing.T) {
 		Repo:        "repo",
 		Version:     "v1.0.0-beta",
 		InstallType: manifest.PreRelease,
+		VerifyLevel: "none",
 		Executables: []string{},
 		LastUpdated: "2025-01-01 12:00:00",
 	}
@@ -293,6 +298,7 @@ func TestUpdate_StrictPreRelease(t *testing.T) {
 		Repo:        "repo",
 		Version:     "v1.0.0-alpha",
 		InstallType: manifest.PreRelease,
+		VerifyLevel: "none",
 		Executables: []string{},
 		LastUpdated: "2025-01-01 12:00:00",
 	}
@@ -394,3 +400,100 @@ func createTestArchive(t *testing.T, baseDir string) string {
 
 	return archivePath
 }
+
+func TestPlan(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+	config.Cfg.ParmBinPath = filepath.Join(tmpDir, "bin")
+
+	pkgDir := filepath.Join(tmpDir, "owner", "repo")
+	os.MkdirAll(pkgDir, 0755)
+	m := &manifest.Manifest{
+		Owner:       "owner",
+		Repo:        "repo",
+		Version:     "v1.0.0",
+		InstallType: manifest.Release,
+		VerifyLevel: "none",
+		Executables: []string{"repo"},
+	}
+	m.Write(pkgDir)
+
+	assetName := fmt.Sprintf("test-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	release := func(tag string) *github.RepositoryRelease {
+		return &github.RepositoryRelease{
+			TagName: github.Ptr(tag),
+			Assets:  []*github.ReleaseAsset{{Name: github.Ptr(assetName)}},
+		}
+	}
+	// no handler for downloads, planning must not download anything
+	mockedHTTPClient := mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetReposReleasesLatestByOwnerByRepo,
+			release("v2.0.0"),
+			release("v1.0.0"),
+		),
+		mock.WithRequestMatch(
+			mock.GetReposReleasesTagsByOwnerByRepoByTag,
+			release("v2.0.0"),
+		),
+	)
+	client := github.NewClient(mockedHTTPClient)
+	up := New(client.Repositories, installer.New(client.Repositories))
+
+	plan, err := up.Plan(context.Background(), "owner", "repo", pkgDir, m, &UpdateFlags{})
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+	if plan.Version != "v2.0.0" || plan.Asset.GetName() != assetName {
+		t.Errorf("Plan() = %s %s, want v2.0.0 %s", plan.Version, plan.Asset.GetName(), assetName)
+	}
+	if len(plan.Links) != 1 || plan.Links[0].Target != filepath.Join(pkgDir, "v2.0.0", "repo") {
+		t.Errorf("Links = %+v, want the executable of the installed version", plan.Links)
+	}
+
+	if _, err := up.Plan(context.Background(), "owner", "repo", pkgDir, m, &UpdateFlags{}); !errors.Is(err, ErrUpToDate) {
+		t.Errorf("Plan() error = %v, want ErrUpToDate", err)
+	}
+}
+
+func TestInstallFlags_VerifyLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		man  manifest.Manifest
+		want verify.Level
+	}{
+		{"recorded", manifest.Manifest{VerifyLevel: "digest"}, verify.LevelDigest},
+		{"recorded none", manifest.Manifest{VerifyLevel: "none"}, verify.LevelNone},
+		{"unrecorded", manifest.Manifest{}, verify.LevelChecksum},
+		{"unrecorded signature", manifest.Manifest{Signer: &manifest.Signer{Method: "minisign"}}, verify.LevelSignature},
+		{"unrecorded attestation", manifest.Manifest{Signer: &manifest.Signer{Method: "attestation"}}, verify.LevelAttestation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := installFlags(&tt.man, "v2.0.0", &UpdateFlags{}).VerifyLevel; got != tt.want {
+				t.Errorf("installFlags().VerifyLevel = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallFlags_Bins(t *testing.T) {
+	orig := config.Cfg.Packages
+	config.Cfg.Packages = map[string]config.PackageRules{"owner/repo": {Rename: map[string]string{"tool": "t"}}}
+	defer func() { config.Cfg.Packages = orig }()
+
+	man := &manifest.Manifest{
+		Owner:   "owner",
+		Repo:    "repo",
+		Bins:    []string{"tool"},
+		Renames: map[string]string{"tool": "old"},
+	}
+	opts := installFlags(man, "v2.0.0", &UpdateFlags{})
+	if len(opts.Bins) != 1 || opts.Bins[0] != "tool" {
+		t.Errorf("installFlags().Bins = %v, want the installed selection", opts.Bins)
+	}
+	// the config wins over the manifest, like when the update is committed
+	if opts.Renames["tool"] != "t" {
+		t.Errorf("installFlags().Renames = %v, want the configured renames", opts.Renames)
+	}
+}
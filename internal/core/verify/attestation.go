@@ -0,0 +1,139 @@
+package verify
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	inTotoPayloadType   = "application/vnd.in-toto+json"
+	slsaProvenance      = "https://slsa.dev/provenance/"
+	githubActionsIssuer = "https://token.actions.githubusercontent.com"
+)
+
+// Returned when an attestation is verified without Fulcio certificates to check its signing certificate against.
+var ErrNoTrustedRoots = errors.New("no trusted roots to verify the attestation's signing certificate against")
+
+// Fulcio certificate extension holding the repository the signing workflow ran in
+var oidSourceRepositoryURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+
+// a DSSE envelope, the signed part of an attestation bundle
+type dsseEnvelope struct {
+	Payload     []byte `json:"payload"`
+	PayloadType string `json:"payloadType"`
+	Signatures  []struct {
+		Sig []byte `json:"sig"`
+	} `json:"signatures"`
+}
+
+type inTotoStatement struct {
+	Subject []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	PredicateType string `json:"predicateType"`
+}
+
+// What a GitHub artifact attestation must say about the artifact and the workflow that built it.
+type AttestationPolicy struct {
+	// "owner/repo" the artifact must have been built in
+	Repo string
+	// the workflow that must have signed the attestation, e.g. "owner/repo/.github/workflows/release.yml".
+	// If empty, any workflow of Repo is accepted.
+	SignerWorkflow string
+	// PEM encoded Fulcio certificates the signing certificate must chain to
+	Roots []byte
+}
+
+// Verifies a GitHub artifact attestation, a sigstore bundle holding a signed in-toto statement,
+// of an artifact with the given digest. Returns the workflow that signed it.
+//
+// Verification is offline, the transparency log entries in the bundle are not checked.
+func VerifyAttestation(bundle []byte, digest Checksum, policy AttestationPolicy) (*Signer, error) {
+	var sb sigstoreBundle
+	if err := json.Unmarshal(bundle, &sb); err != nil {
+		return nil, fmt.Errorf("invalid attestation bundle: \n%w", err)
+	}
+	env := sb.DSSEEnvelope
+	if env == nil || len(env.Signatures) == 0 {
+		return nil, fmt.Errorf("invalid attestation bundle: no signed DSSE envelope")
+	}
+	if env.PayloadType != inTotoPayloadType {
+		return nil, fmt.Errorf("unsupported attestation payload type %q", env.PayloadType)
+	}
+
+	leaf, chain, err := sb.certificates()
+	if err != nil {
+		return nil, err
+	}
+	if leaf == nil {
+		return nil, fmt.Errorf("invalid attestation bundle: no signing certificate")
+	}
+	// without a trusted root, anyone could issue a certificate claiming to be a GitHub Actions workflow
+	if len(policy.Roots) == 0 {
+		return nil, ErrNoTrustedRoots
+	}
+	if err := verifyCertChain(leaf, chain, policy.Roots); err != nil {
+		return nil, err
+	}
+	if err := verifyMessage(leaf.PublicKey, dssePAE(env.PayloadType, env.Payload), env.Signatures[0].Sig); err != nil {
+		return nil, fmt.Errorf("%w: attestation signature doesn't match", err)
+	}
+
+	workflow, err := matchWorkflow(leaf, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmt inTotoStatement
+	if err := json.Unmarshal(env.Payload, &stmt); err != nil {
+		return nil, fmt.Errorf("invalid in-toto statement: \n%w", err)
+	}
+	// GitHub also attests SBOMs, which say nothing about how the artifact was built
+	if !strings.HasPrefix(stmt.PredicateType, slsaProvenance) {
+		return nil, fmt.Errorf("not a build provenance attestation: %s", stmt.PredicateType)
+	}
+	for _, sub := range stmt.Subject {
+		if strings.EqualFold(sub.Digest[digest.Algorithm], digest.Hex) {
+			return &Signer{Method: "attestation", Identity: workflow}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: the attestation is for a different artifact", ErrSignatureInvalid)
+}
+
+// returns the workflow the signing certificate was issued to, if it matches the policy
+func matchWorkflow(cert *x509.Certificate, policy AttestationPolicy) (string, error) {
+	if issuer := certIssuer(cert); issuer != githubActionsIssuer {
+		return "", fmt.Errorf("%w: attestation not signed by GitHub Actions, but %q", ErrSignatureInvalid, issuer)
+	}
+
+	repoURI := "https://github.com/" + policy.Repo
+	if source := certExtension(cert, oidSourceRepositoryURI); !strings.EqualFold(source, repoURI) {
+		return "", fmt.Errorf("%w: attestation made in %s, want %s", ErrSignatureInvalid, source, repoURI)
+	}
+
+	if len(cert.URIs) == 0 {
+		return "", fmt.Errorf("%w: signing certificate has no workflow identity", ErrSignatureInvalid)
+	}
+	// e.g. "https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.0.0"
+	workflow := cert.URIs[0].String()
+	prefix := repoURI + "/.github/workflows/"
+	if policy.SignerWorkflow != "" {
+		prefix = "https://github.com/" + policy.SignerWorkflow + "@"
+	}
+	if !strings.HasPrefix(strings.ToLower(workflow), strings.ToLower(prefix)) {
+		return "", fmt.Errorf("%w: attestation signed by %s, want %s...", ErrSignatureInvalid, workflow, prefix)
+	}
+	return workflow, nil
+}
+
+// the pre-authentication encoding DSSE signatures are made over
+func dssePAE(payloadType string, payload []byte) []byte {
+	pae := "DSSEv1 " + strconv.Itoa(len(payloadType)) + " " + payloadType + " " + strconv.Itoa(len(payload)) + " "
+	return append([]byte(pae), payload...)
+}
@@ -0,0 +1,106 @@
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testWorkflow = "https://github.com/owner/tool/.github/workflows/release.yml@refs/tags/v1.0.0"
+
+// builds a GitHub attestation bundle of a provenance statement for an artifact with the given sha256
+func attestationBundle(t *testing.T, key *ecdsa.PrivateKey, leaf []byte, predicateType, sha256Hex string) []byte {
+	t.Helper()
+	stmt, _ := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v1",
+		"subject":       []map[string]any{{"name": "tool.tar.gz", "digest": map[string]string{"sha256": sha256Hex}}},
+		"predicateType": predicateType,
+		"predicate":     map[string]any{},
+	})
+	digest := sha256.Sum256(dssePAE(inTotoPayloadType, stmt))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(leaf)
+	bundle, _ := json.Marshal(map[string]any{
+		"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"verificationMaterial": map[string]any{
+			"certificate": map[string]any{"rawBytes": block.Bytes},
+		},
+		"dsseEnvelope": map[string]any{
+			"payload":     stmt,
+			"payloadType": inTotoPayloadType,
+			"signatures":  []map[string]any{{"sig": sig}},
+		},
+	})
+	return bundle
+}
+
+func sourceRepo(uri string) pkix.Extension {
+	value, _ := asn1.MarshalWithParams(uri, "utf8")
+	return pkix.Extension{Id: oidSourceRepositoryURI, Value: value}
+}
+
+func TestVerifyAttestation(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	digest := Checksum{Algorithm: "sha256", Hex: sum}
+	root, leaf, key := fulcio(t, testWorkflow, testIssuer, sourceRepo("https://github.com/owner/tool"))
+	bundle := attestationBundle(t, key, leaf, "https://slsa.dev/provenance/v1", sum)
+
+	signer, err := VerifyAttestation(bundle, digest, AttestationPolicy{Repo: "Owner/Tool", Roots: root})
+	if err != nil {
+		t.Fatalf("VerifyAttestation() error: %v", err)
+	}
+	if signer.Method != "attestation" || signer.Identity != testWorkflow {
+		t.Errorf("VerifyAttestation() signer = %v, want attestation %s", signer, testWorkflow)
+	}
+
+	otherRoot, otherLeaf, otherKey := fulcio(t, testWorkflow, testIssuer, sourceRepo("https://github.com/owner/tool"))
+	forkRoot, forkLeaf, forkKey := fulcio(t, "https://github.com/fork/tool/.github/workflows/release.yml@refs/heads/main", testIssuer, sourceRepo("https://github.com/fork/tool"))
+	googleRoot, googleLeaf, googleKey := fulcio(t, testWorkflow, "https://accounts.google.com", sourceRepo("https://github.com/owner/tool"))
+
+	tests := []struct {
+		name   string
+		bundle []byte
+		policy AttestationPolicy
+		want   error
+	}{
+		{"trusted root", bundle, AttestationPolicy{Repo: "owner/tool", Roots: root}, nil},
+		{"signer workflow", bundle, AttestationPolicy{Repo: "owner/tool", SignerWorkflow: "owner/tool/.github/workflows/release.yml", Roots: root}, nil},
+		{"other signer workflow", bundle, AttestationPolicy{Repo: "owner/tool", SignerWorkflow: "owner/tool/.github/workflows/nightly.yml", Roots: root}, ErrSignatureInvalid},
+		{"untrusted root", bundle, AttestationPolicy{Repo: "owner/tool", Roots: otherRoot}, ErrSignatureInvalid},
+		// a certificate claiming to be from GitHub Actions is worthless if nothing it chains to is trusted
+		{"no roots", bundle, AttestationPolicy{Repo: "owner/tool"}, ErrNoTrustedRoots},
+		{"other repo", attestationBundle(t, forkKey, forkLeaf, "https://slsa.dev/provenance/v1", sum), AttestationPolicy{Repo: "owner/tool", Roots: forkRoot}, ErrSignatureInvalid},
+		{"not github actions", attestationBundle(t, googleKey, googleLeaf, "https://slsa.dev/provenance/v1", sum), AttestationPolicy{Repo: "owner/tool", Roots: googleRoot}, ErrSignatureInvalid},
+		{"other artifact", attestationBundle(t, key, leaf, "https://slsa.dev/provenance/v1", strings.Repeat("cd", 32)), AttestationPolicy{Repo: "owner/tool", Roots: root}, ErrSignatureInvalid},
+		{"signed by another key", attestationBundle(t, otherKey, leaf, "https://slsa.dev/provenance/v1", sum), AttestationPolicy{Repo: "owner/tool", Roots: root}, ErrSignatureInvalid},
+		{"wrong certificate", attestationBundle(t, key, otherLeaf, "https://slsa.dev/provenance/v1", sum), AttestationPolicy{Repo: "owner/tool", Roots: otherRoot}, ErrSignatureInvalid},
+		{"sbom", attestationBundle(t, key, leaf, "https://spdx.dev/Document/v2.3", sum), AttestationPolicy{Repo: "owner/tool", Roots: root}, errors.New("not a build provenance attestation")},
+		{"not a bundle", bundle[:len(bundle)/2], AttestationPolicy{Repo: "owner/tool", Roots: root}, errors.New("invalid attestation bundle")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyAttestation(tt.bundle, digest, tt.policy)
+			switch {
+			case tt.want == nil && err != nil:
+				t.Errorf("VerifyAttestation() error: %v", err)
+			case tt.want == nil:
+			case errors.Is(tt.want, ErrSignatureInvalid) && !errors.Is(err, ErrSignatureInvalid):
+				t.Errorf("VerifyAttestation() error = %v, want ErrSignatureInvalid", err)
+			case errors.Is(tt.want, ErrNoTrustedRoots) && !errors.Is(err, ErrNoTrustedRoots):
+				t.Errorf("VerifyAttestation() error = %v, want ErrNoTrustedRoots", err)
+			case err == nil || !strings.Contains(err.Error(), tt.want.Error()):
+				t.Errorf("VerifyAttestation() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
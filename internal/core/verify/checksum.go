@@ -0,0 +1,135 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// A checksum of a file, e.g. from a GitHub asset digest or a checksum file.
+type Checksum struct {
+	// "sha256" or "sha512"
+	Algorithm string
+	// lowercase hex
+	Hex string
+}
+
+// Formats the checksum like GitHub's asset digests, e.g. "sha256:<hex>"
+func (c Checksum) String() string {
+	return c.Algorithm + ":" + c.Hex
+}
+
+// Parses a GitHub asset digest like "sha256:<hex>".
+func ParseDigest(digest string) (Checksum, error) {
+	algo, sum, ok := strings.Cut(digest, ":")
+	if !ok {
+		return Checksum{}, fmt.Errorf("invalid digest %q", digest)
+	}
+	c, ok := checksumFromHex(sum)
+	if !ok || c.Algorithm != strings.ToLower(algo) {
+		return Checksum{}, fmt.Errorf("unsupported digest %q", digest)
+	}
+	return c, nil
+}
+
+// guesses the algorithm from the length of a hex encoded checksum. Weaker ones like MD5 and SHA-1 are ignored.
+func checksumFromHex(s string) (Checksum, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if _, err := hex.DecodeString(s); err != nil {
+		return Checksum{}, false
+	}
+	switch len(s) {
+	case sha256.Size * 2:
+		return Checksum{Algorithm: "sha256", Hex: s}, true
+	case sha512.Size * 2:
+		return Checksum{Algorithm: "sha512", Hex: s}, true
+	}
+	return Checksum{}, false
+}
+
+// Finds the checksum of the file name in the contents of a checksum file. Understands
+// the GNU coreutils format ("<hex>  <name>", also with "*" before binary files), the BSD format
+// ("SHA256 (<name>) = <hex>") and files that hold nothing but a single checksum, which are only
+// used if bare is set, i.e. the checksum file belongs to that one file, like "<name>.sha256".
+func FindChecksum(data []byte, name string, bare bool) (Checksum, bool) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	var lines int
+	var only Checksum
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines++
+
+		// BSD: "SHA256 (tool.tar.gz) = <hex>"
+		if rest, sum, ok := strings.Cut(line, ") = "); ok {
+			if _, file, ok := strings.Cut(rest, " ("); ok && sameFile(file, name) {
+				if c, ok := checksumFromHex(sum); ok {
+					return c, true
+				}
+			}
+			continue
+		}
+
+		// GNU: "<hex>  tool.tar.gz" or "<hex> *tool.tar.gz"
+		sum, file, found := strings.Cut(line, " ")
+		c, ok := checksumFromHex(sum)
+		if !ok {
+			continue
+		}
+		if !found {
+			only = c
+			continue
+		}
+		if sameFile(strings.TrimLeft(strings.TrimSpace(file), "*"), name) {
+			return c, true
+		}
+		if lines == 1 {
+			only = c
+		}
+	}
+	if bare && lines == 1 && only.Hex != "" {
+		return only, true
+	}
+	return Checksum{}, false
+}
+
+// compares a file name from a checksum file, which may have a path like "./dist/tool.tar.gz", to name
+func sameFile(listed, name string) bool {
+	return path.Base(strings.ReplaceAll(listed, "\\", "/")) == name
+}
+
+// Hashes the file at path with the algorithm of want and compares the two.
+// Also returns the checksum of the file.
+func VerifyChecksum(path string, want Checksum) (bool, Checksum, error) {
+	var h hash.Hash
+	switch want.Algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return false, Checksum{}, fmt.Errorf("unsupported checksum algorithm %q", want.Algorithm)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, Checksum{}, err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, Checksum{}, err
+	}
+
+	got := Checksum{Algorithm: want.Algorithm, Hex: hex.EncodeToString(h.Sum(nil))}
+	return got.Hex == want.Hex, got, nil
+}
@@ -0,0 +1,85 @@
+package verify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	sumA = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	sumB = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+)
+
+func TestFindChecksum(t *testing.T) {
+	sha512 := strings.Repeat("ab", 64)
+	tests := []struct {
+		name string
+		data string
+		bare bool
+		want string
+	}{
+		{"gnu", sumB + "  other.tar.gz\n" + sumA + "  tool.tar.gz\n", false, "sha256:" + sumA},
+		{"gnu binary mode", sumA + " *tool.tar.gz\n", false, "sha256:" + sumA},
+		{"gnu with path", sumA + "  ./dist/tool.tar.gz\n", false, "sha256:" + sumA},
+		{"bsd", "SHA256 (other.tar.gz) = " + sumB + "\nSHA256 (tool.tar.gz) = " + sumA + "\n", false, "sha256:" + sumA},
+		{"sha512", sha512 + "  tool.tar.gz\n", false, "sha512:" + sha512},
+		{"uppercase hex", strings.ToUpper(sumA) + "  tool.tar.gz\n", false, "sha256:" + sumA},
+		{"bare", sumA + "\n", true, "sha256:" + sumA},
+		{"bare not allowed", sumA + "\n", false, ""},
+		{"sidecar with another name", sumA + "  tool-renamed.tar.gz\n", true, "sha256:" + sumA},
+		{"not listed", sumB + "  other.tar.gz\n" + sumA + "  another.tar.gz\n", true, ""},
+		{"md5 ignored", "d41d8cd98f00b204e9800998ecf8427e  tool.tar.gz\n", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := FindChecksum([]byte(tt.data), "tool.tar.gz", tt.bare)
+			got := ""
+			if ok {
+				got = c.String()
+			}
+			if got != tt.want {
+				t.Errorf("FindChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDigest(t *testing.T) {
+	c, err := ParseDigest("sha256:" + sumA)
+	if err != nil || c.Algorithm != "sha256" || c.Hex != sumA {
+		t.Errorf("ParseDigest() = %+v, %v", c, err)
+	}
+	for _, bad := range []string{sumA, "sha512:" + sumA, "md5:d41d8cd98f00b204e9800998ecf8427e", "sha256:zz"} {
+		if _, err := ParseDigest(bad); err == nil {
+			t.Errorf("ParseDigest(%q) should fail", bad)
+		}
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty")
+	os.WriteFile(path, nil, 0o644)
+
+	ok, got, err := VerifyChecksum(path, Checksum{Algorithm: "sha256", Hex: sumA})
+	if err != nil || !ok {
+		t.Errorf("VerifyChecksum() = %v, %s, %v, want a match", ok, got, err)
+	}
+	ok, _, err = VerifyChecksum(path, Checksum{Algorithm: "sha256", Hex: sumB})
+	if err != nil || ok {
+		t.Errorf("VerifyChecksum() = %v, %v, want a mismatch", ok, err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, l := range []Level{LevelNone, LevelDigest, LevelChecksum, LevelSignature} {
+		got, err := ParseLevel(strings.ToUpper(l.String()))
+		if err != nil || got != l {
+			t.Errorf("ParseLevel(%q) = %v, %v", l, got, err)
+		}
+	}
+	if _, err := ParseLevel("strict"); err == nil {
+		t.Error("ParseLevel() should fail for unknown levels")
+	}
+}
@@ -0,0 +1,357 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Fulcio certificate extensions holding the OIDC issuer of the signer
+var (
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	// deprecated, the value isn't DER encoded
+	oidIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+)
+
+// Verifies a signature made with "cosign sign-blob" of the file at path. sig is the base64 encoded signature.
+// With a certificate (.pem) the signature is keyless and the certificate must chain to the trusted roots and
+// carry the trusted identity and issuer, otherwise the signature is checked against the trusted public key.
+//
+// Verification is offline, the transparency log entry of keyless signatures is not checked.
+func VerifyCosign(path string, sig, cert []byte, trust Trust) (*Signer, error) {
+	rawSig := decodeBase64(sig)
+	if len(cert) == 0 {
+		return verifyWithKey(path, rawSig, trust)
+	}
+
+	certs, err := parseCertificates(decodeBase64(cert))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read signing certificate: \n%w", err)
+	}
+	return verifyWithCertificate(path, rawSig, certs[0], certs[1:], trust)
+}
+
+// the bundle written by "cosign sign-blob --bundle"
+type cosignBundle struct {
+	Base64Signature string `json:"base64Signature"`
+	Cert            string `json:"cert"`
+}
+
+// a sigstore bundle, as written by "cosign sign-blob --new-bundle-format" or the sigstore clients
+type sigstoreBundle struct {
+	MediaType            string `json:"mediaType"`
+	VerificationMaterial struct {
+		Certificate *struct {
+			RawBytes []byte `json:"rawBytes"`
+		} `json:"certificate"`
+		X509CertificateChain *struct {
+			Certificates []struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"x509CertificateChain"`
+		PublicKey *json.RawMessage `json:"publicKey"`
+	} `json:"verificationMaterial"`
+	MessageSignature *struct {
+		MessageDigest struct {
+			Algorithm string `json:"algorithm"`
+			Digest    []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+	DSSEEnvelope *dsseEnvelope `json:"dsseEnvelope"`
+}
+
+// the certificate a sigstore bundle was signed with, and the intermediates that came with it.
+// Returns a nil certificate if the bundle was signed with a key.
+func (b *sigstoreBundle) certificates() (*x509.Certificate, []*x509.Certificate, error) {
+	vm := b.VerificationMaterial
+	var raw [][]byte
+	switch {
+	case vm.Certificate != nil:
+		raw = append(raw, vm.Certificate.RawBytes)
+	case vm.X509CertificateChain != nil:
+		for _, c := range vm.X509CertificateChain.Certificates {
+			raw = append(raw, c.RawBytes)
+		}
+	}
+	if len(raw) == 0 {
+		return nil, nil, nil
+	}
+
+	var certs []*x509.Certificate
+	for _, der := range raw {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot read signing certificate: \n%w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs[0], certs[1:], nil
+}
+
+// Verifies a cosign or sigstore bundle (.bundle, .sigstore.json) of the file at path. Bundles hold the
+// signature together with the certificate or a hint of the key that made it.
+//
+// Verification is offline, the transparency log entries in the bundle are not checked.
+func VerifyBundle(path string, bundle []byte, trust Trust) (*Signer, error) {
+	var sb sigstoreBundle
+	if err := json.Unmarshal(bundle, &sb); err != nil {
+		return nil, fmt.Errorf("invalid signature bundle: \n%w", err)
+	}
+
+	if sb.MediaType == "" {
+		var cb cosignBundle
+		if err := json.Unmarshal(bundle, &cb); err != nil || cb.Base64Signature == "" {
+			return nil, fmt.Errorf("invalid signature bundle")
+		}
+		var cert []byte
+		if cb.Cert != "" {
+			cert = []byte(cb.Cert)
+		}
+		return VerifyCosign(path, []byte(cb.Base64Signature), cert, trust)
+	}
+
+	if sb.MessageSignature == nil {
+		if sb.DSSEEnvelope != nil {
+			return nil, fmt.Errorf("signature bundles with DSSE envelopes are not supported")
+		}
+		return nil, fmt.Errorf("invalid signature bundle: no message signature")
+	}
+	msg := sb.MessageSignature
+	if msg.MessageDigest.Algorithm != "" && msg.MessageDigest.Algorithm != "SHA2_256" {
+		return nil, fmt.Errorf("unsupported bundle digest algorithm %q", msg.MessageDigest.Algorithm)
+	}
+	if len(msg.MessageDigest.Digest) > 0 {
+		h := sha256.New()
+		if err := hashFile(path, h); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(h.Sum(nil), msg.MessageDigest.Digest) {
+			return nil, fmt.Errorf("%w: the bundle was made for a different file", ErrSignatureInvalid)
+		}
+	}
+
+	leaf, chain, err := sb.certificates()
+	if err != nil {
+		return nil, err
+	}
+	if leaf == nil {
+		return verifyWithKey(path, msg.Signature, trust)
+	}
+	return verifyWithCertificate(path, msg.Signature, leaf, chain, trust)
+}
+
+func verifyWithKey(path string, sig []byte, trust Trust) (*Signer, error) {
+	if len(trust.CosignKey) == 0 {
+		return nil, fmt.Errorf("no cosign public key configured")
+	}
+	block, _ := pem.Decode(trust.CosignKey)
+	if block == nil {
+		return nil, fmt.Errorf("invalid cosign public key: not PEM encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cosign public key: \n%w", err)
+	}
+	if err := verifyBlob(path, pub, sig); err != nil {
+		return nil, err
+	}
+
+	fingerprint := sha256.Sum256(block.Bytes)
+	return &Signer{Method: "cosign", Identity: "sha256:" + hex.EncodeToString(fingerprint[:])}, nil
+}
+
+func verifyWithCertificate(path string, sig []byte, leaf *x509.Certificate, chain []*x509.Certificate, trust Trust) (*Signer, error) {
+	if !trust.keyless() {
+		return nil, fmt.Errorf("no identity, issuer and trusted roots configured for keyless signatures")
+	}
+
+	if err := verifyCertChain(leaf, chain, trust.Roots); err != nil {
+		return nil, err
+	}
+
+	identity, err := matchIdentity(leaf, trust)
+	if err != nil {
+		return nil, err
+	}
+	issuer := certIssuer(leaf)
+	if issuer != trust.Issuer {
+		return nil, fmt.Errorf("%w: certificate issued by %q, want %q", ErrSignatureInvalid, issuer, trust.Issuer)
+	}
+
+	if err := verifyBlob(path, leaf.PublicKey, sig); err != nil {
+		return nil, err
+	}
+	return &Signer{Method: "cosign", Identity: fmt.Sprintf("%s (%s)", identity, issuer)}, nil
+}
+
+// checks that a signing certificate chains to the PEM encoded roots, which may include intermediates
+func verifyCertChain(leaf *x509.Certificate, chain []*x509.Certificate, rootsPEM []byte) error {
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	trusted, err := parseCertificates(rootsPEM)
+	if err != nil {
+		return fmt.Errorf("cannot read trusted roots: \n%w", err)
+	}
+	for _, c := range trusted {
+		if bytes.Equal(c.RawIssuer, c.RawSubject) {
+			roots.AddCert(c)
+		} else {
+			intermediates.AddCert(c)
+		}
+	}
+	for _, c := range chain {
+		intermediates.AddCert(c)
+	}
+
+	// signing certificates live for minutes, so without a transparency log timestamp
+	// the best that can be checked offline is that it was valid when it was issued
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   leaf.NotBefore,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: untrusted signing certificate: \n%w", ErrSignatureInvalid, err)
+	}
+	return nil
+}
+
+// returns the identity of the certificate that matches the trusted one
+func matchIdentity(cert *x509.Certificate, trust Trust) (string, error) {
+	var re *regexp.Regexp
+	if trust.IdentityRegex != "" {
+		var err error
+		if re, err = regexp.Compile(trust.IdentityRegex); err != nil {
+			return "", fmt.Errorf("invalid identity regex: \n%w", err)
+		}
+	}
+
+	identities := slices.Clone(cert.EmailAddresses)
+	for _, u := range cert.URIs {
+		identities = append(identities, u.String())
+	}
+	for _, id := range identities {
+		if (trust.Identity != "" && id == trust.Identity) || (re != nil && re.MatchString(id)) {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("%w: certificate identities %s don't match the trusted identity", ErrSignatureInvalid, strings.Join(identities, ", "))
+}
+
+func certIssuer(cert *x509.Certificate) string {
+	if issuer := certExtension(cert, oidIssuerV2); issuer != "" {
+		return issuer
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidIssuerV1) {
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// reads a Fulcio extension holding a DER encoded string
+func certExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) string {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			var value string
+			if _, err := asn1.UnmarshalWithParams(ext.Value, &value, "utf8"); err == nil {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// checks a signature of the file at path, the way cosign makes them for each kind of key
+func verifyBlob(path string, pub crypto.PublicKey, sig []byte) error {
+	if _, ok := pub.(ed25519.PublicKey); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return verifyMessage(pub, data, sig)
+	}
+
+	h := sha256.New()
+	if err := hashFile(path, h); err != nil {
+		return err
+	}
+	return verifyDigest(pub, h.Sum(nil), sig)
+}
+
+func verifyMessage(pub crypto.PublicKey, msg, sig []byte) error {
+	if key, ok := pub.(ed25519.PublicKey); ok {
+		if !ed25519.Verify(key, msg, sig) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	}
+	digest := sha256.Sum256(msg)
+	return verifyDigest(pub, digest[:], sig)
+}
+
+// checks a signature of a sha256 digest
+func verifyDigest(pub crypto.PublicKey, digest, sig []byte) error {
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, sig) {
+			return ErrSignatureInvalid
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, sig); err != nil {
+			return ErrSignatureInvalid
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
+
+// reads every certificate in PEM data
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
+
+// cosign base64 encodes signatures and certificates, but files are sometimes decoded before they're published
+func decodeBase64(data []byte) []byte {
+	if raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data))); err == nil {
+		return raw
+	}
+	return data
+}
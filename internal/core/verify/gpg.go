@@ -0,0 +1,58 @@
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+)
+
+// Verifies a detached OpenPGP signature of the file at path, armored (.asc) or binary (.sig, .gpg),
+// against a set of public keys.
+func VerifyGPG(path string, sig, keys []byte) (*Signer, error) {
+	keyring, err := readKeyRing(keys)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read OpenPGP keys: \n%w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var signer *openpgp.Entity
+	if isArmored(sig) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(keyring, f, bytes.NewReader(sig), nil)
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(keyring, f, bytes.NewReader(sig), nil)
+	}
+	switch {
+	case errors.Is(err, pgperrors.ErrUnknownIssuer):
+		return nil, fmt.Errorf("%w: not signed by any of the configured OpenPGP keys", ErrSignatureInvalid)
+	case err != nil:
+		return nil, fmt.Errorf("%w: \n%w", ErrSignatureInvalid, err)
+	}
+
+	identity := fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint)
+	for name := range signer.Identities {
+		identity += " (" + name + ")"
+		break
+	}
+	return &Signer{Method: "gpg", Identity: identity}, nil
+}
+
+func readKeyRing(keys []byte) (openpgp.EntityList, error) {
+	if isArmored(keys) {
+		return openpgp.ReadArmoredKeyRing(bytes.NewReader(keys))
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(keys))
+}
+
+func isArmored(data []byte) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(data)), "-----BEGIN PGP")
+}
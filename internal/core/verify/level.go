@@ -0,0 +1,42 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// How strictly a downloaded asset is verified before it's installed.
+type Level uint8
+
+const (
+	// nothing is verified
+	LevelNone Level = iota
+	// the asset is checked against GitHub's digest or a checksum file, if the release has either
+	LevelDigest
+	// like LevelDigest, but installs fail if the release has neither
+	LevelChecksum
+	// the asset must also have a valid signature
+	LevelSignature
+	// the asset must have a GitHub build provenance attestation made in its repo.
+	// An alternative to LevelSignature, neither implies the other.
+	LevelAttestation
+)
+
+var levelNames = []string{"none", "digest", "checksum", "signature", "attestation"}
+
+func (l Level) String() string {
+	if int(l) < len(levelNames) {
+		return levelNames[l]
+	}
+	return fmt.Sprintf("Level(%d)", l)
+}
+
+// Parses a level by name, e.g. "checksum".
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(i), nil
+		}
+	}
+	return LevelNone, fmt.Errorf("unknown verify level %q, must be one of %s", name, strings.Join(levelNames, ", "))
+}
@@ -0,0 +1,106 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const minisignKeyIDSize = 8
+
+// Verifies a minisign signature of the file at path against a public key.
+// Both legacy and prehashed (minisign -H, the default since 0.10) signatures are supported.
+func VerifyMinisign(path string, sig []byte, pubKey string) (*Signer, error) {
+	keyID, pk, err := parseMinisignKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := nonEmptyLines(sig)
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return nil, fmt.Errorf("invalid minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+minisignKeyIDSize+ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid minisign signature")
+	}
+	algo, sigKeyID, signature := string(raw[:2]), raw[2:2+minisignKeyIDSize], raw[2+minisignKeyIDSize:]
+	if !bytes.Equal(sigKeyID, keyID) {
+		return nil, fmt.Errorf("%w: signed by key %s, want %s", ErrSignatureInvalid, minisignKeyName(sigKeyID), minisignKeyName(keyID))
+	}
+
+	var msg []byte
+	switch algo {
+	case "ED":
+		h, _ := blake2b.New512(nil)
+		if err := hashFile(path, h); err != nil {
+			return nil, err
+		}
+		msg = h.Sum(nil)
+	case "Ed":
+		if msg, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported minisign signature algorithm %q", algo)
+	}
+	if !ed25519.Verify(pk, msg, signature) {
+		return nil, ErrSignatureInvalid
+	}
+
+	// the trusted comment is signed together with the signature
+	comment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || !ed25519.Verify(pk, append(signature, comment...), global) {
+		return nil, fmt.Errorf("%w: trusted comment was tampered with", ErrSignatureInvalid)
+	}
+
+	return &Signer{Method: "minisign", Identity: minisignKeyName(keyID)}, nil
+}
+
+// accepts the base64 key alone or the contents of a minisign .pub file
+func parseMinisignKey(key string) ([]byte, ed25519.PublicKey, error) {
+	lines := nonEmptyLines([]byte(key))
+	if len(lines) == 0 {
+		return nil, nil, fmt.Errorf("empty minisign public key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[len(lines)-1])
+	if err != nil || len(raw) != 2+minisignKeyIDSize+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return nil, nil, fmt.Errorf("invalid minisign public key")
+	}
+	return raw[2 : 2+minisignKeyIDSize], ed25519.PublicKey(raw[2+minisignKeyIDSize:]), nil
+}
+
+// formats a key ID the way minisign prints it
+func minisignKeyName(keyID []byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(keyID))
+}
+
+func nonEmptyLines(data []byte) []string {
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func hashFile(path string, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,59 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrSignatureInvalid = errors.New("signature invalid")
+
+// Who made a verified signature.
+type Signer struct {
+	// "minisign", "gpg", "cosign" or "attestation"
+	Method string
+	// the key ID, key fingerprint or certificate identity of the signer
+	Identity string
+}
+
+func (s Signer) String() string {
+	return fmt.Sprintf("%s %s", s.Method, s.Identity)
+}
+
+// The keys and identities signatures are checked against. Methods without a key or identity are never trusted.
+type Trust struct {
+	// minisign public key, either the base64 key itself or the contents of a .pub file
+	MinisignKey string
+	// armored or binary OpenPGP public keys
+	GPGKeys []byte
+	// PEM encoded public key used with "cosign sign-blob --key"
+	CosignKey []byte
+
+	// for keyless cosign signatures: the identity (email or URI) and OIDC issuer the signing
+	// certificate must have, and the PEM encoded Fulcio certificates to trust
+	Identity      string
+	IdentityRegex string
+	Issuer        string
+	Roots         []byte
+}
+
+// Reports whether any signing method has a key or identity to check against.
+func (t Trust) Empty() bool {
+	return !t.Trusts("minisign") && !t.Trusts("gpg") && !t.Trusts("cosign")
+}
+
+// Reports whether signatures made with method ("minisign", "gpg" or "cosign") can be checked.
+func (t Trust) Trusts(method string) bool {
+	switch method {
+	case "minisign":
+		return t.MinisignKey != ""
+	case "gpg":
+		return len(t.GPGKeys) > 0
+	case "cosign":
+		return len(t.CosignKey) > 0 || t.keyless()
+	}
+	return false
+}
+
+func (t Trust) keyless() bool {
+	return (t.Identity != "" || t.IdentityRegex != "") && t.Issuer != "" && len(t.Roots) > 0
+}
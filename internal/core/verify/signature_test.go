@@ -0,0 +1,289 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/blake2b"
+)
+
+func writeAsset(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tool.tar.gz")
+	os.WriteFile(path, []byte(content), 0o644)
+	return path
+}
+
+// returns a minisign public key and a function that signs the way "minisign -S" does
+func minisignKey(t *testing.T) (string, func(data []byte, prehash bool) []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	pubKey := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...)) + "\n"
+
+	sign := func(data []byte, prehash bool) []byte {
+		algo, msg := "Ed", data
+		if prehash {
+			sum := blake2b.Sum512(data)
+			algo, msg = "ED", sum[:]
+		}
+		sig := ed25519.Sign(priv, msg)
+		comment := "timestamp:1700000000\tfile:tool.tar.gz"
+		global := ed25519.Sign(priv, append(bytes.Clone(sig), comment...))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte(algo), keyID...), sig...)) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+	return pubKey, sign
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	path := writeAsset(t, "release contents")
+	pubKey, sign := minisignKey(t)
+
+	for _, prehash := range []bool{true, false} {
+		signer, err := VerifyMinisign(path, sign([]byte("release contents"), prehash), pubKey)
+		if err != nil {
+			t.Fatalf("VerifyMinisign(prehash=%v) error: %v", prehash, err)
+		}
+		if signer.Method != "minisign" || signer.Identity != "0807060504030201" {
+			t.Errorf("VerifyMinisign() signer = %v, want minisign 0807060504030201", signer)
+		}
+	}
+
+	// the bare key works as well as the contents of the .pub file
+	bareKey := strings.Split(strings.TrimSpace(pubKey), "\n")[1]
+	if _, err := VerifyMinisign(path, sign([]byte("release contents"), true), bareKey); err != nil {
+		t.Errorf("VerifyMinisign() with the bare key error: %v", err)
+	}
+
+	if _, err := VerifyMinisign(path, sign([]byte("tampered"), true), pubKey); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("VerifyMinisign() of another file error = %v, want ErrSignatureInvalid", err)
+	}
+
+	otherKey, _ := minisignKey(t)
+	if _, err := VerifyMinisign(path, sign([]byte("release contents"), true), otherKey); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("VerifyMinisign() with another key error = %v, want ErrSignatureInvalid", err)
+	}
+
+	sig := sign([]byte("release contents"), true)
+	tampered := bytes.Replace(sig, []byte("file:tool.tar.gz"), []byte("file:evil.tar.gz"), 1)
+	if _, err := VerifyMinisign(path, tampered, pubKey); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("VerifyMinisign() with a tampered trusted comment error = %v, want ErrSignatureInvalid", err)
+	}
+}
+
+func TestVerifyGPG(t *testing.T) {
+	path := writeAsset(t, "release contents")
+	entity, err := openpgp.NewEntity("Release Bot", "", "release@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys, armored, binary bytes.Buffer
+	entity.Serialize(&keys)
+	openpgp.ArmoredDetachSign(&armored, entity, strings.NewReader("release contents"), nil)
+	openpgp.DetachSign(&binary, entity, strings.NewReader("release contents"), nil)
+
+	for name, sig := range map[string][]byte{"armored": armored.Bytes(), "binary": binary.Bytes()} {
+		signer, err := VerifyGPG(path, sig, keys.Bytes())
+		if err != nil {
+			t.Fatalf("VerifyGPG(%s) error: %v", name, err)
+		}
+		if !strings.Contains(signer.Identity, "release@example.com") {
+			t.Errorf("VerifyGPG(%s) identity = %q, want the key's user ID", name, signer.Identity)
+		}
+	}
+
+	other, _ := openpgp.NewEntity("Someone Else", "", "else@example.com", nil)
+	var otherKeys bytes.Buffer
+	other.Serialize(&otherKeys)
+	if _, err := VerifyGPG(path, armored.Bytes(), otherKeys.Bytes()); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("VerifyGPG() with another key error = %v, want ErrSignatureInvalid", err)
+	}
+
+	tampered := writeAsset(t, "tampered")
+	if _, err := VerifyGPG(tampered, armored.Bytes(), keys.Bytes()); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("VerifyGPG() of another file error = %v, want ErrSignatureInvalid", err)
+	}
+}
+
+func signBlob(t *testing.T, key *ecdsa.PrivateKey, data string) []byte {
+	t.Helper()
+	digest := sha256.Sum256([]byte(data))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestVerifyCosign_Key(t *testing.T) {
+	path := writeAsset(t, "release contents")
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	trust := Trust{CosignKey: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})}
+
+	sig := []byte(base64.StdEncoding.EncodeToString(signBlob(t, key, "release contents")))
+	signer, err := VerifyCosign(path, sig, nil, trust)
+	if err != nil {
+		t.Fatalf("VerifyCosign() error: %v", err)
+	}
+	if !strings.HasPrefix(signer.Identity, "sha256:") {
+		t.Errorf("VerifyCosign() identity = %q, want the key fingerprint", signer.Identity)
+	}
+
+	bad := []byte(base64.StdEncoding.EncodeToString(signBlob(t, key, "tampered")))
+	if _, err := VerifyCosign(path, bad, nil, trust); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("VerifyCosign() of another file error = %v, want ErrSignatureInvalid", err)
+	}
+	if _, err := VerifyCosign(path, sig, nil, Trust{}); err == nil {
+		t.Error("VerifyCosign() without a key should fail")
+	}
+}
+
+const testIssuer = "https://token.actions.githubusercontent.com"
+
+// issues a Fulcio-like signing certificate for identity, returning the trusted root and the leaf's key
+func fulcio(t *testing.T, identity, issuer string, exts ...pkix.Extension) (rootPEM, leafPEM []byte, key *ecdsa.PrivateKey) {
+	t.Helper()
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, root, root, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ = x509.ParseCertificate(rootDER)
+
+	key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	uri, _ := url.Parse(identity)
+	issuerExt, _ := asn1.MarshalWithParams(issuer, "utf8")
+	leaf := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(9 * time.Minute),
+		URIs:            []*url.URL{uri},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: append([]pkix.Extension{{Id: oidIssuerV2, Value: issuerExt}}, exts...),
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leaf, root, &key.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER}), key
+}
+
+func TestVerifyCosign_Keyless(t *testing.T) {
+	path := writeAsset(t, "release contents")
+	identity := "https://github.com/owner/tool/.github/workflows/release.yml@refs/tags/v1.0.0"
+	root, leaf, key := fulcio(t, identity, testIssuer)
+	sig := []byte(base64.StdEncoding.EncodeToString(signBlob(t, key, "release contents")))
+	// cosign writes the certificate base64 encoded
+	cert := []byte(base64.StdEncoding.EncodeToString(leaf))
+
+	tests := []struct {
+		name  string
+		trust Trust
+		ok    bool
+	}{
+		{"identity", Trust{Identity: identity, Issuer: testIssuer, Roots: root}, true},
+		{"identity regex", Trust{IdentityRegex: `^https://github\.com/owner/tool/`, Issuer: testIssuer, Roots: root}, true},
+		{"other identity", Trust{Identity: "https://github.com/evil/tool/.github/workflows/release.yml@refs/tags/v1.0.0", Issuer: testIssuer, Roots: root}, false},
+		{"other issuer", Trust{Identity: identity, Issuer: "https://accounts.google.com", Roots: root}, false},
+		{"untrusted root", Trust{Identity: identity, Issuer: testIssuer, Roots: func() []byte { r, _, _ := fulcio(t, identity, testIssuer); return r }()}, false},
+		{"no identity", Trust{Issuer: testIssuer, Roots: root}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := VerifyCosign(path, sig, cert, tt.trust)
+			if tt.ok != (err == nil) {
+				t.Fatalf("VerifyCosign() error = %v, want ok = %v", err, tt.ok)
+			}
+			if tt.ok && signer.Identity != identity+" ("+testIssuer+")" {
+				t.Errorf("VerifyCosign() identity = %q", signer.Identity)
+			}
+		})
+	}
+
+	// a PEM certificate works too
+	trust := Trust{Identity: identity, Issuer: testIssuer, Roots: root}
+	if _, err := VerifyCosign(path, sig, leaf, trust); err != nil {
+		t.Errorf("VerifyCosign() with a PEM certificate error: %v", err)
+	}
+	bad := []byte(base64.StdEncoding.EncodeToString(signBlob(t, key, "tampered")))
+	if _, err := VerifyCosign(path, bad, cert, trust); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("VerifyCosign() of another file error = %v, want ErrSignatureInvalid", err)
+	}
+}
+
+func TestVerifyBundle(t *testing.T) {
+	path := writeAsset(t, "release contents")
+	identity := "https://github.com/owner/tool/.github/workflows/release.yml@refs/tags/v1.0.0"
+	root, leaf, key := fulcio(t, identity, testIssuer)
+	trust := Trust{Identity: identity, Issuer: testIssuer, Roots: root}
+	rawSig := signBlob(t, key, "release contents")
+	block, _ := pem.Decode(leaf)
+	digest := sha256.Sum256([]byte("release contents"))
+
+	legacy, _ := json.Marshal(map[string]any{
+		"base64Signature": base64.StdEncoding.EncodeToString(rawSig),
+		"cert":            base64.StdEncoding.EncodeToString(leaf),
+	})
+	sigstore, _ := json.Marshal(map[string]any{
+		"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"verificationMaterial": map[string]any{
+			"certificate": map[string]any{"rawBytes": block.Bytes},
+		},
+		"messageSignature": map[string]any{
+			"messageDigest": map[string]any{"algorithm": "SHA2_256", "digest": digest[:]},
+			"signature":     rawSig,
+		},
+	})
+
+	for name, bundle := range map[string][]byte{"cosign": legacy, "sigstore": sigstore} {
+		if _, err := VerifyBundle(path, bundle, trust); err != nil {
+			t.Errorf("VerifyBundle(%s) error: %v", name, err)
+		}
+		if _, err := VerifyBundle(writeAsset(t, "tampered"), bundle, trust); !errors.Is(err, ErrSignatureInvalid) {
+			t.Errorf("VerifyBundle(%s) of another file error = %v, want ErrSignatureInvalid", name, err)
+		}
+	}
+
+	dsse, _ := json.Marshal(map[string]any{"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json", "dsseEnvelope": map[string]any{}})
+	if _, err := VerifyBundle(path, dsse, trust); err == nil {
+		t.Error("VerifyBundle() with a DSSE envelope should fail")
+	}
+}
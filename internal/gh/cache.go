@@ -0,0 +1,163 @@
+package gh
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// on-disk copy of a response, replayed when GitHub answers a conditional request with 304 Not Modified
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// Caches GET responses from repo endpoints that carry an ETag or Last-Modified header, and revalidates them
+// with conditional requests. GitHub doesn't count 304 responses against the rate limit.
+type cacheTransport struct {
+	base http.RoundTripper
+	dir  string
+	// responses can differ between tokens, e.g. for private repos
+	tokenHash string
+}
+
+func newCacheTransport(base http.RoundTripper, dir, token string) *cacheTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	sum := sha256.Sum256([]byte(token))
+	return &cacheTransport{
+		base:      base,
+		dir:       dir,
+		tokenHash: hex.EncodeToString(sum[:]),
+	}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	path := t.entryPath(req)
+	entry := readCacheEntry(path)
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastMod := entry.Header.Get("Last-Modified"); lastMod != "" {
+			req.Header.Set("If-Modified-Since", lastMod)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return entry.response(req, resp.Header), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// a failed write only means the next request isn't conditional
+	_ = writeCacheEntry(path, &cacheEntry{
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	})
+	return resp, nil
+}
+
+// only API lookups are cached, not release asset downloads
+func cacheable(req *http.Request) bool {
+	return req.Method == http.MethodGet &&
+		strings.HasPrefix(req.URL.Path, "/repos/") &&
+		req.Header.Get("Accept") != "application/octet-stream"
+}
+
+func (t *cacheTransport) entryPath(req *http.Request) string {
+	sum := sha256.Sum256([]byte(t.tokenHash + "\n" + req.Header.Get("Accept") + "\n" + req.URL.String()))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// fresh headers, e.g. the current rate limit, take precedence over the cached ones
+func (e *cacheEntry) response(req *http.Request, fresh http.Header) *http.Response {
+	header := e.Header.Clone()
+	for k, v := range fresh {
+		header[k] = v
+	}
+	return &http.Response{
+		Status:        http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func readCacheEntry(path string) *cacheEntry {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+func writeCacheEntry(path string, entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Removes every cached response.
+func CleanCache(dir string) error {
+	return os.RemoveAll(dir)
+}
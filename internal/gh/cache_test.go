@@ -0,0 +1,110 @@
+package gh
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+)
+
+func newCachedTestClient(t *testing.T, handler http.HandlerFunc) (*github.Client, string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	provider := New(context.Background(), "", WithHTTPClient(&http.Client{}), WithCache(dir))
+	cli := provider.(*client).c
+	cli.BaseURL, _ = url.Parse(server.URL + "/")
+	return cli, dir
+}
+
+func TestCacheTransport_Revalidates(t *testing.T) {
+	var requests, notModified atomic.Int32
+	cli, _ := newCachedTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "59")
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		w.Write([]byte(`{"tag_name":"v1.0.0"}`))
+	})
+
+	ctx := context.Background()
+	for range 2 {
+		rel, _, err := cli.Repositories.GetLatestRelease(ctx, "owner", "repo")
+		if err != nil {
+			t.Fatalf("GetLatestRelease() error: %v", err)
+		}
+		if rel.GetTagName() != "v1.0.0" {
+			t.Errorf("TagName = %v, want v1.0.0", rel.GetTagName())
+		}
+	}
+
+	if requests.Load() != 2 {
+		t.Errorf("server got %d requests, want 2", requests.Load())
+	}
+	if notModified.Load() != 1 {
+		t.Errorf("server answered %d conditional requests, want 1", notModified.Load())
+	}
+}
+
+func TestCacheTransport_SkipsResponsesWithoutValidators(t *testing.T) {
+	var conditional atomic.Int32
+	cli, _ := newCachedTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			conditional.Add(1)
+		}
+		w.Write([]byte(`{"tag_name":"v1.0.0"}`))
+	})
+
+	ctx := context.Background()
+	for range 2 {
+		if _, _, err := cli.Repositories.GetLatestRelease(ctx, "owner", "repo"); err != nil {
+			t.Fatalf("GetLatestRelease() error: %v", err)
+		}
+	}
+	if conditional.Load() != 0 {
+		t.Error("responses without an ETag or Last-Modified header should not be revalidated")
+	}
+}
+
+func TestCacheTransport_SeparatesTokens(t *testing.T) {
+	dir := t.TempDir()
+	req := httptest.NewRequest(http.MethodGet, "https://api.github.com/repos/owner/repo/releases/latest", nil)
+
+	a := newCacheTransport(nil, dir, "token-a")
+	b := newCacheTransport(nil, dir, "token-b")
+	if a.entryPath(req) == b.entryPath(req) {
+		t.Error("responses for different tokens should be cached separately")
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		accept string
+		want   bool
+	}{
+		{http.MethodGet, "/repos/owner/repo/releases/latest", "application/vnd.github.v3+json", true},
+		{http.MethodGet, "/repos/owner/repo/releases/assets/1", "application/octet-stream", false},
+		{http.MethodPost, "/repos/owner/repo/releases", "", false},
+		{http.MethodGet, "/search/repositories", "", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "https://api.github.com"+tt.path, nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := cacheable(req); got != tt.want {
+			t.Errorf("cacheable(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
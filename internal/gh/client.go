@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/google/go-github/v74/github"
+	"github.com/shurcooL/githubv4"
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
@@ -13,19 +14,30 @@ import (
 type Provider interface {
 	Repos() *github.RepositoriesService
 	Search() *github.SearchService
+	// current API quota of the token; doesn't count against it
+	RateLimits(ctx context.Context) (*github.RateLimits, error)
+	ReleaseLookup
 }
 
 type client struct {
 	c *github.Client
+	// only set with a token, the GraphQL API doesn't allow unauthenticated requests
+	v4 *githubv4.Client
 }
 
 func (cli *client) Repos() *github.RepositoriesService { return cli.c.Repositories }
 func (cli *client) Search() *github.SearchService      { return cli.c.Search }
 
+func (cli *client) RateLimits(ctx context.Context) (*github.RateLimits, error) {
+	limits, _, err := cli.c.RateLimit.Get(ctx)
+	return limits, err
+}
+
 type Option func(*clientOptions)
 
 type clientOptions struct {
-	hc *http.Client
+	hc       *http.Client
+	cacheDir string
 }
 
 func WithHTTPClient(hc *http.Client) Option {
@@ -34,6 +46,13 @@ func WithHTTPClient(hc *http.Client) Option {
 	}
 }
 
+// Caches API responses in dir and revalidates them with conditional requests.
+func WithCache(dir string) Option {
+	return func(c *clientOptions) {
+		c.cacheDir = dir
+	}
+}
+
 func New(ctx context.Context, token string, opts ...Option) Provider {
 	var cliOpts clientOptions
 	for _, opt := range opts {
@@ -46,10 +65,20 @@ func New(ctx context.Context, token string, opts ...Option) Provider {
 		hc = oauth2.NewClient(ctx, src)
 	}
 
-	cli := github.NewClient(hc)
-	return &client{
-		c: cli,
+	wrapped := *hc
+	wrapped.Transport = newRateLimitTransport(hc.Transport)
+	if cliOpts.cacheDir != "" {
+		wrapped.Transport = newCacheTransport(wrapped.Transport, cliOpts.cacheDir, token)
+	}
+	hc = &wrapped
+
+	cli := &client{
+		c: github.NewClient(hc),
+	}
+	if token != "" {
+		cli.v4 = githubv4.NewClient(hc)
 	}
+	return cli
 }
 
 // returns the current API key, or nil if there is none
@@ -0,0 +1,149 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+)
+
+const (
+	// GitHub asks clients to wait at least a minute after hitting a secondary rate limit without a Retry-After header
+	defaultSecondaryWait = time.Minute
+	maxSecondaryWait     = 5 * time.Minute
+	maxSecondaryRetries  = 3
+)
+
+// Retries requests that hit a secondary rate limit once GitHub allows it.
+// Primary rate limits are passed through, since waiting them out can take up to an hour.
+type rateLimitTransport struct {
+	base  http.RoundTripper
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{
+		base:  base,
+		sleep: sleepCtx,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, ok := secondaryRateLimitWait(resp)
+		// requests with a body can only be retried if it can be read again
+		canRetry := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if !ok || !canRetry || attempt >= maxSecondaryRetries || wait > maxSecondaryWait {
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// reports how long to wait before retrying a response that hit a secondary rate limit
+func secondaryRateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	// an exhausted primary limit
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, false
+	}
+
+	if after := resp.Header.Get("Retry-After"); after != "" {
+		secs, err := strconv.Atoi(after)
+		if err != nil || secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	// plain permission errors are also 403s, so check what GitHub says
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil || !strings.Contains(strings.ToLower(string(body)), "secondary rate limit") {
+		return 0, false
+	}
+	return defaultSecondaryWait, true
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Returns an error if fewer than needed core API requests are left for the current token.
+func EnsureBudget(ctx context.Context, p Provider, needed int) error {
+	if needed <= 0 {
+		return nil
+	}
+	limits, err := p.RateLimits(ctx)
+	if err != nil {
+		// the check is best-effort, the requests themselves will still fail if the limit is hit
+		return nil
+	}
+	core := limits.GetCore()
+	if core == nil || core.Remaining >= needed {
+		return nil
+	}
+	return fmt.Errorf("not enough GitHub API requests left: need about %d, have %d of %d until %s\n%s",
+		needed, core.Remaining, core.Limit, core.Reset.Local().Format(time.Kitchen), tokenHint(core.Limit))
+}
+
+// Adds an explanation to errors caused by GitHub rate limits. Other errors are returned as is.
+func ExplainRateLimit(err error) error {
+	var rlErr *github.RateLimitError
+	if errors.As(err, &rlErr) {
+		return fmt.Errorf("GitHub API rate limit of %d requests exceeded, resets at %s\n%s: \n%w",
+			rlErr.Rate.Limit, rlErr.Rate.Reset.Local().Format(time.Kitchen), tokenHint(rlErr.Rate.Limit), err)
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return fmt.Errorf("GitHub API secondary rate limit hit, try again in a few minutes or with fewer --jobs: \n%w", err)
+	}
+	return err
+}
+
+// unauthenticated clients get 60 requests an hour
+func tokenHint(limit int) string {
+	if limit > 60 {
+		return "wait for the limit to reset and try again"
+	}
+	return "set a GitHub token in PARM_GITHUB_TOKEN, GITHUB_TOKEN or GH_TOKEN to raise the limit"
+}
@@ -0,0 +1,162 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+)
+
+type stepTransport struct {
+	responses []func() *http.Response
+	calls     int
+}
+
+func (s *stepTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := s.responses[min(s.calls, len(s.responses)-1)]()
+	s.calls++
+	resp.Request = req
+	return resp, nil
+}
+
+func respond(status int, header map[string]string, body string) func() *http.Response {
+	return func() *http.Response {
+		h := http.Header{}
+		for k, v := range header {
+			h.Set(k, v)
+		}
+		return &http.Response{StatusCode: status, Header: h, Body: io.NopCloser(strings.NewReader(body))}
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		first     func() *http.Response
+		wantCalls int
+		wantWait  time.Duration
+	}{
+		{
+			name:      "retries after Retry-After",
+			first:     respond(http.StatusForbidden, map[string]string{"Retry-After": "3"}, ""),
+			wantCalls: 2,
+			wantWait:  3 * time.Second,
+		},
+		{
+			name:      "retries secondary limit without Retry-After",
+			first:     respond(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit."}`),
+			wantCalls: 2,
+			wantWait:  defaultSecondaryWait,
+		},
+		{
+			name:      "doesn't wait out primary limit",
+			first:     respond(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, ""),
+			wantCalls: 1,
+		},
+		{
+			name:      "doesn't retry permission errors",
+			first:     respond(http.StatusForbidden, nil, `{"message":"Resource not accessible"}`),
+			wantCalls: 1,
+		},
+		{
+			name:      "doesn't wait too long",
+			first:     respond(http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}, ""),
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &stepTransport{responses: []func() *http.Response{tt.first, respond(http.StatusOK, nil, "{}")}}
+			var waited time.Duration
+			tr := newRateLimitTransport(base)
+			tr.sleep = func(ctx context.Context, d time.Duration) error {
+				waited += d
+				return nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "https://api.github.com/repos/owner/repo", nil)
+			if _, err := tr.RoundTrip(req); err != nil {
+				t.Fatalf("RoundTrip() error: %v", err)
+			}
+			if base.calls != tt.wantCalls {
+				t.Errorf("sent %d requests, want %d", base.calls, tt.wantCalls)
+			}
+			if waited != tt.wantWait {
+				t.Errorf("waited %v, want %v", waited, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransport_GivesUp(t *testing.T) {
+	base := &stepTransport{responses: []func() *http.Response{
+		respond(http.StatusForbidden, map[string]string{"Retry-After": "1"}, ""),
+	}}
+	tr := newRateLimitTransport(base)
+	tr.sleep = func(context.Context, time.Duration) error { return nil }
+
+	req := httptest.NewRequest(http.MethodGet, "https://api.github.com/repos/owner/repo", nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error: %v", err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	if base.calls != maxSecondaryRetries+1 {
+		t.Errorf("sent %d requests, want %d", base.calls, maxSecondaryRetries+1)
+	}
+}
+
+func newRateLimitProvider(t *testing.T, remaining int) Provider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"resources":{"core":{"limit":60,"remaining":` + strconv.Itoa(remaining) + `,"reset":1700000000}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	p := New(context.Background(), "", WithHTTPClient(&http.Client{}))
+	p.(*client).c.BaseURL, _ = url.Parse(server.URL + "/")
+	return p
+}
+
+func TestEnsureBudget(t *testing.T) {
+	p := newRateLimitProvider(t, 5)
+	ctx := context.Background()
+
+	if err := EnsureBudget(ctx, p, 5); err != nil {
+		t.Errorf("EnsureBudget() error with enough quota: %v", err)
+	}
+	err := EnsureBudget(ctx, p, 6)
+	if err == nil {
+		t.Fatal("EnsureBudget() should fail without enough quota")
+	}
+	if !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("EnsureBudget() error should suggest setting a token, got: %v", err)
+	}
+}
+
+func TestExplainRateLimit(t *testing.T) {
+	rlErr := &github.RateLimitError{Rate: github.Rate{Limit: 60}, Message: "API rate limit exceeded"}
+	err := ExplainRateLimit(rlErr)
+	if !errors.Is(err, rlErr) {
+		t.Error("ExplainRateLimit() should wrap the original error")
+	}
+	if !strings.Contains(err.Error(), "rate limit of 60") {
+		t.Errorf("ExplainRateLimit() = %v", err)
+	}
+
+	other := errors.New("boom")
+	if ExplainRateLimit(other) != other {
+		t.Error("ExplainRateLimit() should return other errors as is")
+	}
+}
@@ -0,0 +1,119 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// Returned by LatestReleases when the GraphQL API can't be used, e.g. without a token.
+var ErrBulkUnavailable = errors.New("bulk release lookups need a GitHub token")
+
+// number of repos looked up per GraphQL query
+const bulkChunkSize = 50
+
+type RepoRef struct {
+	Owner string
+	Repo  string
+}
+
+// The newest releases of a repo on each channel, nil if there is none.
+type RepoReleases struct {
+	Stable     *github.RepositoryRelease
+	PreRelease *github.RepositoryRelease
+}
+
+// Looks up the newest releases of many repos at once.
+type ReleaseLookup interface {
+	// repos that couldn't be looked up are missing from the result
+	LatestReleases(ctx context.Context, repos []RepoRef) (map[RepoRef]*RepoReleases, error)
+}
+
+// no assets, the release to update to is fetched again by its tag before installing
+type gqlRelease struct {
+	TagName      string
+	IsPrerelease bool
+	IsDraft      bool
+	DatabaseID   int64 `graphql:"databaseId"`
+}
+
+type gqlRepo struct {
+	LatestRelease *gqlRelease
+	// only searched for the newest pre-release, which is almost always among the last few releases.
+	// an older one is left out and looked up with the REST API instead
+	Releases struct {
+		Nodes []gqlRelease
+	} `graphql:"releases(first: 10, orderBy: {field: CREATED_AT, direction: DESC})"`
+}
+
+func (cli *client) LatestReleases(ctx context.Context, repos []RepoRef) (map[RepoRef]*RepoReleases, error) {
+	if cli.v4 == nil {
+		return nil, ErrBulkUnavailable
+	}
+
+	res := make(map[RepoRef]*RepoReleases, len(repos))
+	var errs []error
+	for start := 0; start < len(repos); start += bulkChunkSize {
+		chunk := repos[start:min(start+bulkChunkSize, len(repos))]
+		if err := queryReleases(ctx, cli.v4, chunk, res); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(res) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("could not look up releases: \n%w", errors.Join(errs...))
+	}
+	// partial results are fine, callers fall back to the REST API for missing repos
+	return res, nil
+}
+
+// looks up every repo in a single query, with one aliased repository field per repo
+func queryReleases(ctx context.Context, v4 *githubv4.Client, repos []RepoRef, res map[RepoRef]*RepoReleases) error {
+	fields := make([]reflect.StructField, len(repos))
+	vars := make(map[string]any, 2*len(repos))
+	for i, ref := range repos {
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("R%d", i),
+			Type: reflect.TypeFor[*gqlRepo](),
+			Tag:  reflect.StructTag(fmt.Sprintf(`graphql:"r%d: repository(owner: $o%d, name: $n%d)"`, i, i, i)),
+		}
+		vars[fmt.Sprintf("o%d", i)] = githubv4.String(ref.Owner)
+		vars[fmt.Sprintf("n%d", i)] = githubv4.String(ref.Repo)
+	}
+
+	q := reflect.New(reflect.StructOf(fields))
+	// a repo that doesn't exist fails the query, but the other repos are still filled in
+	err := v4.Query(ctx, q.Interface(), vars)
+
+	for i, ref := range repos {
+		repo, _ := q.Elem().Field(i).Interface().(*gqlRepo)
+		if repo == nil {
+			continue
+		}
+		rels := &RepoReleases{}
+		if repo.LatestRelease != nil {
+			rels.Stable = repo.LatestRelease.toRelease()
+		}
+		for _, rel := range repo.Releases.Nodes {
+			if rel.IsPrerelease && !rel.IsDraft {
+				rels.PreRelease = rel.toRelease()
+				break
+			}
+		}
+		res[ref] = rels
+	}
+	return err
+}
+
+func (rel *gqlRelease) toRelease() *github.RepositoryRelease {
+	return &github.RepositoryRelease{
+		ID:         github.Ptr(rel.DatabaseID),
+		TagName:    github.Ptr(rel.TagName),
+		Prerelease: github.Ptr(rel.IsPrerelease),
+		Draft:      github.Ptr(rel.IsDraft),
+	}
+}
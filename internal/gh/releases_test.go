@@ -0,0 +1,104 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/githubv4"
+)
+
+func TestLatestReleases(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Query     string
+			Variables map[string]any
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		query = in.Query
+
+		w.Write([]byte(`{
+			"data": {
+				"r0": {
+					"latestRelease": {
+						"tagName": "v2.0.0",
+						"databaseId": 2
+					},
+					"releases": {"nodes": [
+						{"tagName": "v3.0.0-rc1", "isPrerelease": true, "isDraft": true},
+						{"tagName": "v3.0.0-beta", "isPrerelease": true, "databaseId": 3},
+						{"tagName": "v2.0.0", "isPrerelease": false}
+					]}
+				},
+				"r1": null
+			},
+			"errors": [{"message": "Could not resolve to a Repository with the name 'owner/missing'."}]
+		}`))
+	}))
+	defer server.Close()
+
+	cli := &client{v4: githubv4.NewEnterpriseClient(server.URL, server.Client())}
+	tool := RepoRef{Owner: "owner", Repo: "tool"}
+	missing := RepoRef{Owner: "owner", Repo: "missing"}
+
+	res, err := cli.LatestReleases(context.Background(), []RepoRef{tool, missing})
+	if err != nil {
+		t.Fatalf("LatestReleases() error: %v", err)
+	}
+
+	if !strings.Contains(query, "r0: repository(owner: $o0, name: $n0)") || !strings.Contains(query, "r1: repository(owner: $o1, name: $n1)") {
+		t.Errorf("query doesn't look up both repos: %s", query)
+	}
+
+	rels, ok := res[tool]
+	if !ok {
+		t.Fatal("LatestReleases() is missing owner/tool")
+	}
+	if rels.Stable.GetTagName() != "v2.0.0" {
+		t.Errorf("Stable = %v, want v2.0.0", rels.Stable.GetTagName())
+	}
+	if strings.Contains(query, "releaseAssets") {
+		t.Errorf("query shouldn't fetch release assets: %s", query)
+	}
+	// drafts are skipped
+	if rels.PreRelease.GetTagName() != "v3.0.0-beta" {
+		t.Errorf("PreRelease = %v, want v3.0.0-beta", rels.PreRelease.GetTagName())
+	}
+
+	if _, ok := res[missing]; ok {
+		t.Error("LatestReleases() should leave out repos that couldn't be found")
+	}
+}
+
+func TestLatestReleases_Chunks(t *testing.T) {
+	var queries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	defer server.Close()
+
+	cli := &client{v4: githubv4.NewEnterpriseClient(server.URL, server.Client())}
+	repos := make([]RepoRef, bulkChunkSize+1)
+	for i := range repos {
+		repos[i] = RepoRef{Owner: "owner", Repo: "repo"}
+	}
+	if _, err := cli.LatestReleases(context.Background(), repos); err != nil {
+		t.Fatalf("LatestReleases() error: %v", err)
+	}
+	if queries != 2 {
+		t.Errorf("sent %d queries, want 2", queries)
+	}
+}
+
+func TestLatestReleases_WithoutToken(t *testing.T) {
+	p := New(context.Background(), "", WithHTTPClient(&http.Client{}))
+	if _, err := p.LatestReleases(context.Background(), []RepoRef{{"owner", "repo"}}); !errors.Is(err, ErrBulkUnavailable) {
+		t.Errorf("LatestReleases() error = %v, want %v", err, ErrBulkUnavailable)
+	}
+}
@@ -2,15 +2,19 @@ package manifest
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"parm/internal/parmutil"
 	"parm/pkg/sysutil"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
 const ManifestFileName string = ".curdfile.json"
-const CurrentSchemaVersion int = 2
+const CurrentSchemaVersion int = 3
 
 type InstallType string
 
@@ -28,6 +32,38 @@ type Manifest struct {
 	InstallType   InstallType `json:"install_type"`
 	Version       string      `json:"version"`
 	Pinned        bool        `json:"pinned"`
+	Asset         *Asset      `json:"asset"`
+	Signer        *Signer     `json:"signer"`
+	// how strictly the asset was verified when it was installed, e.g. "checksum". Updates verify as strictly.
+	VerifyLevel string `json:"verify_level"`
+	// the executables to link, by name or path in the version dir. All of them if empty.
+	Bins []string `json:"bins"`
+	// names to link executables under instead of their own, keyed by executable name or path
+	Renames map[string]string `json:"renames"`
+}
+
+// Asset is the release asset a package was installed from.
+type Asset struct {
+	Name   string `json:"name"`
+	ID     int64  `json:"id"`
+	URL    string `json:"url"`
+	Size   int    `json:"size"`
+	Digest string `json:"digest"`
+	// asset name with the release version replaced by placeholders,
+	// used to pick the same asset on later releases
+	Pattern string `json:"pattern"`
+	// the name the executable had in an asset holding nothing but that executable, e.g. "jq-linux-amd64",
+	// if it was installed under a shorter one
+	Executable string `json:"executable"`
+}
+
+// Signer is who signed the release asset of a package, recorded when its signature was verified.
+type Signer struct {
+	// "minisign", "gpg", "cosign" or "attestation"
+	Method   string `json:"method"`
+	Identity string `json:"identity"`
+	// the signature file that was verified, e.g. "checksums.txt.sig", empty for attestations
+	File string `json:"file"`
 }
 
 // TODO: create manifest options struct??
@@ -55,14 +91,76 @@ func New(owner, repo, version string, installType InstallType, installDir string
 
 func (m *Manifest) GetFullExecPaths() []string {
 	var res []string
+	srcPath := parmutil.GetVersionDir(m.Owner, m.Repo, m.Version)
 	for _, path := range m.Executables {
-		srcPath := parmutil.GetInstallDir(m.Owner, m.Repo)
 		newPath := filepath.Join(srcPath, path)
 		res = append(res, newPath)
 	}
 	return res
 }
 
+// Returns the full paths of the executables to link, the ones selected by Bins or all of them.
+func (m *Manifest) GetLinkedExecPaths() []string {
+	srcPath := parmutil.GetVersionDir(m.Owner, m.Repo, m.Version)
+	var res []string
+	for _, path := range m.SelectExecutables() {
+		res = append(res, filepath.Join(srcPath, path))
+	}
+	return res
+}
+
+// Returns the executables, given as paths in the version dir, that Bins selects. All of them if Bins is empty.
+func (m *Manifest) SelectExecutables() []string {
+	if len(m.Bins) == 0 {
+		return m.Executables
+	}
+	var res []string
+	for _, exec := range m.Executables {
+		if slices.ContainsFunc(m.Bins, m.matchesExecutable(exec)) {
+			res = append(res, exec)
+		}
+	}
+	return res
+}
+
+// Returns the entries of Bins that match none of the executables.
+func (m *Manifest) UnmatchedBins() []string {
+	var res []string
+	for _, bin := range m.Bins {
+		if !slices.ContainsFunc(m.Executables, func(exec string) bool { return m.matchesExecutable(exec)(bin) }) {
+			res = append(res, bin)
+		}
+	}
+	return res
+}
+
+// Returns the name to link an executable, given as a path in the version dir, under.
+func (m *Manifest) LinkName(exec string) string {
+	for _, name := range m.executableNames(exec) {
+		if link, ok := m.Renames[name]; ok {
+			return link
+		}
+	}
+	return path.Base(exec)
+}
+
+func (m *Manifest) matchesExecutable(exec string) func(string) bool {
+	return func(bin string) bool {
+		return slices.Contains(m.executableNames(exec), bin)
+	}
+}
+
+// returns what an executable can be selected and renamed by: its path, its name and, if it's a bare
+// executable installed under a shorter name, the name it had in the asset
+func (m *Manifest) executableNames(exec string) []string {
+	names := []string{exec, path.Base(exec)}
+	// a bare executable is the only file of its asset
+	if m.Asset != nil && m.Asset.Executable != "" && len(m.Executables) == 1 {
+		names = append(names, m.Asset.Executable)
+	}
+	return names
+}
+
 func (m *Manifest) Write(installDir string) error {
 	path := filepath.Join(installDir, ManifestFileName)
 	data, err := json.MarshalIndent(m, "", "  ")
@@ -84,20 +182,102 @@ func Read(installDir string) (*Manifest, error) {
 		return nil, err
 	}
 	migrate(&raw)
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
 
 	var m Manifest
 	err = json.Unmarshal(data, &m)
 	return &m, err
 }
 
+// Moves a package installed before side-by-side versions (manifest directly in the package dir)
+// into its own version dir and marks it as the active version. Does nothing for already migrated packages.
+//
+// The package dir is moved whole into a migrating dir next to it, which then replaces it. A migration
+// interrupted in between is finished by FinishLegacyMigration.
+func MigrateLegacyLayout(pkgDir string) error {
+	if _, err := os.Stat(filepath.Join(pkgDir, ManifestFileName)); err != nil {
+		return nil
+	}
+	m, err := Read(pkgDir)
+	if err != nil {
+		return err
+	}
+
+	migrating := filepath.Join(filepath.Dir(pkgDir), parmutil.MIGRATING_DIR_PREFIX+filepath.Base(pkgDir))
+	// left over from a migration interrupted before the package was moved into it
+	if err := os.RemoveAll(migrating); err != nil {
+		return fmt.Errorf("cannot migrate %s: \n%w", pkgDir, err)
+	}
+	if err := os.Mkdir(migrating, 0o755); err != nil {
+		return fmt.Errorf("cannot migrate %s: \n%w", pkgDir, err)
+	}
+	if err := os.Rename(pkgDir, filepath.Join(migrating, parmutil.VersionDirName(m.Version))); err != nil {
+		_ = os.Remove(migrating)
+		return fmt.Errorf("cannot migrate %s: \n%w", pkgDir, err)
+	}
+	return FinishLegacyMigration(migrating)
+}
+
+// Finishes a migration started by MigrateLegacyLayout: marks the moved version as active
+// and puts the migrating dir in place of the package dir.
+func FinishLegacyMigration(migrating string) error {
+	name, ok := strings.CutPrefix(filepath.Base(migrating), parmutil.MIGRATING_DIR_PREFIX)
+	if !ok {
+		return fmt.Errorf("%s is not a migrating dir", migrating)
+	}
+	pkgDir := filepath.Join(filepath.Dir(migrating), name)
+
+	entries, err := os.ReadDir(migrating)
+	if err != nil {
+		return err
+	}
+	var m *Manifest
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if man, err := Read(filepath.Join(migrating, e.Name())); err == nil {
+			m = man
+			break
+		}
+	}
+	if m == nil {
+		// interrupted before the package was moved, it's still in place
+		return os.RemoveAll(migrating)
+	}
+	if _, err := os.Stat(pkgDir); err == nil {
+		return fmt.Errorf("cannot finish migrating %s, it has been installed again since: remove %s to keep the new install", pkgDir, migrating)
+	}
+	if err := parmutil.SetActiveVersion(migrating, m.Version); err != nil {
+		return fmt.Errorf("cannot migrate %s: \n%w", pkgDir, err)
+	}
+	if err := os.Rename(migrating, pkgDir); err != nil {
+		return fmt.Errorf("cannot migrate %s: \n%w", pkgDir, err)
+	}
+	return nil
+}
+
 // INFO: should modify the raw param
 func migrate(raw *(map[string]any)) {
 	version, _ := (*raw)["schema_version"].(float64)
 
-	if version < float64(CurrentSchemaVersion) {
-		(*raw)["schema_version"] = CurrentSchemaVersion
-		(*raw)["pinned"] = false
+	setDefault := func(key string, val any) {
+		if _, ok := (*raw)[key]; !ok {
+			(*raw)[key] = val
+		}
+	}
+
+	if version < 2 {
+		setDefault("pinned", false)
+	}
+	if version < 3 {
+		// asset was not recorded before v3, fall back to asset scoring on update
+		setDefault("asset", nil)
 	}
+	(*raw)["schema_version"] = CurrentSchemaVersion
 }
 
 // TODO: move this out of here? shouldn't really be here
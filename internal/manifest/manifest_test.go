@@ -5,10 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
 	"parm/internal/config"
+	"parm/internal/parmutil"
 )
 
 func TestNew(t *testing.T) {
@@ -184,6 +186,82 @@ func TestManifest_GetFullExecPaths(t *testing.T) {
 	}
 }
 
+func TestSelectExecutables(t *testing.T) {
+	execs := []string{"nvim-linux64/bin/nvim", "nvim-linux64/bin/xxd", "nvim-linux64/lib/helper"}
+
+	tests := []struct {
+		name string
+		bins []string
+		want []string
+	}{
+		{"all", nil, execs},
+		{"by name", []string{"nvim"}, []string{"nvim-linux64/bin/nvim"}},
+		{"by path", []string{"nvim-linux64/lib/helper"}, []string{"nvim-linux64/lib/helper"}},
+		{"no partial paths", []string{"bin/nvim"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manifest{Executables: execs, Bins: tt.bins}
+			if got := m.SelectExecutables(); !slices.Equal(got, tt.want) {
+				t.Errorf("SelectExecutables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	m := &Manifest{Executables: execs, Bins: []string{"nvim", "vim", "bin/xxd"}}
+	if got := m.UnmatchedBins(); !slices.Equal(got, []string{"vim", "bin/xxd"}) {
+		t.Errorf("UnmatchedBins() = %v, want [vim bin/xxd]", got)
+	}
+}
+
+func TestSelectExecutables_BareExecutable(t *testing.T) {
+	// "jq-linux-amd64" installed as "jq"
+	m := &Manifest{
+		Executables: []string{"jq"},
+		Asset:       &Asset{Name: "jq-linux-amd64", Executable: "jq-linux-amd64"},
+		Bins:        []string{"jq-linux-amd64"},
+		Renames:     map[string]string{"jq-linux-amd64": "jq1"},
+	}
+	if got := m.SelectExecutables(); !slices.Equal(got, []string{"jq"}) {
+		t.Errorf("SelectExecutables() = %v, want [jq]", got)
+	}
+	if got := m.UnmatchedBins(); len(got) != 0 {
+		t.Errorf("UnmatchedBins() = %v, want none", got)
+	}
+	if got := m.LinkName("jq"); got != "jq1" {
+		t.Errorf("LinkName() = %q, want the rename of the asset's name", got)
+	}
+
+	// the installed name still works, and wins over the asset's name
+	m.Bins = []string{"jq"}
+	m.Renames = map[string]string{"jq": "jq2", "jq-linux-amd64": "jq1"}
+	if got := m.SelectExecutables(); !slices.Equal(got, []string{"jq"}) {
+		t.Errorf("SelectExecutables() = %v, want [jq]", got)
+	}
+	if got := m.LinkName("jq"); got != "jq2" {
+		t.Errorf("LinkName() = %q, want jq2", got)
+	}
+}
+
+func TestLinkName(t *testing.T) {
+	renames := map[string]string{"tool-linux-amd64": "tool", "lib/helper": "tool-helper"}
+	tests := []struct {
+		exec string
+		want string
+	}{
+		{"tool-linux-amd64", "tool"},
+		{"bin/tool-linux-amd64", "tool"},
+		{"lib/helper", "tool-helper"},
+		{"bin/helper", "helper"},
+	}
+	for _, tt := range tests {
+		m := &Manifest{Executables: []string{tt.exec}, Renames: renames}
+		if got := m.LinkName(tt.exec); got != tt.want {
+			t.Errorf("LinkName(%q) = %q, want %q", tt.exec, got, tt.want)
+		}
+	}
+}
+
 func TestGetBinExecutables(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -251,3 +329,139 @@ func TestGetBinExecutables_WithNonBinaries(t *testing.T) {
 		}
 	}
 }
+
+func TestRead_MigratesOldSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	old := `{
+  "schema_version": 1,
+  "owner": "owner",
+  "repo": "repo",
+  "last_updated": "2025-01-01 12:00:00",
+  "executables": ["bin/app"],
+  "install_type": "release",
+  "version": "v1.0.0"
+}`
+	os.WriteFile(filepath.Join(tmpDir, ManifestFileName), []byte(old), 0644)
+
+	m, err := Read(tmpDir)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+
+	if m.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %v, want %v", m.SchemaVersion, CurrentSchemaVersion)
+	}
+	if m.Pinned {
+		t.Error("Pinned = true, want false")
+	}
+	if m.Asset != nil {
+		t.Errorf("Asset = %+v, want nil", m.Asset)
+	}
+}
+
+func TestWriteRead_Asset(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	m := &Manifest{
+		SchemaVersion: CurrentSchemaVersion,
+		Owner:         "owner",
+		Repo:          "repo",
+		Version:       "v1.0.0",
+		InstallType:   Release,
+		Asset: &Asset{
+			Name:    "repo-1.0.0-linux.tar.gz",
+			ID:      42,
+			URL:     "https://example.com/repo-1.0.0-linux.tar.gz",
+			Size:    1024,
+			Digest:  "sha256:abc",
+			Pattern: "repo-{version}-linux.tar.gz",
+		},
+		Signer: &Signer{Method: "minisign", Identity: "0807060504030201", File: "checksums.txt.minisig"},
+	}
+	if err := m.Write(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(tmpDir)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if got.Asset == nil || *got.Asset != *m.Asset {
+		t.Errorf("Asset = %+v, want %+v", got.Asset, m.Asset)
+	}
+	if got.Signer == nil || *got.Signer != *m.Signer {
+		t.Errorf("Signer = %+v, want %+v", got.Signer, m.Signer)
+	}
+}
+
+func TestMigrateLegacyLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	pkgDir := filepath.Join(tmpDir, "owner", "repo")
+	os.MkdirAll(filepath.Join(pkgDir, "bin"), 0755)
+	os.WriteFile(filepath.Join(pkgDir, "bin", "app"), []byte("app"), 0755)
+
+	m := &Manifest{
+		Owner:       "owner",
+		Repo:        "repo",
+		Version:     "v1.0.0",
+		InstallType: Release,
+		Executables: []string{"bin/app"},
+	}
+	m.Write(pkgDir)
+
+	if err := MigrateLegacyLayout(pkgDir); err != nil {
+		t.Fatalf("MigrateLegacyLayout() error: %v", err)
+	}
+
+	versionDir := filepath.Join(pkgDir, "v1.0.0")
+	if _, err := os.Stat(filepath.Join(versionDir, "bin", "app")); err != nil {
+		t.Errorf("executable was not moved into the version dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pkgDir, ManifestFileName)); !os.IsNotExist(err) {
+		t.Error("manifest still exists in the package dir")
+	}
+	if got := parmutil.GetInstallDir("owner", "repo"); got != versionDir {
+		t.Errorf("GetInstallDir() = %v, want %v", got, versionDir)
+	}
+
+	// migrating twice is a no-op
+	if err := MigrateLegacyLayout(pkgDir); err != nil {
+		t.Fatalf("MigrateLegacyLayout() second run error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "owner", parmutil.MIGRATING_DIR_PREFIX+"repo")); !os.IsNotExist(err) {
+		t.Error("migrating dir was left behind")
+	}
+}
+
+func TestFinishLegacyMigration_NotMoved(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	// interrupted before the package dir was moved, which is still a legacy install
+	pkgDir := filepath.Join(tmpDir, "owner", "repo")
+	os.MkdirAll(pkgDir, 0755)
+	m := &Manifest{Owner: "owner", Repo: "repo", Version: "v1.0.0", InstallType: Release}
+	m.Write(pkgDir)
+	migrating := filepath.Join(tmpDir, "owner", parmutil.MIGRATING_DIR_PREFIX+"repo")
+	os.Mkdir(migrating, 0755)
+
+	if err := FinishLegacyMigration(migrating); err != nil {
+		t.Fatalf("FinishLegacyMigration() error: %v", err)
+	}
+	if _, err := os.Stat(migrating); !os.IsNotExist(err) {
+		t.Error("empty migrating dir was left behind")
+	}
+	if _, err := os.Stat(filepath.Join(pkgDir, ManifestFileName)); err != nil {
+		t.Errorf("legacy install was touched: %v", err)
+	}
+
+	if err := MigrateLegacyLayout(pkgDir); err != nil {
+		t.Fatalf("MigrateLegacyLayout() error: %v", err)
+	}
+	if got := parmutil.GetInstallDir("owner", "repo"); got != filepath.Join(pkgDir, "v1.0.0") {
+		t.Errorf("GetInstallDir() = %v, want the v1.0.0 dir", got)
+	}
+}
@@ -0,0 +1,111 @@
+package parmfile
+
+import (
+	"fmt"
+	"os"
+	"parm/internal/manifest"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const DefaultFileName string = "parmfile.toml"
+
+// Package is a single declared package in a parmfile.
+type Package struct {
+	// owner/repo
+	Name    string               `toml:"name"`
+	Channel manifest.InstallType `toml:"channel"`
+	// empty means the latest version on the channel
+	Version string `toml:"version,omitempty"`
+	Pinned  bool   `toml:"pinned,omitempty"`
+	Asset   string `toml:"asset,omitempty"`
+}
+
+type Parmfile struct {
+	Packages []Package `toml:"package"`
+}
+
+func (p *Package) OwnerRepo() (owner, repo string, err error) {
+	owner, repo, ok := strings.Cut(p.Name, "/")
+	if !ok || owner == "" || repo == "" {
+		return "", "", fmt.Errorf("invalid package name %q, expected <owner>/<repo>", p.Name)
+	}
+	return owner, repo, nil
+}
+
+// FromManifests builds a parmfile that locks every package to its installed version.
+func FromManifests(mans []*manifest.Manifest) *Parmfile {
+	pf := &Parmfile{Packages: make([]Package, 0, len(mans))}
+	for _, man := range mans {
+		pkg := Package{
+			Name:    fmt.Sprintf("%s/%s", man.Owner, man.Repo),
+			Channel: man.InstallType,
+			Version: man.Version,
+			Pinned:  man.Pinned,
+		}
+		if man.Asset != nil {
+			pkg.Asset = man.Asset.Name
+		}
+		pf.Packages = append(pf.Packages, pkg)
+	}
+	slices.SortFunc(pf.Packages, func(a, b Package) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return pf
+}
+
+func Read(path string) (*Parmfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Parmfile, error) {
+	var pf Parmfile
+	if err := toml.Unmarshal(data, &pf); err != nil {
+		return nil, fmt.Errorf("cannot parse parmfile: \n%w", err)
+	}
+	if err := pf.validate(); err != nil {
+		return nil, err
+	}
+	return &pf, nil
+}
+
+func (pf *Parmfile) Marshal() ([]byte, error) {
+	return toml.Marshal(pf)
+}
+
+func (pf *Parmfile) Write(path string) error {
+	data, err := pf.Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (pf *Parmfile) validate() error {
+	seen := make(map[string]bool)
+	for i := range pf.Packages {
+		pkg := &pf.Packages[i]
+		if _, _, err := pkg.OwnerRepo(); err != nil {
+			return err
+		}
+		switch pkg.Channel {
+		case "":
+			pkg.Channel = manifest.Release
+		case manifest.Release, manifest.PreRelease:
+		default:
+			return fmt.Errorf("invalid channel %q for %s", pkg.Channel, pkg.Name)
+		}
+		key := strings.ToLower(pkg.Name)
+		if seen[key] {
+			return fmt.Errorf("duplicate package entry: %s", pkg.Name)
+		}
+		seen[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package parmfile
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"parm/internal/manifest"
+)
+
+func TestFromManifests(t *testing.T) {
+	mans := []*manifest.Manifest{
+		{Owner: "sharkdp", Repo: "fd", Version: "v10.2.0", InstallType: manifest.Release},
+		{Owner: "BurntSushi", Repo: "ripgrep", Version: "14.1.0", InstallType: manifest.Release, Pinned: true},
+	}
+
+	pf := FromManifests(mans)
+	if len(pf.Packages) != 2 {
+		t.Fatalf("FromManifests() returned %d packages, want 2", len(pf.Packages))
+	}
+
+	// sorted case-insensitively by name
+	if pf.Packages[0].Name != "BurntSushi/ripgrep" {
+		t.Errorf("Packages[0].Name = %v, want BurntSushi/ripgrep", pf.Packages[0].Name)
+	}
+	if !pf.Packages[0].Pinned {
+		t.Error("Packages[0].Pinned = false, want true")
+	}
+	if pf.Packages[1].Version != "v10.2.0" {
+		t.Errorf("Packages[1].Version = %v, want v10.2.0", pf.Packages[1].Version)
+	}
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	pf := &Parmfile{
+		Packages: []Package{
+			{Name: "owner/repo", Channel: manifest.PreRelease, Version: "v1.0.0-beta", Asset: "repo-linux.tar.gz"},
+		},
+	}
+
+	if err := pf.Write(path); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(got.Packages) != 1 || got.Packages[0] != pf.Packages[0] {
+		t.Errorf("Read() = %+v, want %+v", got.Packages, pf.Packages)
+	}
+}
+
+func TestParse_DefaultsChannel(t *testing.T) {
+	data := []byte(`
+[[package]]
+name = "owner/repo"
+`)
+	pf, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if pf.Packages[0].Channel != manifest.Release {
+		t.Errorf("Channel = %v, want %v", pf.Packages[0].Channel, manifest.Release)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "missing repo",
+			data:    "[[package]]\nname = \"owner\"\n",
+			wantErr: "invalid package name",
+		},
+		{
+			name:    "bad channel",
+			data:    "[[package]]\nname = \"owner/repo\"\nchannel = \"nightly\"\n",
+			wantErr: "invalid channel",
+		},
+		{
+			name:    "duplicate",
+			data:    "[[package]]\nname = \"owner/repo\"\n[[package]]\nname = \"Owner/Repo\"\n",
+			wantErr: "duplicate package",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
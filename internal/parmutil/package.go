@@ -10,8 +10,20 @@ import (
 
 const STAGING_DIR_PREFIX string = ".staging-"
 
+// prefix of the dir a package installed before side-by-side versions is moved into while it's migrated
+const MIGRATING_DIR_PREFIX string = ".migrating-"
+
+// file in parm_pkg_path marking that every package has been moved to the versioned layout
+const LAYOUT_MIGRATED_FILE string = ".layout-migrated"
+
+// file in a package dir holding the tag of the active version
+const CURRENT_VERSION_FILE string = ".current"
+
+// file in a package dir listing the versions replaced by updates, oldest first
+const GENERATIONS_FILE string = ".generations"
+
 func MakeInstallDir(owner, repo string, perm os.FileMode) (string, error) {
-	path := GetInstallDir(owner, repo)
+	path := GetPkgDir(owner, repo)
 	err := os.MkdirAll(path, perm)
 	if err != nil {
 		return "", fmt.Errorf("cannot create install dir: \n%w", err)
@@ -19,13 +31,91 @@ func MakeInstallDir(owner, repo string, perm os.FileMode) (string, error) {
 	return path, nil
 }
 
-// Generates install directory for a package. Does not guarantee that the directory actually exists.
-func GetInstallDir(owner, repo string) string {
+// Generates the directory holding every installed version of a package. Does not guarantee that the directory actually exists.
+func GetPkgDir(owner, repo string) string {
 	installPath := config.Cfg.ParmPkgPath
 	dest := filepath.Join(installPath, owner, repo)
 	return dest
 }
 
+// Generates the install directory of a single version of a package. Does not guarantee that the directory actually exists.
+func GetVersionDir(owner, repo, version string) string {
+	return filepath.Join(GetPkgDir(owner, repo), VersionDirName(version))
+}
+
+// Returns the install directory of the active version of a package.
+// Falls back to the package dir if no version is active, which is where pre-versioned installs live.
+// Does not guarantee that the directory actually exists.
+func GetInstallDir(owner, repo string) string {
+	return GetActiveDir(GetPkgDir(owner, repo))
+}
+
+// Same as GetInstallDir, but for an already resolved package dir.
+func GetActiveDir(pkgDir string) string {
+	ver, err := readCurrentVersion(pkgDir)
+	if err != nil || ver == "" {
+		return pkgDir
+	}
+	return filepath.Join(pkgDir, VersionDirName(ver))
+}
+
+// Returns the tag of the active version of a package
+func GetCurrentVersion(owner, repo string) (string, error) {
+	ver, err := readCurrentVersion(GetPkgDir(owner, repo))
+	if err != nil {
+		return "", fmt.Errorf("no active version for %s/%s: \n%w", owner, repo, err)
+	}
+	return ver, nil
+}
+
+func SetCurrentVersion(owner, repo, version string) error {
+	return SetActiveVersion(GetPkgDir(owner, repo), version)
+}
+
+// Same as SetCurrentVersion, but for an already resolved package dir.
+func SetActiveVersion(pkgDir, version string) error {
+	path := filepath.Join(pkgDir, CURRENT_VERSION_FILE)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(version+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func readCurrentVersion(pkgDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(pkgDir, CURRENT_VERSION_FILE))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Lists the version dirs of a package, not their tags.
+func ListVersionDirs(owner, repo string) ([]string, error) {
+	entries, err := os.ReadDir(GetPkgDir(owner, repo))
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		dirs = append(dirs, e.Name())
+	}
+	return dirs, nil
+}
+
+// Converts a release tag into something that is safe to use as a single path element.
+func VersionDirName(version string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(version)
+	if name == "" || strings.HasPrefix(name, ".") {
+		// dot-prefixed entries are reserved for parm's own files
+		name = "_" + name
+	}
+	return name
+}
+
 func GetBinDir(repoName string) string {
 	binPath := config.Cfg.ParmBinPath
 	dest := filepath.Join(binPath, repoName)
@@ -45,18 +135,6 @@ func MakeStagingDir(owner, repo string) (string, error) {
 	return tmpDir, nil
 }
 
-func PromoteStagingDir(final, staging string) (string, error) {
-	if fi, err := os.Stat(final); err == nil && fi.IsDir() {
-		if err := os.RemoveAll(final); err != nil {
-			return "", fmt.Errorf("failed removing existing install: %w", err)
-		}
-	}
-	if err := os.Rename(staging, final); err != nil {
-		return "", fmt.Errorf("staging promotion failed: %w", err)
-	}
-	return final, nil
-}
-
 // dir should be the owner dir, at $PKG_ROOT/owner/
 // TODO: fix this mess
 func Cleanup(dir string) error {
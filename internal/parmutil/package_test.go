@@ -148,79 +148,6 @@ func TestMakeStagingDir_UniqueNames(t *testing.T) {
 	os.RemoveAll(dir2)
 }
 
-func TestPromoteStagingDir(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	// Create staging directory
-	stagingDir := filepath.Join(tmpDir, "staging")
-	os.MkdirAll(stagingDir, 0755)
-
-	// Create file in staging
-	testFile := filepath.Join(stagingDir, "test.txt")
-	os.WriteFile(testFile, []byte("test content"), 0644)
-
-	// Promote to final location
-	finalDir := filepath.Join(tmpDir, "final")
-	result, err := PromoteStagingDir(finalDir, stagingDir)
-	if err != nil {
-		t.Fatalf("PromoteStagingDir() error: %v", err)
-	}
-
-	if result != finalDir {
-		t.Errorf("PromoteStagingDir() = %v, want %v", result, finalDir)
-	}
-
-	// Verify final directory exists
-	if _, err := os.Stat(finalDir); os.IsNotExist(err) {
-		t.Error("Final directory was not created")
-	}
-
-	// Verify staging directory no longer exists
-	if _, err := os.Stat(stagingDir); !os.IsNotExist(err) {
-		t.Error("Staging directory still exists after promotion")
-	}
-
-	// Verify file was moved
-	movedFile := filepath.Join(finalDir, "test.txt")
-	content, err := os.ReadFile(movedFile)
-	if err != nil || string(content) != "test content" {
-		t.Error("File was not moved correctly")
-	}
-}
-
-func TestPromoteStagingDir_OverwriteExisting(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	// Create existing final directory
-	finalDir := filepath.Join(tmpDir, "final")
-	os.MkdirAll(finalDir, 0755)
-	oldFile := filepath.Join(finalDir, "old.txt")
-	os.WriteFile(oldFile, []byte("old content"), 0644)
-
-	// Create staging directory
-	stagingDir := filepath.Join(tmpDir, "staging")
-	os.MkdirAll(stagingDir, 0755)
-	newFile := filepath.Join(stagingDir, "new.txt")
-	os.WriteFile(newFile, []byte("new content"), 0644)
-
-	// Promote (should overwrite)
-	_, err := PromoteStagingDir(finalDir, stagingDir)
-	if err != nil {
-		t.Fatalf("PromoteStagingDir() error: %v", err)
-	}
-
-	// Old file should not exist
-	if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
-		t.Error("Old file still exists after promotion")
-	}
-
-	// New file should exist
-	movedFile := filepath.Join(finalDir, "new.txt")
-	if _, err := os.Stat(movedFile); os.IsNotExist(err) {
-		t.Error("New file was not moved")
-	}
-}
-
 func TestCleanup(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -295,3 +222,64 @@ func TestCleanup_EmptyString(t *testing.T) {
 		t.Errorf("Cleanup() returned error for empty string: %v", err)
 	}
 }
+
+func TestGetInstallDir_ActiveVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	// no active version yet, falls back to the package dir
+	if got, want := GetInstallDir("owner", "repo"), GetPkgDir("owner", "repo"); got != want {
+		t.Errorf("GetInstallDir() = %v, want %v", got, want)
+	}
+
+	os.MkdirAll(GetPkgDir("owner", "repo"), 0755)
+	if err := SetCurrentVersion("owner", "repo", "v1.2.0"); err != nil {
+		t.Fatalf("SetCurrentVersion() error: %v", err)
+	}
+
+	ver, err := GetCurrentVersion("owner", "repo")
+	if err != nil {
+		t.Fatalf("GetCurrentVersion() error: %v", err)
+	}
+	if ver != "v1.2.0" {
+		t.Errorf("GetCurrentVersion() = %v, want v1.2.0", ver)
+	}
+
+	want := filepath.Join(tmpDir, "owner", "repo", "v1.2.0")
+	if got := GetInstallDir("owner", "repo"); got != want {
+		t.Errorf("GetInstallDir() = %v, want %v", got, want)
+	}
+}
+
+func TestVersionDirName(t *testing.T) {
+	tests := map[string]string{
+		"v1.0.0":        "v1.0.0",
+		"release/2024":  "release_2024",
+		".hidden":       "_.hidden",
+		"":              "_",
+		`win\style\tag`: "win_style_tag",
+	}
+	for in, want := range tests {
+		if got := VersionDirName(in); got != want {
+			t.Errorf("VersionDirName(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestListVersionDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	config.Cfg.ParmPkgPath = tmpDir
+
+	pkgDir := GetPkgDir("owner", "repo")
+	os.MkdirAll(filepath.Join(pkgDir, "v1.0.0"), 0755)
+	os.MkdirAll(filepath.Join(pkgDir, "v2.0.0"), 0755)
+	os.WriteFile(filepath.Join(pkgDir, CURRENT_VERSION_FILE), []byte("v2.0.0"), 0644)
+
+	dirs, err := ListVersionDirs("owner", "repo")
+	if err != nil {
+		t.Fatalf("ListVersionDirs() error: %v", err)
+	}
+	if len(dirs) != 2 {
+		t.Errorf("ListVersionDirs() = %v, want 2 version dirs", dirs)
+	}
+}
@@ -0,0 +1,46 @@
+// Package testutil holds fixtures shared by the tests of several packages.
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"parm/internal/manifest"
+	"parm/internal/parmutil"
+)
+
+// Installs a version of a package with the given executables into parm_pkg_path, without activating it.
+func InstallPkgVersion(t testing.TB, owner, repo, version string, bins ...string) *manifest.Manifest {
+	t.Helper()
+	dir := parmutil.GetVersionDir(owner, repo, version)
+	os.MkdirAll(dir, 0755)
+	// ELF magic, padded to the minimum header size
+	content := append([]byte{0x7f, 0x45, 0x4c, 0x46}, make([]byte, 60)...)
+	for _, bin := range bins {
+		os.WriteFile(filepath.Join(dir, bin), content, 0755)
+	}
+	man := &manifest.Manifest{
+		Owner:       owner,
+		Repo:        repo,
+		Version:     version,
+		InstallType: manifest.Release,
+		Executables: bins,
+	}
+	if err := man.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	return man
+}
+
+// Fails the test unless the link of bin in parm_bin_path is a symlink to a file in wantDir.
+func AssertLink(t testing.TB, bin, wantDir string) {
+	t.Helper()
+	target, err := os.Readlink(parmutil.GetBinDir(bin))
+	if err != nil {
+		t.Fatalf("link for %s missing: %v", bin, err)
+	}
+	if filepath.Dir(target) != wantDir {
+		t.Errorf("link for %s points to %v, want a file in %v", bin, target, wantDir)
+	}
+}
@@ -0,0 +1,191 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/h2non/filetype"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported asset format")
+
+// number of bytes read from the start of a file to detect its format
+const headerSize = 512
+
+// Format is an asset format that Extract knows how to unpack.
+type Format struct {
+	Name string
+	// reports whether a file starting with header is in this format.
+	// header holds up to 512 bytes, fewer if the file is shorter.
+	Match func(header []byte) bool
+	// unpacks srcPath into destPath
+	Extract func(srcPath, destPath string) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = []Format{
+		{Name: "compressed", Match: isCompressed, Extract: ExtractCompressed},
+		{Name: "zip", Match: isZip, Extract: ExtractZip},
+		{Name: "tar", Match: isTarHeader, Extract: ExtractTar},
+		{Name: "deb", Match: isDeb, Extract: ExtractDeb},
+		{Name: "rpm", Match: isRpm, Extract: ExtractRpm},
+		// AppImages are ELF executables too, so they have to be checked first
+		{Name: "AppImage", Match: isAppImage, Extract: extractAppImage},
+		{Name: "executable", Match: isExecutable, Extract: extractExecutable},
+	}
+)
+
+// Register adds a format to the ones Extract detects. Formats are checked in reverse order of
+// registration, so a registered format takes precedence over the built-in ones it overlaps with.
+func Register(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats = slices.Insert(formats, 0, f)
+}
+
+// Detect returns the format of a file from its magic bytes, regardless of its name.
+func Detect(path string) (*Format, error) {
+	header, err := readHeader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, f := range formats {
+		if f.Match(header) {
+			return &f, nil
+		}
+	}
+
+	kind := "unknown"
+	if t, _ := filetype.Match(header); t != filetype.Unknown {
+		kind = t.Extension
+	}
+	return nil, fmt.Errorf("%w: %s (detected type: %s)", ErrUnsupportedFormat, filepath.Base(path), kind)
+}
+
+// Extract unpacks an asset into destPath. The format is detected from the file's contents,
+// so assets with missing or misleading suffixes are handled the same as correctly named ones.
+func Extract(srcPath, destPath string) error {
+	f, err := Detect(srcPath)
+	if err != nil {
+		return err
+	}
+	if err := f.Extract(srcPath, destPath); err != nil {
+		return fmt.Errorf("failed to extract %s as %s: \n%w", filepath.Base(srcPath), f.Name, err)
+	}
+	return nil
+}
+
+func readHeader(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return header[:n], nil
+}
+
+func isCompressed(header []byte) bool {
+	return detectCompression(header) != None
+}
+
+func isZip(header []byte) bool {
+	// regular and empty archives
+	return bytes.HasPrefix(header, []byte("PK\x03\x04")) || bytes.HasPrefix(header, []byte("PK\x05\x06"))
+}
+
+// tar headers carry "ustar" at offset 257
+func isTarHeader(header []byte) bool {
+	return len(header) >= 262 && string(header[257:262]) == "ustar"
+}
+
+func isExecutable(header []byte) bool {
+	return filetype.IsType(header, filetype.GetType("elf")) ||
+		filetype.IsType(header, filetype.GetType("macho")) ||
+		filetype.IsType(header, filetype.GetType("exe"))
+}
+
+// AppImages carry "AI" and their type (1 or 2) right after the ELF identification bytes
+func isAppImage(header []byte) bool {
+	return filetype.IsType(header, filetype.GetType("elf")) && len(header) >= 11 &&
+		string(header[8:10]) == "AI" && (header[10] == 1 || header[10] == 2)
+}
+
+// ExtractTar unpacks an uncompressed tarball into destPath.
+func ExtractTar(srcPath, destPath string) error {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return extractTar(file, destPath, nil)
+}
+
+// bare executables are placed into destPath as they are
+func extractExecutable(srcPath, destPath string) error {
+	if err := os.MkdirAll(destPath, 0o755); err != nil {
+		return err
+	}
+	target := filepath.Join(destPath, filepath.Base(srcPath))
+	if filepath.Clean(filepath.Dir(srcPath)) != filepath.Clean(destPath) {
+		if err := copyFile(srcPath, target); err != nil {
+			return err
+		}
+	}
+	return os.Chmod(target, 0o755)
+}
+
+// AppImages are self-contained, they are installed like bare executables without their suffix
+func extractAppImage(srcPath, destPath string) error {
+	if err := extractExecutable(srcPath, destPath); err != nil {
+		return err
+	}
+	target := filepath.Join(destPath, filepath.Base(srcPath))
+	name, ok := cutSuffixFold(filepath.Base(srcPath), ".appimage")
+	if !ok || name == "" {
+		return nil
+	}
+	return os.Rename(target, filepath.Join(destPath, name))
+}
+
+func cutSuffixFold(s, suffix string) (string, bool) {
+	if len(s) < len(suffix) || !strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+		return s, false
+	}
+	return s[:len(s)-len(suffix)], true
+}
+
+func copyFile(srcPath, destPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(destPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,134 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtract_IgnoresMisleadingNames(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// a zip named like a tarball
+	zipPath := filepath.Join(tmpDir, "tool.tgz")
+	createTestZip(t, zipPath, map[string]string{"tool": "from zip"})
+	// a gzipped tarball without any suffix
+	tarGzPath := filepath.Join(tmpDir, "tool_Linux_x86_64")
+	os.WriteFile(tarGzPath, compress(t, Gzip, makeTar(t, map[string]string{"tool": "from tar.gz"})), 0o644)
+	// an uncompressed tarball
+	tarPath := filepath.Join(tmpDir, "tool.bin")
+	os.WriteFile(tarPath, makeTar(t, map[string]string{"tool": "from tar"}), 0o644)
+
+	tests := map[string]string{
+		zipPath:   "from zip",
+		tarGzPath: "from tar.gz",
+		tarPath:   "from tar",
+	}
+	for src, want := range tests {
+		t.Run(filepath.Base(src), func(t *testing.T) {
+			dest := filepath.Join(tmpDir, "out-"+filepath.Base(src))
+			if err := Extract(src, dest); err != nil {
+				t.Fatalf("Extract() error: %v", err)
+			}
+			assertFile(t, filepath.Join(dest, "tool"), want)
+		})
+	}
+}
+
+func TestExtract_Executable(t *testing.T) {
+	tmpDir := t.TempDir()
+	elf := append([]byte{0x7f, 0x45, 0x4c, 0x46}, make([]byte, 60)...)
+	src := filepath.Join(tmpDir, "tool-linux-amd64")
+	os.WriteFile(src, elf, 0o644)
+
+	// downloaded straight into the extraction dir
+	if err := Extract(src, tmpDir); err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&0o111 == 0 {
+		t.Error("executable should be made executable")
+	}
+
+	dest := filepath.Join(tmpDir, "out")
+	if err := Extract(src, dest); err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dest, "tool-linux-amd64"))
+	if err != nil || !bytes.Equal(got, elf) {
+		t.Errorf("executable not copied into dest (%v)", err)
+	}
+}
+
+func TestExtract_Unsupported(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "tool.tar.gz")
+	os.WriteFile(src, []byte("#!/bin/sh\necho not an archive\n"), 0o644)
+
+	err := Extract(src, tmpDir)
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Extract() error = %v, want ErrUnsupportedFormat", err)
+	}
+	info, _ := os.Stat(src)
+	if info.Mode()&0o111 != 0 {
+		t.Error("unsupported file should not be made executable")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	saved := formats
+	t.Cleanup(func() { formats = saved })
+
+	var extracted string
+	Register(Format{
+		Name:  "custom",
+		Match: func(header []byte) bool { return bytes.HasPrefix(header, []byte("CUSTOM")) },
+		Extract: func(srcPath, destPath string) error {
+			extracted = srcPath
+			return nil
+		},
+	})
+
+	src := filepath.Join(t.TempDir(), "tool.zip")
+	os.WriteFile(src, []byte("CUSTOM archive"), 0o644)
+	f, err := Detect(src)
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if f.Name != "custom" {
+		t.Errorf("Detect() = %s, want custom", f.Name)
+	}
+	if err := Extract(src, t.TempDir()); err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	if extracted != src {
+		t.Error("registered extractor was not used")
+	}
+}
+
+func TestExtract_AppImage(t *testing.T) {
+	tmpDir := t.TempDir()
+	appImage := append([]byte{0x7f, 0x45, 0x4c, 0x46, 2, 1, 1, 0, 'A', 'I', 2}, make([]byte, 60)...)
+	src := filepath.Join(tmpDir, "Tool-x86_64.AppImage")
+	os.WriteFile(src, appImage, 0o644)
+
+	f, err := Detect(src)
+	if err != nil || f.Name != "AppImage" {
+		t.Fatalf("Detect() = %v, %v, want AppImage", f, err)
+	}
+	if err := Extract(src, tmpDir); err != nil {
+		t.Fatalf("Extract() error: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(tmpDir, "Tool-x86_64"))
+	if err != nil {
+		t.Fatalf("AppImage should be installed without its suffix: %v", err)
+	}
+	if info.Mode()&0o111 == 0 {
+		t.Error("AppImage should be made executable")
+	}
+}
@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+type Compression string
+
+const (
+	None  Compression = ""
+	Gzip  Compression = "gzip"
+	Xz    Compression = "xz"
+	Bzip2 Compression = "bzip2"
+	Zstd  Compression = "zstd"
+)
+
+var compressionMagic = []struct {
+	comp  Compression
+	magic []byte
+}{
+	{Gzip, []byte{0x1f, 0x8b}},
+	{Xz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{Bzip2, []byte{'B', 'Z', 'h'}},
+	{Zstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// suffixes stripped from the name of a compressed single file
+var compressionSuffixes = []string{".gz", ".xz", ".bz2", ".zst", ".zstd"}
+
+// Detects the compression of a file from its magic bytes, regardless of its name.
+func DetectCompression(path string) (Compression, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return None, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 8)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return None, err
+	}
+	return detectCompression(header[:n]), nil
+}
+
+func detectCompression(header []byte) Compression {
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.comp
+		}
+	}
+	return None
+}
+
+func newDecompressor(comp Compression, r io.Reader) (io.ReadCloser, error) {
+	switch comp {
+	case Gzip:
+		return gzip.NewReader(r)
+	case Xz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case Bzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case Zstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %q", comp)
+}
+
+// Extracts a compressed tarball into destPath. A compressed file that isn't a tarball, like a gzipped binary,
+// is decompressed into destPath instead, named after srcPath without its compression suffix.
+func ExtractCompressed(srcPath, destPath string) error {
+	comp, err := DetectCompression(srcPath)
+	if err != nil {
+		return err
+	}
+	if comp == None {
+		return fmt.Errorf("%s is not compressed", filepath.Base(srcPath))
+	}
+
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	dr, err := newDecompressor(comp, file)
+	if err != nil {
+		return fmt.Errorf("cannot read %s stream: \n%w", comp, err)
+	}
+	defer dr.Close()
+
+	br := bufio.NewReaderSize(dr, 1024)
+	if isTar(br) {
+		return extractTar(br, destPath, nil)
+	}
+	return decompressSingle(br, srcPath, destPath)
+}
+
+func isTar(br *bufio.Reader) bool {
+	header, _ := br.Peek(262)
+	return isTarHeader(header)
+}
+
+func decompressSingle(r io.Reader, srcPath, destPath string) error {
+	name := filepath.Base(srcPath)
+	for _, suffix := range compressionSuffixes {
+		if trimmed, ok := cutSuffixFold(name, suffix); ok && trimmed != "" {
+			name = trimmed
+			break
+		}
+	}
+
+	if err := os.MkdirAll(destPath, 0o755); err != nil {
+		return err
+	}
+	// the decompressed file may replace srcPath if it has no suffix to strip
+	tmp, err := os.CreateTemp(destPath, ".decompress-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(destPath, name))
+}
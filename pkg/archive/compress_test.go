@@ -0,0 +1,181 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// tar holding bin/tool with the content "tool bz2\n", compressed with bzip2
+var bzip2Tar = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x16, 0x83,
+	0xdf, 0xad, 0x00, 0x00, 0x7d, 0x7b, 0x90, 0xca, 0x10, 0x00, 0x60, 0x40,
+	0x00, 0xff, 0x80, 0x00, 0x40, 0x70, 0x25, 0x9e, 0x10, 0x04, 0x00, 0x00,
+	0x08, 0x20, 0x00, 0x74, 0x1a, 0x29, 0xb4, 0x80, 0x69, 0xe9, 0x0d, 0xa4,
+	0x1a, 0x64, 0x12, 0x4a, 0x18, 0x81, 0xa6, 0x80, 0x0d, 0x02, 0x67, 0xdc,
+	0x5c, 0xea, 0x50, 0x82, 0x0f, 0x48, 0x48, 0x8d, 0x52, 0x62, 0x70, 0x93,
+	0x3d, 0x02, 0x19, 0x0f, 0xa0, 0x2c, 0xea, 0x4e, 0x4b, 0x46, 0x00, 0x9a,
+	0xf8, 0x99, 0x0c, 0x90, 0xe5, 0xb9, 0xb9, 0x12, 0xa6, 0xe9, 0xc7, 0x34,
+	0x9a, 0x8c, 0x5e, 0x62, 0x4c, 0xbe, 0x50, 0x50, 0xc3, 0xc3, 0x0b, 0x85,
+	0x39, 0x8d, 0x50, 0x57, 0xeb, 0x5b, 0x55, 0x22, 0x20, 0x3f, 0x17, 0x72,
+	0x45, 0x38, 0x50, 0x90, 0x16, 0x83, 0xdf, 0xad,
+}
+
+func makeTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func compress(t *testing.T, comp Compression, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch comp {
+	case Gzip:
+		w = gzip.NewWriter(&buf)
+	case Xz:
+		w, err = xz.NewWriter(&buf)
+	case Zstd:
+		w, err = zstd.NewWriter(&buf)
+	default:
+		t.Fatalf("cannot compress with %s", comp)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read %s: %v", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+	}
+}
+
+func TestExtractCompressed_Tarballs(t *testing.T) {
+	tarData := makeTar(t, map[string]string{"bin/tool": "tool content"})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"tool.tar.gz", compress(t, Gzip, tarData)},
+		{"tool.tar.xz", compress(t, Xz, tarData)},
+		{"tool.tar.zst", compress(t, Zstd, tarData)},
+		// names don't matter, only the content does
+		{"tool_Linux_x86_64", compress(t, Xz, tarData)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			src := filepath.Join(tmpDir, tt.name)
+			os.WriteFile(src, tt.data, 0644)
+
+			dest := filepath.Join(tmpDir, "out")
+			if err := ExtractCompressed(src, dest); err != nil {
+				t.Fatalf("ExtractCompressed() error: %v", err)
+			}
+			assertFile(t, filepath.Join(dest, "bin", "tool"), "tool content")
+		})
+	}
+}
+
+func TestExtractCompressed_Bzip2Tarball(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "tool.tar.bz2")
+	os.WriteFile(src, bzip2Tar, 0644)
+
+	if err := ExtractCompressed(src, tmpDir); err != nil {
+		t.Fatalf("ExtractCompressed() error: %v", err)
+	}
+	assertFile(t, filepath.Join(tmpDir, "bin", "tool"), "tool bz2\n")
+}
+
+func TestExtractCompressed_SingleBinary(t *testing.T) {
+	tests := []struct {
+		name     string
+		comp     Compression
+		wantName string
+	}{
+		{"tool-linux-amd64.gz", Gzip, "tool-linux-amd64"},
+		{"tool-linux-amd64.XZ", Xz, "tool-linux-amd64"},
+		{"tool-linux-amd64.zst", Zstd, "tool-linux-amd64"},
+		{"tool_linux_amd64", Gzip, "tool_linux_amd64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			src := filepath.Join(tmpDir, tt.name)
+			os.WriteFile(src, compress(t, tt.comp, []byte("binary")), 0644)
+
+			if err := ExtractCompressed(src, tmpDir); err != nil {
+				t.Fatalf("ExtractCompressed() error: %v", err)
+			}
+			out := filepath.Join(tmpDir, tt.wantName)
+			assertFile(t, out, "binary")
+
+			fi, _ := os.Stat(out)
+			if fi.Mode().Perm()&0100 == 0 {
+				t.Errorf("decompressed binary is not executable: %v", fi.Mode())
+			}
+		})
+	}
+}
+
+func TestDetectCompression(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want Compression
+	}{
+		{compress(t, Gzip, []byte("x")), Gzip},
+		{compress(t, Xz, []byte("x")), Xz},
+		{compress(t, Zstd, []byte("x")), Zstd},
+		{bzip2Tar, Bzip2},
+		{[]byte{0x7f, 'E', 'L', 'F'}, None},
+		{[]byte{}, None},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "asset")
+		os.WriteFile(path, tt.data, 0644)
+		got, err := DetectCompression(path)
+		if err != nil {
+			t.Fatalf("DetectCompression() error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("DetectCompression() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestExtractCompressed_NotCompressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool")
+	os.WriteFile(path, []byte{0x7f, 'E', 'L', 'F'}, 0755)
+	if err := ExtractCompressed(path, t.TempDir()); err == nil {
+		t.Error("ExtractCompressed() should fail for uncompressed files")
+	}
+}
@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const arMagic = "!<arch>\n"
+
+// debian packages are ar archives whose first member is debian-binary
+func isDeb(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(arMagic+"debian-binary"))
+}
+
+// ExtractDeb unpacks the files installed by a .deb package into destPath, without running any of
+// its maintainer scripts. Paths under /usr are flattened, so /usr/bin/tool is unpacked to bin/tool.
+func ExtractDeb(srcPath, destPath string) error {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	magic := make([]byte, len(arMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != arMagic {
+		return fmt.Errorf("not an ar archive")
+	}
+
+	for {
+		name, size, err := nextArMember(br)
+		if err == io.EOF {
+			return fmt.Errorf("package has no data.tar member")
+		}
+		if err != nil {
+			return err
+		}
+
+		if !strings.HasPrefix(name, "data.tar") {
+			// members are padded to an even size
+			if _, err := br.Discard(int(size + size%2)); err != nil {
+				return err
+			}
+			continue
+		}
+		return extractPayloadTar(io.LimitReader(br, size), destPath)
+	}
+}
+
+// reads the header of the next member of an ar archive
+func nextArMember(r io.Reader) (string, int64, error) {
+	hdr := make([]byte, 60)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", 0, fmt.Errorf("truncated ar archive")
+		}
+		return "", 0, err
+	}
+	if string(hdr[58:60]) != "`\n" {
+		return "", 0, fmt.Errorf("invalid ar member header")
+	}
+	// GNU ar terminates names with a slash
+	name := strings.TrimRight(strings.TrimSpace(string(hdr[0:16])), "/")
+	size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid size of ar member %q", name)
+	}
+	return name, size, nil
+}
+
+// unpacks an optionally compressed tar holding a root filesystem
+func extractPayloadTar(r io.Reader, destPath string) error {
+	br := bufio.NewReaderSize(r, 1024)
+	header, _ := br.Peek(8)
+
+	var tr io.Reader = br
+	if comp := detectCompression(header); comp != None {
+		dr, err := newDecompressor(comp, br)
+		if err != nil {
+			return fmt.Errorf("cannot read %s stream: \n%w", comp, err)
+		}
+		defer dr.Close()
+		tr = dr
+	}
+	return extractTar(tr, destPath, rootfsPath)
+}
@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeAr(t *testing.T, members ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(arMagic)
+	for _, m := range members {
+		fmt.Fprintf(&buf, "%-16s%-12s%-6s%-6s%-8s%-10d`\n", m[0]+"/", "0", "0", "0", "100644", len(m[1]))
+		buf.WriteString(m[1])
+		if len(m[1])%2 == 1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// tarball of a root filesystem with an absolute symlink, like most packages have
+func makeRootfsTar(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "./usr/bin/", Mode: 0755, Typeflag: tar.TypeDir})
+	tw.WriteHeader(&tar.Header{Name: "./usr/bin/tool", Mode: 0755, Size: 4, Typeflag: tar.TypeReg})
+	tw.Write([]byte("tool"))
+	tw.WriteHeader(&tar.Header{Name: "./opt/other/other", Mode: 0755, Size: 5, Typeflag: tar.TypeReg})
+	tw.Write([]byte("other"))
+	tw.WriteHeader(&tar.Header{Name: "./usr/bin/other", Linkname: "/opt/other/other", Typeflag: tar.TypeSymlink})
+	tw.Close()
+	return buf.Bytes()
+}
+
+func TestExtractDeb(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, comp := range []Compression{None, Gzip, Xz, Zstd} {
+		t.Run(string(comp), func(t *testing.T) {
+			data, member := makeRootfsTar(t), "data.tar"
+			if comp != None {
+				data = compress(t, comp, data)
+				member += ".x"
+			}
+			deb := makeAr(t,
+				[2]string{"debian-binary", "2.0\n"},
+				[2]string{"control.tar.gz", string(compress(t, Gzip, makeTar(t, map[string]string{"control": "x"})))},
+				[2]string{member, string(data)},
+			)
+			src := filepath.Join(tmpDir, "tool_1.0_amd64"+string(comp)+".deb")
+			os.WriteFile(src, deb, 0o644)
+
+			dest := filepath.Join(tmpDir, "out-"+string(comp))
+			if err := Extract(src, dest); err != nil {
+				t.Fatalf("Extract() error: %v", err)
+			}
+			assertFile(t, filepath.Join(dest, "bin", "tool"), "tool")
+			// the absolute link is rewritten to stay inside dest
+			assertFile(t, filepath.Join(dest, "bin", "other"), "other")
+			if _, err := os.Stat(filepath.Join(dest, "control")); !os.IsNotExist(err) {
+				t.Error("control files should not be unpacked")
+			}
+		})
+	}
+}
+
+func TestExtractDeb_NoData(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "tool.deb")
+	os.WriteFile(src, makeAr(t, [2]string{"debian-binary", "2.0\n"}), 0o644)
+	if err := Extract(src, t.TempDir()); err == nil {
+		t.Error("Extract() should fail for a package without data")
+	}
+}
+
+func TestRootfsPath(t *testing.T) {
+	tests := map[string]string{
+		"./usr/bin/tool":   "bin/tool",
+		"/usr/share/x":     "share/x",
+		"./usr/":           ".",
+		"./":               ".",
+		"./opt/tool/tool":  "opt/tool/tool",
+		"../../etc/passwd": "etc/passwd",
+	}
+	for in, want := range tests {
+		if got := rootfsPath(in); got != want {
+			t.Errorf("rootfsPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
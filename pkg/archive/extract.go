@@ -25,7 +25,13 @@ func ExtractTarGz(srcPath, destPath string) error {
 	}
 	defer gz.Close()
 
-	tr := tar.NewReader(gz)
+	return extractTar(gz, destPath, nil)
+}
+
+// unpacks a tar stream into destPath. If rename is set, entries are placed at the path it returns
+// instead of the path recorded in the tarball.
+func extractTar(r io.Reader, destPath string, rename func(string) string) error {
+	tr := tar.NewReader(r)
 	for {
 		hdr, err := tr.Next()
 		if err == io.EOF {
@@ -36,6 +42,13 @@ func ExtractTarGz(srcPath, destPath string) error {
 		}
 
 		name := hdr.Name
+		linkTarget := hdr.Linkname
+		if rename != nil {
+			name = rename(name)
+			if hdr.Typeflag == tar.TypeSymlink {
+				linkTarget = relinkAbsolute(name, linkTarget, rename)
+			}
+		}
 		target, err := sysutil.SafeJoin(destPath, name)
 		if err != nil {
 			return err
@@ -47,28 +60,13 @@ func ExtractTarGz(srcPath, destPath string) error {
 				return err
 			}
 		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-				return err
-			}
-			out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fs.FileMode(hdr.Mode))
-			if err != nil {
+			if err := writeFile(target, tr, fs.FileMode(hdr.Mode)); err != nil {
 				return err
 			}
-			if _, err := io.Copy(out, tr); err != nil {
-				out.Close()
-				return err
-			}
-			out.Close()
 		case tar.TypeSymlink:
-			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
-				return err
-			}
-			linkTarget := hdr.Linkname
-			cleanedTarget := filepath.Clean(filepath.Join(filepath.Dir(name), linkTarget))
-			if _, err := sysutil.SafeJoin(destPath, cleanedTarget); err != nil {
+			if err := writeSymlink(destPath, name, linkTarget, target); err != nil {
 				return err
 			}
-			_ = os.Symlink(linkTarget, target)
 		default:
 			// nothing?
 		}
@@ -76,6 +74,34 @@ func ExtractTarGz(srcPath, destPath string) error {
 	return nil
 }
 
+func writeFile(target string, r io.Reader, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// links that point outside of destPath are rejected
+func writeSymlink(destPath, name, linkTarget, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	cleanedTarget := filepath.Clean(filepath.Join(filepath.Dir(name), linkTarget))
+	if _, err := sysutil.SafeJoin(destPath, cleanedTarget); err != nil {
+		return err
+	}
+	_ = os.Symlink(linkTarget, target)
+	return nil
+}
+
 func ExtractZip(srcPath, destPath string) error {
 	r, err := zip.OpenReader(srcPath)
 	if err != nil {
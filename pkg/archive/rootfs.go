@@ -0,0 +1,33 @@
+package archive
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maps a path inside a .deb or .rpm, which is relative to the filesystem root, to where it is unpacked.
+// /usr is flattened so that e.g. /usr/bin/tool ends up in bin/tool, everything else keeps its path.
+func rootfsPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if name == "usr" || name == "" {
+		return "."
+	}
+	if rest, ok := strings.CutPrefix(name, "usr/"); ok {
+		return rest
+	}
+	return name
+}
+
+// packages often link to absolute paths like /opt/tool/tool, which would point outside of the
+// extraction dir. They are turned into links relative to the link's own (renamed) location.
+func relinkAbsolute(name, linkTarget string, rename func(string) string) string {
+	if !path.IsAbs(filepath.ToSlash(linkTarget)) {
+		return linkTarget
+	}
+	rel, err := filepath.Rel(filepath.Dir(name), filepath.FromSlash(rename(linkTarget)))
+	if err != nil {
+		return linkTarget
+	}
+	return rel
+}
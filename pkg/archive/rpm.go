@@ -0,0 +1,210 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"parm/pkg/sysutil"
+)
+
+var (
+	rpmLeadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	rpmHeaderMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+)
+
+const rpmLeadSize = 96
+
+func isRpm(header []byte) bool {
+	return bytes.HasPrefix(header, rpmLeadMagic)
+}
+
+// ExtractRpm unpacks the files installed by a .rpm package into destPath, without running any of
+// its scriptlets. Paths under /usr are flattened, so /usr/bin/tool is unpacked to bin/tool.
+func ExtractRpm(srcPath, destPath string) error {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	if _, err := br.Discard(rpmLeadSize); err != nil {
+		return fmt.Errorf("truncated rpm lead")
+	}
+	// the signature header is padded to a multiple of 8 bytes, the main header isn't
+	if err := skipRpmHeader(br, true); err != nil {
+		return fmt.Errorf("invalid rpm signature header: \n%w", err)
+	}
+	if err := skipRpmHeader(br, false); err != nil {
+		return fmt.Errorf("invalid rpm header: \n%w", err)
+	}
+
+	// the rest of the file is the payload, a cpio archive that is usually compressed
+	header, _ := br.Peek(8)
+	var payload io.Reader = br
+	if comp := detectCompression(header); comp != None {
+		dr, err := newDecompressor(comp, br)
+		if err != nil {
+			return fmt.Errorf("cannot read %s payload: \n%w", comp, err)
+		}
+		defer dr.Close()
+		payload = dr
+	}
+	return extractCpio(payload, destPath, rootfsPath)
+}
+
+func skipRpmHeader(br *bufio.Reader, padded bool) error {
+	hdr := make([]byte, 16)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return err
+	}
+	if !bytes.Equal(hdr[:4], rpmHeaderMagic) {
+		return fmt.Errorf("bad header magic")
+	}
+	entries := int(binary.BigEndian.Uint32(hdr[8:12]))
+	storeSize := int(binary.BigEndian.Uint32(hdr[12:16]))
+
+	size := entries*16 + storeSize
+	if padded {
+		size += (8 - (16+size)%8) % 8
+	}
+	_, err := br.Discard(size)
+	return err
+}
+
+const (
+	cpioHeaderSize = 110
+	cpioTrailer    = "TRAILER!!!"
+	// PATH_MAX, longer names and link targets can't be created anyway
+	cpioMaxPath = 4096
+
+	cpioTypeMask    = 0o170000
+	cpioTypeDir     = 0o040000
+	cpioTypeReg     = 0o100000
+	cpioTypeSymlink = 0o120000
+)
+
+// unpacks a cpio archive in the "newc" format, the one used by rpm payloads
+func extractCpio(r io.Reader, destPath string, rename func(string) string) error {
+	br := bufio.NewReader(r)
+	// hard links only carry the file's data in their last entry
+	pendingLinks := map[uint64][]string{}
+
+	for {
+		hdr := make([]byte, cpioHeaderSize)
+		if _, err := io.ReadFull(br, hdr); err != nil {
+			return fmt.Errorf("truncated cpio archive: \n%w", err)
+		}
+		magic := string(hdr[0:6])
+		if magic != "070701" && magic != "070702" {
+			return fmt.Errorf("unsupported cpio format %q", magic)
+		}
+
+		var fields [13]uint64
+		for i := range fields {
+			v, err := strconv.ParseUint(string(hdr[6+i*8:14+i*8]), 16, 32)
+			if err != nil {
+				return fmt.Errorf("invalid cpio header: \n%w", err)
+			}
+			fields[i] = v
+		}
+		ino, mode, nlink, size, nameSize := fields[0], fields[1], fields[4], int64(fields[6]), int(fields[11])
+
+		// both sizes come straight from the archive, so they're checked before anything is allocated
+		if nameSize == 0 || nameSize > cpioMaxPath {
+			return fmt.Errorf("invalid cpio entry name size %d", nameSize)
+		}
+		if mode&cpioTypeMask == cpioTypeSymlink && size > cpioMaxPath {
+			return fmt.Errorf("invalid cpio link target size %d", size)
+		}
+
+		nameBuf := make([]byte, nameSize)
+		if _, err := io.ReadFull(br, nameBuf); err != nil {
+			return err
+		}
+		if _, err := br.Discard(cpioPadding(cpioHeaderSize + nameSize)); err != nil {
+			return err
+		}
+		name := string(bytes.TrimRight(nameBuf, "\x00"))
+		if name == cpioTrailer {
+			return nil
+		}
+
+		if rename != nil {
+			name = rename(name)
+		}
+		target, err := sysutil.SafeJoin(destPath, name)
+		if err != nil {
+			return err
+		}
+
+		data := &io.LimitedReader{R: br, N: size}
+		switch mode & cpioTypeMask {
+		case cpioTypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case cpioTypeReg:
+			if size == 0 && nlink > 1 {
+				pendingLinks[ino] = append(pendingLinks[ino], target)
+				break
+			}
+			if err := writeFile(target, data, fs.FileMode(mode&0o777)); err != nil {
+				return err
+			}
+			for _, link := range pendingLinks[ino] {
+				if err := linkOrCopy(target, link); err != nil {
+					return err
+				}
+			}
+			delete(pendingLinks, ino)
+		case cpioTypeSymlink:
+			linkTarget, err := io.ReadAll(data)
+			if err != nil {
+				return err
+			}
+			lt := string(linkTarget)
+			if rename != nil {
+				lt = relinkAbsolute(name, lt, rename)
+			}
+			if err := writeSymlink(destPath, name, lt, target); err != nil {
+				return err
+			}
+		default:
+			// device files and the like
+		}
+
+		// skip whatever wasn't read, plus the padding after the data
+		if _, err := io.Copy(io.Discard, data); err != nil {
+			return err
+		}
+		if data.N > 0 {
+			return fmt.Errorf("truncated cpio archive: %s is missing %d bytes", name, data.N)
+		}
+		if _, err := br.Discard(cpioPadding(int(size))); err != nil {
+			return err
+		}
+	}
+}
+
+// cpio entries are aligned to 4 bytes
+func cpioPadding(n int) int {
+	return (4 - n%4) % 4
+}
+
+func linkOrCopy(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+	return copyFile(src, dest)
+}
@@ -0,0 +1,129 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type cpioEntry struct {
+	name  string
+	mode  int
+	ino   int
+	nlink int
+	data  string
+}
+
+func makeCpio(t *testing.T, entries ...cpioEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	pad := func() {
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+	entries = append(entries, cpioEntry{name: cpioTrailer, nlink: 1})
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			e.ino, e.mode, 0, 0, e.nlink, 0, len(e.data), 0, 0, 0, 0, len(e.name)+1, 0)
+		buf.WriteString(e.name + "\x00")
+		pad()
+		buf.WriteString(e.data)
+		pad()
+	}
+	return buf.Bytes()
+}
+
+func makeRpm(t *testing.T, payload []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	lead := make([]byte, rpmLeadSize)
+	copy(lead, rpmLeadMagic)
+	buf.Write(lead)
+
+	// signature header with one index entry and a 5 byte store, padded to 8 bytes
+	buf.Write(rpmHeaderMagic)
+	buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 5})
+	buf.Write(make([]byte, 16+5+3))
+	// main header with an empty index and a 3 byte store
+	buf.Write(rpmHeaderMagic)
+	buf.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3})
+	buf.Write(make([]byte, 3))
+
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+func TestExtractRpm(t *testing.T) {
+	tmpDir := t.TempDir()
+	payload := makeCpio(t,
+		cpioEntry{name: "./usr/bin", mode: cpioTypeDir | 0o755, ino: 1, nlink: 2},
+		cpioEntry{name: "./usr/bin/tool", mode: cpioTypeReg | 0o755, ino: 2, nlink: 1, data: "tool"},
+		// a hard linked pair, only the last entry carries the data
+		cpioEntry{name: "./usr/bin/tool-a", mode: cpioTypeReg | 0o755, ino: 3, nlink: 2},
+		cpioEntry{name: "./usr/bin/tool-b", mode: cpioTypeReg | 0o755, ino: 3, nlink: 2, data: "shared"},
+		cpioEntry{name: "./usr/bin/tool-link", mode: cpioTypeSymlink | 0o777, ino: 4, nlink: 1, data: "/usr/bin/tool"},
+	)
+
+	for _, comp := range []Compression{None, Gzip, Xz, Zstd} {
+		t.Run(string(comp), func(t *testing.T) {
+			data := payload
+			if comp != None {
+				data = compress(t, comp, data)
+			}
+			src := filepath.Join(tmpDir, "tool-1.0-1.x86_64"+string(comp)+".rpm")
+			os.WriteFile(src, makeRpm(t, data), 0o644)
+
+			dest := filepath.Join(tmpDir, "out-"+string(comp))
+			if err := Extract(src, dest); err != nil {
+				t.Fatalf("Extract() error: %v", err)
+			}
+			assertFile(t, filepath.Join(dest, "bin", "tool"), "tool")
+			assertFile(t, filepath.Join(dest, "bin", "tool-a"), "shared")
+			assertFile(t, filepath.Join(dest, "bin", "tool-b"), "shared")
+			assertFile(t, filepath.Join(dest, "bin", "tool-link"), "tool")
+
+			info, err := os.Stat(filepath.Join(dest, "bin", "tool"))
+			if err != nil || info.Mode()&0o111 == 0 {
+				t.Error("file modes should be kept")
+			}
+		})
+	}
+}
+
+func TestExtractRpm_PathTraversal(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "evil.rpm")
+	payload := makeCpio(t, cpioEntry{name: "./usr/bin/evil", mode: cpioTypeSymlink | 0o777, ino: 1, nlink: 1, data: "../../../etc/passwd"})
+	os.WriteFile(src, makeRpm(t, compress(t, Gzip, payload)), 0o644)
+
+	if err := Extract(src, t.TempDir()); err == nil {
+		t.Error("Extract() should reject links escaping the extraction dir")
+	}
+}
+
+func TestExtractRpm_InvalidSizes(t *testing.T) {
+	tool := makeCpio(t, cpioEntry{name: "./usr/bin/tool", mode: cpioTypeReg | 0o755, ino: 1, nlink: 1, data: "tool"})
+	// the name size field comes right before the checksum at the end of the header
+	hugeName := bytes.Clone(tool)
+	copy(hugeName[94:102], "ffffffff")
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{"huge name size", hugeName},
+		{"truncated data", tool[:cpioHeaderSize+16+2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "bad.rpm")
+			os.WriteFile(src, makeRpm(t, compress(t, Gzip, tt.payload)), 0o644)
+
+			if err := Extract(src, t.TempDir()); err == nil {
+				t.Error("Extract() should reject the malformed cpio entry")
+			}
+		})
+	}
+}
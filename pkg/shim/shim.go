@@ -0,0 +1,189 @@
+// Package shim implements scoop-style shims: a tiny launcher executable, copied under the name of a command,
+// that runs the executable named in the ".shim" file next to it.
+package shim
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const Ext string = ".shim"
+
+// name of the launcher, installed next to the parm executable
+const LauncherName string = "parm-shim"
+
+// Shim is what a launcher runs.
+type Shim struct {
+	// the executable to run
+	Path string
+	// arguments passed before the ones the launcher is run with
+	Args []string
+}
+
+// Returns the shim file of a launcher, e.g. "jq.shim" for "jq.exe".
+func FileFor(launcher string) string {
+	ext := filepath.Ext(launcher)
+	if !strings.EqualFold(ext, ".exe") {
+		ext = ""
+	}
+	return strings.TrimSuffix(launcher, ext) + Ext
+}
+
+// Returns the launcher installed next to the running executable.
+func Launcher() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	name := LauncherName + filepath.Ext(exe)
+	path := filepath.Join(filepath.Dir(exe), name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("shim launcher %s not found next to %s, reinstall parm or use another link_mode: \n%w", name, exe, err)
+	}
+	return path, nil
+}
+
+// Writes a shim file in scoop's format: the path as is, in double quotes, and the arguments
+// as a Windows command line, quoted where they need to be:
+//
+//	path = "C:\path\to\tool.exe"
+//	args = --config "C:\My Files\tool.toml"
+func Write(path string, s Shim) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "path = \"%s\"\n", s.Path)
+	if len(s.Args) > 0 {
+		quoted := make([]string, len(s.Args))
+		for i, arg := range s.Args {
+			quoted[i] = quoteArg(arg)
+		}
+		fmt.Fprintf(&b, "args = %s\n", strings.Join(quoted, " "))
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+func Read(path string) (*Shim, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var s Shim
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch strings.TrimSpace(key) {
+		case "path":
+			// paths can't hold double quotes on Windows, so they're never escaped
+			s.Path = strings.Trim(val, `"`)
+		case "args":
+			s.Args = splitArgs(val)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if s.Path == "" {
+		return nil, fmt.Errorf("shim %s has no path", path)
+	}
+	return &s, nil
+}
+
+// Runs the executable the shim of a launcher points at, and returns its exit code.
+func Run(launcher string, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	s, err := Read(FileFor(launcher))
+	if err != nil {
+		return 1, err
+	}
+
+	cmd := exec.Command(s.Path, append(s.Args, args...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// quotes an argument the way Windows programs split their command line
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for _, c := range arg {
+		switch c {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			// backslashes before a quote escape each other, and one more escapes the quote
+			b.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteRune(c)
+	}
+	// the closing quote mustn't be escaped
+	b.WriteString(strings.Repeat(`\`, 2*backslashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// splits a command line into arguments like Windows programs do: on spaces and tabs outside double quotes,
+// with backslashes only escaping quotes and the backslashes right before them
+func splitArgs(line string) []string {
+	var args []string
+	var b strings.Builder
+	inArg, quoted, backslashes := false, false, 0
+	for _, c := range line {
+		switch {
+		case c == '\\':
+			backslashes++
+			inArg = true
+			continue
+		case c == '"':
+			b.WriteString(strings.Repeat(`\`, backslashes/2))
+			if backslashes%2 == 1 {
+				b.WriteRune(c)
+			} else {
+				quoted = !quoted
+			}
+			inArg = true
+		case (c == ' ' || c == '\t') && !quoted:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+			}
+			inArg = false
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+			b.WriteRune(c)
+			inArg = true
+		}
+		backslashes = 0
+	}
+	b.WriteString(strings.Repeat(`\`, backslashes))
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args
+}
@@ -0,0 +1,101 @@
+package shim
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestFileFor(t *testing.T) {
+	tests := map[string]string{
+		"/bin/jq":             "/bin/jq.shim",
+		`C:\bin\jq.exe`:       `C:\bin\jq.shim`,
+		`C:\bin\jq.EXE`:       `C:\bin\jq.shim`,
+		"/bin/tool.v2":        "/bin/tool.v2.shim",
+		"/bin/docker-compose": "/bin/docker-compose.shim",
+	}
+	for launcher, want := range tests {
+		if got := FileFor(launcher); got != want {
+			t.Errorf("FileFor(%q) = %q, want %q", launcher, got, want)
+		}
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool.shim")
+	want := Shim{Path: `C:\Users\me\.parm\pkg\owner\tool\v1.0.0\tool.exe`, Args: []string{"--color", "always"}}
+	if err := Write(path, want); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if got.Path != want.Path || !slices.Equal(got.Args, want.Args) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+
+	// arguments with spaces, quotes and backslashes survive
+	want = Shim{
+		Path: `C:\Program Files\tool\tool.exe`,
+		Args: []string{"--config", `C:\My Files\tool.toml`, `say "hi"`, `C:\dir\`, ""},
+	}
+	if err := Write(path, want); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	wantFile := "path = \"C:\\Program Files\\tool\\tool.exe\"\nargs = --config \"C:\\My Files\\tool.toml\" \"say \\\"hi\\\"\" C:\\dir\\ \"\"\n"
+	if string(data) != wantFile {
+		t.Errorf("Write() wrote %q, want %q", data, wantFile)
+	}
+	got, err = Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if got.Path != want.Path || !slices.Equal(got.Args, want.Args) {
+		t.Errorf("Read() = %q %q, want %q %q", got.Path, got.Args, want.Path, want.Args)
+	}
+
+	// written by scoop, unquoted
+	os.WriteFile(path, []byte("path = C:\\scoop\\apps\\jq\\current\\jq.exe\r\n"), 0o644)
+	if got, err := Read(path); err != nil || got.Path != `C:\scoop\apps\jq\current\jq.exe` {
+		t.Errorf("Read() = %+v, %v", got, err)
+	}
+
+	os.WriteFile(path, []byte("args = -v\n"), 0o644)
+	if _, err := Read(path); err == nil {
+		t.Error("Read() should fail for a shim without a path")
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs /bin/sh")
+	}
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "tool.sh")
+	os.WriteFile(target, []byte("#!/bin/sh\necho \"$@\"\nexit 3\n"), 0o755)
+	launcher := filepath.Join(tmpDir, "tool")
+	if err := Write(FileFor(launcher), Shim{Path: target, Args: []string{"--shim"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	code, err := Run(launcher, []string{"a", "b"}, nil, &stdout, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("Run() exit code = %d, want 3", code)
+	}
+	if got := stdout.String(); got != "--shim a b\n" {
+		t.Errorf("Run() output = %q, want the launcher's arguments passed on", got)
+	}
+
+	if _, err := Run(filepath.Join(t.TempDir(), "missing"), nil, nil, nil, nil); err == nil {
+		t.Error("Run() should fail without a shim file")
+	}
+}
@@ -103,27 +103,3 @@ func IsBinaryExecutable(path string) (bool, *types.Type, error) {
 
 	return false, nil, nil
 }
-
-func SymlinkBinToPath(binPath, destPath string) error {
-	isBin, err := IsValidBinaryExecutable(binPath)
-	if err != nil {
-		return err
-	}
-	if !isBin {
-		return fmt.Errorf("provided dir is not a binary")
-	}
-
-	if _, err := os.Lstat(destPath); err == nil {
-		if err := os.Remove(destPath); err != nil {
-			return fmt.Errorf("failed to remove existing symlink at %s\n%w", destPath, err)
-		}
-	} else if !os.IsNotExist(err) {
-		return fmt.Errorf("failed to check destination path: \n%w", err)
-	}
-
-	if err := os.Symlink(binPath, destPath); err != nil {
-		return err
-	}
-
-	return nil
-}
@@ -0,0 +1,135 @@
+package sysutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"parm/pkg/shim"
+	"path/filepath"
+)
+
+// How executables are made available in the bin dir.
+type LinkMode string
+
+const (
+	LinkSymlink  LinkMode = "symlink"
+	LinkHardlink LinkMode = "hardlink"
+	LinkCopy     LinkMode = "copy"
+	// a copy of the shim launcher, which runs the executable named in a ".shim" file next to it.
+	// Unlike symlinks, needs neither admin rights nor developer mode on Windows.
+	LinkShim LinkMode = "shim"
+)
+
+var LinkModes = []LinkMode{LinkSymlink, LinkHardlink, LinkCopy, LinkShim}
+
+// returns the launcher copied for LinkShim, replaced in tests
+var ShimLauncher = shim.Launcher
+
+// Returned by ReadBinLink for hardlinks and copies, which don't record what they were made from.
+var ErrUnknownTarget = errors.New("link target unknown")
+
+func ParseLinkMode(s string) (LinkMode, error) {
+	for _, mode := range LinkModes {
+		if string(mode) == s {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown link mode %q, want symlink, hardlink, copy or shim", s)
+}
+
+// Returns the link mode used if none is configured: shims on Windows, where symlinks need admin rights, symlinks elsewhere.
+func DefaultLinkMode(goos string) LinkMode {
+	if goos == "windows" {
+		return LinkShim
+	}
+	return LinkSymlink
+}
+
+// Makes the executable at binPath available at destPath, replacing whatever is there.
+func LinkBinToPath(mode LinkMode, binPath, destPath string) error {
+	isBin, err := IsValidBinaryExecutable(binPath)
+	if err != nil {
+		return err
+	}
+	if !isBin {
+		return fmt.Errorf("provided dir is not a binary")
+	}
+
+	if err := RemoveBinLink(destPath); err != nil {
+		return fmt.Errorf("failed to remove existing link at %s\n%w", destPath, err)
+	}
+
+	switch mode {
+	case LinkSymlink:
+		return os.Symlink(binPath, destPath)
+	case LinkHardlink:
+		return os.Link(binPath, destPath)
+	case LinkCopy:
+		return copyExecutable(binPath, destPath)
+	case LinkShim:
+		launcher, err := ShimLauncher()
+		if err != nil {
+			return err
+		}
+		if err := copyExecutable(launcher, destPath); err != nil {
+			return err
+		}
+		return shim.Write(shim.FileFor(destPath), shim.Shim{Path: binPath})
+	}
+	return fmt.Errorf("unknown link mode %q", mode)
+}
+
+// Returns the executable a link in the bin dir runs, for symlinks and shims.
+func ReadBinLink(destPath string) (string, error) {
+	if target, err := os.Readlink(destPath); err == nil {
+		return target, nil
+	}
+	if _, err := os.Lstat(destPath); err != nil {
+		return "", err
+	}
+	s, err := shim.Read(shim.FileFor(destPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrUnknownTarget
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.Path, nil
+}
+
+// Removes a link from the bin dir, along with its shim file if it has one. Does nothing if there's no link.
+func RemoveBinLink(destPath string) error {
+	if err := os.Remove(destPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(shim.FileFor(destPath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func copyExecutable(srcPath, destPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(destPath), "."+filepath.Base(destPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), destPath)
+}
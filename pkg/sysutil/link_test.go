@@ -0,0 +1,90 @@
+package sysutil
+
+import (
+	"errors"
+	"os"
+	"parm/pkg/shim"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// ELF magic, padded to the minimum header size
+var elfHeader = append([]byte{0x7f, 0x45, 0x4c, 0x46}, make([]byte, 60)...)
+
+func TestLinkBinToPath(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping Linux-specific test")
+	}
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "tool")
+	launcher := filepath.Join(tmpDir, shim.LauncherName)
+	os.WriteFile(target, elfHeader, 0o755)
+	os.WriteFile(launcher, append(elfHeader, "launcher"...), 0o755)
+
+	orig := ShimLauncher
+	ShimLauncher = func() (string, error) { return launcher, nil }
+	defer func() { ShimLauncher = orig }()
+
+	tests := []struct {
+		mode       LinkMode
+		wantTarget bool
+		// the file the link should have the contents of
+		wantContent string
+	}{
+		{LinkSymlink, true, target},
+		{LinkHardlink, false, target},
+		{LinkCopy, false, target},
+		{LinkShim, true, launcher},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			dest := filepath.Join(tmpDir, "bin-"+string(tt.mode))
+			// replaces whatever is there
+			os.WriteFile(dest, []byte("stale"), 0o644)
+
+			if err := LinkBinToPath(tt.mode, target, dest); err != nil {
+				t.Fatalf("LinkBinToPath() error: %v", err)
+			}
+			got, _ := os.ReadFile(dest)
+			want, _ := os.ReadFile(tt.wantContent)
+			if string(got) != string(want) {
+				t.Errorf("LinkBinToPath() made a link with the contents of another file")
+			}
+
+			current, err := ReadBinLink(dest)
+			switch {
+			case tt.wantTarget && current != target:
+				t.Errorf("ReadBinLink() = %q, %v, want %q", current, err, target)
+			case !tt.wantTarget && !errors.Is(err, ErrUnknownTarget):
+				t.Errorf("ReadBinLink() error = %v, want ErrUnknownTarget", err)
+			}
+
+			if err := RemoveBinLink(dest); err != nil {
+				t.Fatalf("RemoveBinLink() error: %v", err)
+			}
+			for _, path := range []string{dest, shim.FileFor(dest)} {
+				if _, err := os.Lstat(path); !os.IsNotExist(err) {
+					t.Errorf("RemoveBinLink() left %s behind", filepath.Base(path))
+				}
+			}
+			if _, err := os.Stat(target); err != nil {
+				t.Errorf("RemoveBinLink() removed the executable: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseLinkMode(t *testing.T) {
+	for _, mode := range LinkModes {
+		if got, err := ParseLinkMode(string(mode)); err != nil || got != mode {
+			t.Errorf("ParseLinkMode(%q) = %q, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseLinkMode("junction"); err == nil {
+		t.Error("ParseLinkMode() should fail for an unknown mode")
+	}
+	if DefaultLinkMode("windows") != LinkShim || DefaultLinkMode("linux") != LinkSymlink {
+		t.Error("DefaultLinkMode() should use shims on Windows only")
+	}
+}
@@ -0,0 +1,80 @@
+package sysutil
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+const (
+	LibcGnu  = "gnu"
+	LibcMusl = "musl"
+)
+
+// Detects the C library of the host. Returns "" on anything but Linux.
+func DetectLibc() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	return detectLibc("/")
+}
+
+func detectLibc(root string) string {
+	// musl's dynamic loader is the one thing every musl system has, e.g. /lib/ld-musl-x86_64.so.1
+	if matches, _ := filepath.Glob(filepath.Join(root, "lib", "ld-musl-*.so.1")); len(matches) > 0 {
+		return LibcMusl
+	}
+	return LibcGnu
+}
+
+// Returns the ARM architecture version of the host (5, 6 or 7) when running on 32-bit ARM, 0 otherwise.
+// ARMv8 CPUs running 32-bit code report 7.
+func ArmVersion() int {
+	if runtime.GOARCH != "arm" {
+		return 0
+	}
+	if runtime.GOOS == "linux" {
+		if v := cpuinfoArmVersion("/proc/cpuinfo"); v > 0 {
+			return min(v, 7)
+		}
+	}
+	// fall back to the version parm itself was built for
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "GOARM" {
+				if v, err := strconv.Atoi(strings.TrimSuffix(s.Value, ",softfloat")); err == nil {
+					return v
+				}
+			}
+		}
+	}
+	return 7
+}
+
+func cpuinfoArmVersion(path string) int {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		key, val, ok := strings.Cut(sc.Text(), ":")
+		if !ok || strings.TrimSpace(key) != "CPU architecture" {
+			continue
+		}
+		// e.g. "7" or "AArch64"
+		if v, err := strconv.Atoi(strings.TrimSpace(val)); err == nil {
+			return v
+		}
+		if strings.EqualFold(strings.TrimSpace(val), "aarch64") {
+			return 8
+		}
+	}
+	return 0
+}
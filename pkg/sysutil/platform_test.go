@@ -0,0 +1,36 @@
+package sysutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectLibc(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "lib"), 0o755)
+	if got := detectLibc(root); got != LibcGnu {
+		t.Errorf("detectLibc() = %q without a musl loader, want gnu", got)
+	}
+
+	os.WriteFile(filepath.Join(root, "lib", "ld-musl-x86_64.so.1"), nil, 0o755)
+	if got := detectLibc(root); got != LibcMusl {
+		t.Errorf("detectLibc() = %q with a musl loader, want musl", got)
+	}
+}
+
+func TestCpuinfoArmVersion(t *testing.T) {
+	tests := map[string]int{
+		"processor\t: 0\nCPU architecture: 7\nCPU variant\t: 0x0\n":       7,
+		"model name\t: ARMv6-compatible processor\nCPU architecture: 6\n": 6,
+		"CPU architecture: AArch64\n":                                     8,
+		"processor\t: 0\nvendor_id\t: GenuineIntel\n":                     0,
+	}
+	for content, want := range tests {
+		path := filepath.Join(t.TempDir(), "cpuinfo")
+		os.WriteFile(path, []byte(content), 0o644)
+		if got := cpuinfoArmVersion(path); got != want {
+			t.Errorf("cpuinfoArmVersion(%q) = %d, want %d", content, got, want)
+		}
+	}
+}
@@ -48,3 +48,8 @@ func IsProcessRunning(execPath string) (bool, error) {
 
 	return false, nil
 }
+
+func IsPidRunning(pid int) bool {
+	ok, err := process.PidExists(int32(pid))
+	return err == nil && ok
+}
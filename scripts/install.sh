@@ -126,6 +126,11 @@ fi
 [ -n "${src:-}" ] && [ -f "$src" ] || { echo "error: parm binary not found after extract" >&2; exit 1; }
 chmod +x "$src"
 mv -f "$src" "$bin_dir/parm"
+# launcher used when link_mode is "shim"
+if [ -f "$(dirname "$src")/parm-shim" ]; then
+  chmod +x "$(dirname "$src")/parm-shim"
+  mv -f "$(dirname "$src")/parm-shim" "$bin_dir/parm-shim"
+fi
 
 echo "Installed: $bin_dir/parm"
 